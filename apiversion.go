@@ -0,0 +1,120 @@
+package whatsapp
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// apiVersionRegexp matches Graph API versions such as "v22.0".
+var apiVersionRegexp = regexp.MustCompile(`^v[1-9][0-9]*\.[0-9]+$`)
+
+// ValidateAPIVersion checks that version is a Graph API version such as "v22.0".
+func ValidateAPIVersion(version string) error {
+	if !apiVersionRegexp.MatchString(version) {
+		return fmt.Errorf("invalid API version %q: want a version like %q", version, DefaultAPIVersion)
+	}
+	return nil
+}
+
+// WithAPIVersion sets the Graph API version used by the client. An invalid
+// version makes every request of the client fail.
+// https://developers.facebook.com/docs/graph-api/guides/versioning
+func WithAPIVersion(version string) ClientOption {
+	return func(wa *Client) {
+		wa.APIVersion = version
+		if err := ValidateAPIVersion(version); err != nil {
+			wa.configErr = err
+		}
+	}
+}
+
+// APIVersionWarning reports that the Graph API served a request with a
+// different version than requested, or announced the deprecation of the version.
+type APIVersionWarning struct {
+	// Requested is the configured API version.
+	Requested string
+	// Served is the version that handled the request, from the facebook-api-version header.
+	// Graph API upgrades requests for expired versions to the oldest available one.
+	Served string
+	// Deprecation is the value of the Deprecation header, if any.
+	Deprecation string
+	// Sunset is the value of the Sunset header, if any.
+	Sunset string
+}
+
+// String describes the warning.
+func (w APIVersionWarning) String() string {
+	var parts []string
+	if w.Served != "" && w.Served != w.Requested {
+		parts = append(parts, fmt.Sprintf("requested Graph API %s, served by %s", w.Requested, w.Served))
+	}
+	if w.Deprecation != "" {
+		parts = append(parts, fmt.Sprintf("Graph API %s is deprecated (%s)", w.Requested, w.Deprecation))
+	}
+	if w.Sunset != "" {
+		parts = append(parts, fmt.Sprintf("Graph API %s sunsets %s", w.Requested, w.Sunset))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// WithAPIVersionWarning makes the client call fn when the Graph API serves a
+// request with a newer version than configured, or announces the deprecation
+// of the configured version. Every distinct warning is reported once.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithAPIVersionWarning(func(w APIVersionWarning) {
+//	    log.Printf("Upgrade the Graph API version: %s", w)
+//	}))
+func WithAPIVersionWarning(fn func(APIVersionWarning)) ClientOption {
+	return func(wa *Client) {
+		wa.OnAPIVersionWarning = fn
+	}
+}
+
+// checkAPIVersion reports version warnings of the response to OnAPIVersionWarning.
+func (wa *Client) checkAPIVersion(resp *http.Response) {
+	if wa.OnAPIVersionWarning == nil {
+		return
+	}
+	warning := APIVersionWarning{
+		Requested:   wa.APIVersion,
+		Served:      resp.Header.Get("Facebook-Api-Version"),
+		Deprecation: resp.Header.Get("Deprecation"),
+		Sunset:      resp.Header.Get("Sunset"),
+	}
+	if compareAPIVersions(warning.Served, warning.Requested) <= 0 && warning.Deprecation == "" && warning.Sunset == "" {
+		return
+	}
+	if _, reported := wa.apiVersionWarnings.LoadOrStore(warning, true); !reported {
+		wa.OnAPIVersionWarning(warning)
+	}
+}
+
+// compareAPIVersions compares two API versions like "v22.0" numerically. Versions
+// that can't be parsed compare equal to anything.
+func compareAPIVersions(a, b string) int {
+	am, an, aok := parseAPIVersion(a)
+	bm, bn, bok := parseAPIVersion(b)
+	switch {
+	case !aok || !bok:
+		return 0
+	case am != bm:
+		return am - bm
+	default:
+		return an - bn
+	}
+}
+
+func parseAPIVersion(version string) (major, minor int, ok bool) {
+	if !apiVersionRegexp.MatchString(version) {
+		return 0, 0, false
+	}
+	majorStr, minorStr, _ := strings.Cut(version[1:], ".")
+	major, _ = strconv.Atoi(majorStr)
+	minor, _ = strconv.Atoi(minorStr)
+	return major, minor, true
+}
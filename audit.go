@@ -0,0 +1,213 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// auditRedacted replaces the values of redacted fields in audited payloads.
+const auditRedacted = "[REDACTED]"
+
+// DefaultAuditRedactedFields are the fields of audited payloads an Auditor
+// redacts if its RedactFields is nil: recipients, texts, links, file names,
+// and the personal data of contacts and locations.
+var DefaultAuditRedactedFields = []string{
+	"to", "body", "caption", "text", "link", "filename", "payload",
+	"phone", "email", "address", "latitude", "longitude",
+	"formatted_name", "first_name", "last_name", "middle_name",
+}
+
+// AuditRecord describes an outbound API request for compliance logs. It
+// contains no access tokens, and the personal data of the request is hashed
+// or redacted, see Auditor.
+type AuditRecord struct {
+	// Time is the time the request was sent, and Duration how long it took.
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	// Method and Endpoint are the HTTP method and the URL path of the request.
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	// StatusCode is the HTTP status code of the response, zero if the request
+	// failed without one, in which case Error describes the failure.
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Recipient is the hash of the recipient of messages, see
+	// Auditor.HashRecipient, or the ID of the group of group messages.
+	Recipient string `json:"recipient,omitempty"`
+	// MessageType is the type of messages.
+	MessageType MessageType `json:"message_type,omitempty"`
+	// MessageID is the WhatsApp message ID (wamid) of messages accepted by the
+	// API, and MessageStatus the pacing status of template messages, if any.
+	MessageID     string `json:"message_id,omitempty"`
+	MessageStatus string `json:"message_status,omitempty"`
+	// Payload is the JSON body of the request with the redacted fields
+	// replaced, if the Auditor includes payloads.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Auditor produces an AuditRecord for every API request of a client, for
+// compliance pipelines that must log all customer communication. Recipients
+// are logged as keyed hashes, so the records of a customer can be found
+// without storing their phone number. Combine it with WithAppSecretProof to
+// sign the requests as well.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithAppSecretProof(appSecret), WithAuditor(&Auditor{
+//	    HashKey:        auditKey,
+//	    IncludePayload: true,
+//	    Record:         AuditWriter(auditLog),
+//	}))
+type Auditor struct {
+	// Record receives the records. It is called after every request, and must
+	// be safe for concurrent use.
+	Record func(ctx context.Context, record *AuditRecord)
+	// HashKey is the key of the HMAC-SHA256 recipient hashes. Without it, the
+	// Auditor hashes with a random key of its own, so the hashes of a
+	// recipient only match while the process runs; set it to look up the
+	// records of a customer across restarts.
+	HashKey []byte
+	// IncludePayload adds the redacted JSON bodies of the requests to the records.
+	IncludePayload bool
+	// RedactFields are the names of the fields whose values are redacted from
+	// payloads at any depth, DefaultAuditRedactedFields if nil.
+	RedactFields []string
+
+	randomKeyOnce sync.Once
+	randomKey     []byte // randomKey is the hash key if HashKey is empty.
+}
+
+// WithAuditor makes the client report every API request to auditor.
+func WithAuditor(auditor *Auditor) ClientOption {
+	return func(wa *Client) {
+		wa.Auditor = auditor
+	}
+}
+
+// AuditWriter returns a Record function of an Auditor writing every record to
+// w as a line of JSON. Write errors are ignored.
+func AuditWriter(w io.Writer) func(ctx context.Context, record *AuditRecord) {
+	var mu sync.Mutex
+	return func(ctx context.Context, record *AuditRecord) {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(append(line, '\n'))
+	}
+}
+
+// HashRecipient returns the hash recipients are logged as, so the records of
+// a customer can be looked up. Formatting, such as "+" and spaces, is ignored.
+func (a *Auditor) HashRecipient(recipient string) string {
+	mac := hmac.New(sha256.New, a.hashKey())
+	mac.Write([]byte(phoneDigits(recipient)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashKey returns HashKey, or the random key of the Auditor if it is empty.
+// Phone numbers are too few for unkeyed hashes not to be reversed.
+func (a *Auditor) hashKey() []byte {
+	if len(a.HashKey) > 0 {
+		return a.HashKey
+	}
+	a.randomKeyOnce.Do(func() {
+		a.randomKey = make([]byte, sha256.Size)
+		rand.Read(a.randomKey)
+	})
+	return a.randomKey
+}
+
+// audit reports the request to the auditor. The response body of accepted
+// messages is read to log their ID, and replaced with a copy.
+func (a *Auditor) audit(req *http.Request, payload []byte, resp *http.Response, err error, start time.Time) {
+	record := &AuditRecord{
+		Time:     start,
+		Duration: time.Since(start),
+		Method:   req.Method,
+		Endpoint: req.URL.Path,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.StatusCode = resp.StatusCode
+	}
+
+	if len(payload) > 0 {
+		var message struct {
+			To            string        `json:"to"`
+			Type          MessageType   `json:"type"`
+			RecipientType RecipientType `json:"recipient_type"`
+		}
+		if json.Unmarshal(payload, &message) == nil && message.To != "" {
+			if message.RecipientType == RecipientTypeGroup {
+				record.Recipient = message.To
+			} else {
+				record.Recipient = a.HashRecipient(message.To)
+			}
+			record.MessageType = message.Type
+		}
+		if a.IncludePayload {
+			record.Payload = a.redact(payload)
+		}
+	}
+
+	if record.Recipient != "" && err == nil && resp.StatusCode == http.StatusOK {
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		var response MessagesResponse
+		if readErr == nil && json.Unmarshal(body, &response) == nil && len(response.Messages) > 0 {
+			record.MessageID = response.Messages[0].ID
+			record.MessageStatus = response.Messages[0].MessageStatus
+		}
+	}
+	a.Record(req.Context(), record)
+}
+
+// redact returns payload with the values of the redacted fields replaced.
+func (a *Auditor) redact(payload []byte) json.RawMessage {
+	var value any
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return nil
+	}
+	fields := a.RedactFields
+	if fields == nil {
+		fields = DefaultAuditRedactedFields
+	}
+	var walk func(value any)
+	walk = func(value any) {
+		switch value := value.(type) {
+		case map[string]any:
+			for key, field := range value {
+				if slices.Contains(fields, key) {
+					value[key] = auditRedacted
+				} else {
+					walk(field)
+				}
+			}
+		case []any:
+			for _, element := range value {
+				walk(element)
+			}
+		}
+	}
+	walk(value)
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
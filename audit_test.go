@@ -0,0 +1,33 @@
+package whatsapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestAuditorHashRecipient(t *testing.T) {
+	const recipient = "15551234567"
+	unkeyed := sha256.Sum256([]byte(recipient))
+
+	auditor := &Auditor{}
+	hash := auditor.HashRecipient(recipient)
+	if hash == hex.EncodeToString(unkeyed[:]) {
+		t.Error("HashRecipient without HashKey is the plain SHA-256 of the phone number")
+	}
+	if got := auditor.HashRecipient("+1 555-123-4567"); got != hash {
+		t.Errorf("HashRecipient of the formatted number = %s, want %s", got, hash)
+	}
+	if other := (&Auditor{}).HashRecipient(recipient); other == hash {
+		t.Error("Auditors without HashKey share their hash key")
+	}
+
+	key := []byte("audit key")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(recipient))
+	want := hex.EncodeToString(mac.Sum(nil))
+	if got := (&Auditor{HashKey: key}).HashRecipient("+" + recipient); got != want {
+		t.Errorf("HashRecipient with HashKey = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,271 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MaxBatchSize is the maximum number of requests in a single Graph API batch.
+// https://developers.facebook.com/docs/graph-api/batch-requests
+const MaxBatchSize = 50
+
+// BatchRequest is a single request within a Graph API batch.
+// https://developers.facebook.com/docs/graph-api/batch-requests
+type BatchRequest struct {
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// RelativeURL is the request path relative to the API version, e.g. "<phone-number-id>/messages".
+	RelativeURL string `json:"relative_url"`
+	// Body is the URL-encoded request body, see Batch.Add.
+	Body string `json:"body,omitempty"`
+	// Name allows referencing the result of this request from later requests in the batch.
+	Name string `json:"name,omitempty"`
+}
+
+// BatchResponse is the response to a single request within a Graph API batch.
+type BatchResponse struct {
+	Code    int `json:"code"`
+	Headers []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"headers"`
+	Body string `json:"body"`
+}
+
+// Decode decodes the response body into v, or returns the API error
+// if the request failed.
+func (br *BatchResponse) Decode(v any) error {
+	if br == nil {
+		// Graph API returns null for requests that were not completed
+		return fmt.Errorf("batch request was not completed")
+	}
+	if br.Code != http.StatusOK {
+		return ScrubError(parseAPIError(br.Code, []byte(br.Body), fmt.Errorf("want 200 OK, got %d", br.Code)))
+	}
+	return json.Unmarshal([]byte(br.Body), v)
+}
+
+// Batch packs multiple Graph API calls, such as message sends, into a single
+// HTTP request to reduce the request overhead of high-volume senders.
+//
+// Example usage:
+//
+//	batch := client.NewBatch()
+//	for _, recipient := range recipients {
+//	    batch.AddMessage(&Request{
+//	        MessagingProduct: MessagingProductWhatsApp,
+//	        RecipientType:    RecipientTypeIndividual,
+//	        To:               recipient,
+//	        Type:             MessageTypeText,
+//	        Text:             &SendTextParams{Body: "Hello!"},
+//	    })
+//	}
+//
+//	responses, err := batch.Do(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for i, response := range responses {
+//	    var sent MessagesResponse
+//	    if err := response.Decode(&sent); err != nil {
+//	        log.Printf("Message to %s failed: %v", recipients[i], err)
+//	    }
+//	}
+//
+// Messages added with AddMessage go through the checks of SendMessage that
+// don't depend on the context: SanitizeInteractive, the TestMode recipients
+// and StrictValidation. The RateLimiter is waited for once per message, and
+// sent messages are saved to the MessageStore. Batches of messages can't be
+// sent in DryRun mode. The callback data and idempotency key of the context
+// and OrderedSends don't apply to batches: the messages of a batch are sent
+// in a single request, in no guaranteed order.
+//
+// https://developers.facebook.com/docs/graph-api/batch-requests
+type Batch struct {
+	client   *Client
+	requests []BatchRequest
+	messages []batchMessage
+	err      error
+}
+
+// batchMessage is a message added to a batch, with the index of its response.
+type batchMessage struct {
+	index   int
+	request *Request
+}
+
+// NewBatch creates an empty batch executed by the client.
+func (wa *Client) NewBatch() *Batch {
+	return &Batch{client: wa}
+}
+
+// Len returns the number of requests in the batch.
+func (b *Batch) Len() int {
+	return len(b.requests)
+}
+
+// Add adds a request to the batch and returns its index in the responses.
+// The body, if not nil, is marshaled to JSON and its top-level fields are sent
+// as URL-encoded parameters, with nested objects encoded as JSON strings.
+func (b *Batch) Add(method, relativeURL string, body any) int {
+	request := BatchRequest{Method: method, RelativeURL: relativeURL}
+	if body != nil {
+		encoded, err := encodeBatchBody(body)
+		if err != nil && b.err == nil {
+			b.err = fmt.Errorf("encoding batch request %d: %w", len(b.requests), err)
+		}
+		request.Body = encoded
+	}
+	b.requests = append(b.requests, request)
+	return len(b.requests) - 1
+}
+
+// AddMessage adds a message send to the batch and returns its index in the responses.
+// The response body decodes into MessagesResponse. Messages that SendMessage
+// would reject, e.g. to recipients not allowed in TestMode, make Do fail.
+func (b *Batch) AddMessage(request *Request) int {
+	wa := b.client
+	if wa.SanitizeInteractive && request != nil && request.Interactive != nil {
+		sanitized := *request
+		sanitized.Interactive = SanitizeInteractive(request.Interactive)
+		request = &sanitized
+	}
+	err := wa.checkTestRecipient(request)
+	if err == nil && wa.StrictValidation {
+		if err = ValidateRequest(request); err != nil {
+			err = fmt.Errorf("invalid message: %w", err)
+		}
+	}
+	if err != nil && b.err == nil {
+		b.err = fmt.Errorf("batch request %d: %w", len(b.requests), err)
+	}
+	index := b.Add(http.MethodPost, wa.PhoneNumberID+"/messages", request)
+	b.messages = append(b.messages, batchMessage{index: index, request: request})
+	return index
+}
+
+// Do sends the batch and returns the responses in the order the requests were added.
+// An error is returned only if the batch as a whole failed, or if sent messages
+// couldn't be saved to the MessageStore, in which case the responses are
+// returned too; errors of individual requests are reported by
+// BatchResponse.Decode. Responses of requests that Graph API didn't complete are nil.
+func (b *Batch) Do(ctx context.Context) ([]*BatchResponse, error) {
+	wa := b.client
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.requests) == 0 {
+		return nil, fmt.Errorf("batch is empty")
+	}
+	if len(b.requests) > MaxBatchSize {
+		return nil, fmt.Errorf("batch has %d requests, maximum is %d", len(b.requests), MaxBatchSize)
+	}
+	if wa.DryRun && len(b.messages) > 0 {
+		return nil, fmt.Errorf("batch has %d messages, which can't be sent in dry-run mode", len(b.messages))
+	}
+	if wa.RateLimiter != nil {
+		for range b.messages {
+			if err := wa.RateLimiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("waiting for rate limiter: %w", err)
+			}
+		}
+	}
+
+	responses, err := b.do(ctx)
+	if err != nil {
+		if observer, ok := wa.RateLimiter.(ErrorObserver); ok && len(b.messages) > 0 {
+			observer.ObserveError(err)
+		}
+		return nil, err
+	}
+	if wa.MessageStore != nil {
+		if err := b.storeMessages(ctx, responses); err != nil {
+			// The messages were sent, so the responses are returned to prevent resending them
+			return responses, fmt.Errorf("messages sent but not stored: %w", err)
+		}
+	}
+	return responses, nil
+}
+
+// storeMessages saves the sent messages of the batch to the MessageStore.
+func (b *Batch) storeMessages(ctx context.Context, responses []*BatchResponse) error {
+	for _, message := range b.messages {
+		if message.index >= len(responses) {
+			continue
+		}
+		var response MessagesResponse
+		if responses[message.index].Decode(&response) != nil {
+			continue
+		}
+		if err := b.client.storeMessages(ctx, message.request, &response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// do sends the batch request.
+func (b *Batch) do(ctx context.Context) ([]*BatchResponse, error) {
+	ctx, cancel := withDefaultTimeout(ctx, b.client.SendTimeout)
+	defer cancel()
+
+	batch, err := json.Marshal(b.requests)
+	if err != nil {
+		return nil, fmt.Errorf("encoding batch: %w", err)
+	}
+
+	u, err := b.client.graphURL()
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+	form := url.Values{"batch": {string(batch)}, "include_headers": {"false"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, b.client.scrub(fmt.Errorf("creating request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := b.client.do(req, batch)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, b.client.scrub(decodeAPIError(resp, fmt.Errorf("want 200 OK, got %s", resp.Status)))
+	}
+
+	var responses []*BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return responses, nil
+}
+
+// encodeBatchBody converts a JSON request body into the URL-encoded form expected
+// in batch requests.
+func encodeBatchBody(body any) (string, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return "", fmt.Errorf("body must be a JSON object: %w", err)
+	}
+
+	form := make(url.Values, len(fields))
+	for name, raw := range fields {
+		var str string
+		if err := json.Unmarshal(raw, &str); err == nil {
+			form.Set(name, str)
+		} else {
+			form.Set(name, string(raw))
+		}
+	}
+	return form.Encode(), nil
+}
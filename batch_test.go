@@ -0,0 +1,130 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// batchHandler answers every batch with a sent message per request.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, `[{"code":200,"body":"{\"messages\":[{\"id\":\"wamid.1\"}]}"},{"code":400,"body":"{\"error\":{\"message\":\"Invalid parameter\",\"code\":100}}"}]`)
+}
+
+func TestBatchRejectsMessagesSendMessageRejects(t *testing.T) {
+	invalid := testTextRequest("")
+	invalid.Text = nil
+	for _, tc := range []struct {
+		name    string
+		opts    []ClientOption
+		request *Request
+		want    error
+	}{
+		{"test mode", []ClientOption{WithTestMode(TestMode{Recipients: []string{"15550000000"}})}, testTextRequest("hello"), ErrRecipientNotAllowed},
+		{"dry run", []ClientOption{WithDryRun(nil)}, testTextRequest("hello"), nil},
+		{"strict validation", []ClientOption{func(wa *Client) { wa.StrictValidation = true }}, invalid, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var sent bool
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				sent = true
+				batchHandler(w, r)
+			}, tc.opts...)
+			batch := client.NewBatch()
+			batch.AddMessage(tc.request)
+
+			_, err := batch.Do(context.Background())
+			if err == nil {
+				t.Fatal("Do succeeded, want an error")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("Do() = %v, want %v", err, tc.want)
+			}
+			if sent {
+				t.Error("batch was sent")
+			}
+		})
+	}
+}
+
+func TestBatchStoresSentMessages(t *testing.T) {
+	store := NewMemoryMessageStore()
+	client := newTestClient(t, batchHandler)
+	client.MessageStore = store
+
+	batch := client.NewBatch()
+	request := testTextRequest("hello")
+	batch.AddMessage(request)
+	batch.AddMessage(testTextRequest("rejected"))
+	responses, err := batch.Do(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("got %d responses, want 2", len(responses))
+	}
+
+	stored, err := store.Get(context.Background(), "wamid.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Request.Text.Body != request.Text.Body {
+		t.Errorf("stored request %+v, want %+v", stored.Request, request)
+	}
+}
+
+type countingRateLimiter struct {
+	waits    int
+	observed []error
+}
+
+func (l *countingRateLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return nil
+}
+
+func (l *countingRateLimiter) ObserveError(err error) {
+	l.observed = append(l.observed, err)
+}
+
+func TestBatchWaitsForRateLimiterPerMessage(t *testing.T) {
+	limiter := &countingRateLimiter{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"Too many calls","code":4}}`, http.StatusBadRequest)
+	})
+	client.RateLimiter = limiter
+
+	batch := client.NewBatch()
+	batch.AddMessage(testTextRequest("first"))
+	batch.AddMessage(testTextRequest("second"))
+	if _, err := batch.Do(context.Background()); err == nil {
+		t.Fatal("Do succeeded, want an error")
+	}
+	if limiter.waits != 2 {
+		t.Errorf("waited %d times for the rate limiter, want 2", limiter.waits)
+	}
+	if len(limiter.observed) != 1 {
+		t.Errorf("observed %d errors, want 1", len(limiter.observed))
+	}
+}
+
+func TestBatchSendTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+		batchHandler(w, r)
+	}, WithSendTimeout(10*time.Millisecond))
+
+	batch := client.NewBatch()
+	batch.AddMessage(testTextRequest("first"))
+	if _, err := batch.Do(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
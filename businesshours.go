@@ -0,0 +1,182 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultAutoReplyWindow is the period during which an AutoResponder replies
+// at most once to the same user, if its Window is not set.
+const DefaultAutoReplyWindow = 12 * time.Hour
+
+// OpeningHours is a period of a day, as offsets from midnight. End must be
+// after Start, and at most 24 hours.
+type OpeningHours struct {
+	Start, End time.Duration
+}
+
+// BusinessHours is a weekly schedule of opening hours in a time zone.
+//
+// Example usage:
+//
+//	nineToFive := []OpeningHours{{Start: 9 * time.Hour, End: 17 * time.Hour}}
+//	hours := &BusinessHours{
+//	    Location: berlin,
+//	    Weekly: map[time.Weekday][]OpeningHours{
+//	        time.Monday: nineToFive, time.Tuesday: nineToFive, time.Wednesday: nineToFive,
+//	        time.Thursday: nineToFive, time.Friday: nineToFive,
+//	    },
+//	}
+type BusinessHours struct {
+	// Location is the time zone of the schedule. UTC is used if it's nil.
+	Location *time.Location
+	// Weekly lists the opening hours of every day of the week. Days missing
+	// from it are closed.
+	Weekly map[time.Weekday][]OpeningHours
+}
+
+// Validate checks that all opening hours are within a day.
+func (bh *BusinessHours) Validate() error {
+	for day, hours := range bh.Weekly {
+		for _, h := range hours {
+			if h.Start < 0 || h.End <= h.Start || h.End > 24*time.Hour {
+				return fmt.Errorf("invalid opening hours %s-%s on %s", h.Start, h.End, day)
+			}
+		}
+	}
+	return nil
+}
+
+// IsOpen reports whether t is within the opening hours.
+func (bh *BusinessHours) IsOpen(t time.Time) bool {
+	location := bh.Location
+	if location == nil {
+		location = time.UTC
+	}
+	t = t.In(location)
+	// The wall clock, rather than the time elapsed since midnight, which differs on DST changes
+	hour, minute, second := t.Clock()
+	sinceMidnight := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+	for _, h := range bh.Weekly[t.Weekday()] {
+		if sinceMidnight >= h.Start && sinceMidnight < h.End {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoResponder replies to messages received outside business hours, at most
+// once per user within Window, e.g. to tell customers when support will be
+// back. Its Middleware attaches it to a Router.
+//
+// Example usage:
+//
+//	responder := NewAutoResponder(client, hours, "Thanks for your message! We're open Mon-Fri 9:00-17:00 and will get back to you then.")
+//	router.Use(responder.Middleware)
+type AutoResponder struct {
+	// Client sends the replies.
+	Client *Client
+	// Hours is the schedule outside of which messages are answered.
+	Hours *BusinessHours
+	// Text is the body of the reply.
+	Text string
+	// Template, if set, is sent instead of Text.
+	Template *SendTemplateParams
+	// Window is the period during which the same user gets at most one reply,
+	// DefaultAutoReplyWindow if zero.
+	Window time.Duration
+	// SkipHandler keeps the messages answered by the responder from reaching
+	// the next handler. By default they are passed on, e.g. to be queued for agents.
+	SkipHandler bool
+
+	mu       sync.Mutex
+	replied  map[string]time.Time // replied are the times of the last replies by user.
+	sweepAt  int                  // sweepAt is the size of replied at which expired replies are removed.
+	replying map[string]bool      // replying are the users whose reply is being sent.
+}
+
+// NewAutoResponder creates an AutoResponder replying with text outside hours.
+func NewAutoResponder(client *Client, hours *BusinessHours, text string) *AutoResponder {
+	return &AutoResponder{Client: client, Hours: hours, Text: text}
+}
+
+// Middleware returns next wrapped with the auto-replies. It is a MessageMiddleware.
+func (ar *AutoResponder) Middleware(next MessageHandler) MessageHandler {
+	return MessageHandlerFunc(func(ctx context.Context, message *IncomingMessage) error {
+		now := time.Now()
+		if !ar.shouldReply(message, now) {
+			return next.HandleMessage(ctx, message)
+		}
+		err := ar.reply(ctx, message)
+		ar.repliedTo(message, now, err == nil)
+		if err != nil {
+			err = fmt.Errorf("auto-reply to %s: %w", message.From, err)
+		}
+		if ar.SkipHandler {
+			return err
+		}
+		return errors.Join(err, next.HandleMessage(ctx, message))
+	})
+}
+
+// shouldReply reports whether message, received at now, gets an auto-reply.
+// If so, further messages of the user get none until repliedTo is called.
+func (ar *AutoResponder) shouldReply(message *IncomingMessage, now time.Time) bool {
+	switch message.Unwrap().Type {
+	case MessageTypeReaction, MessageTypeSystem, MessageTypeUnsupported:
+		return false
+	}
+	if message.From == "" || message.IsGroup() || ar.Hours.IsOpen(now) {
+		return false
+	}
+
+	window := orDefault(ar.Window, DefaultAutoReplyWindow)
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	if last, ok := ar.replied[message.From]; (ok && now.Sub(last) < window) || ar.replying[message.From] {
+		return false
+	}
+	if ar.replying == nil {
+		ar.replying = make(map[string]bool)
+	}
+	ar.replying[message.From] = true
+	return true
+}
+
+// repliedTo records the end of the auto-reply to message, received at now.
+// Only sent replies count, so the next message is answered if sending failed.
+func (ar *AutoResponder) repliedTo(message *IncomingMessage, now time.Time, sent bool) {
+	window := orDefault(ar.Window, DefaultAutoReplyWindow)
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	delete(ar.replying, message.From)
+	if !sent {
+		return
+	}
+	if ar.replied == nil {
+		ar.replied = make(map[string]time.Time)
+	}
+	if len(ar.replied) >= ar.sweepAt {
+		for user, last := range ar.replied {
+			if now.Sub(last) >= window {
+				delete(ar.replied, user)
+			}
+		}
+		ar.sweepAt = max(2*len(ar.replied), 1024)
+	}
+	ar.replied[message.From] = now
+}
+
+// reply sends the auto-reply to the sender of message.
+func (ar *AutoResponder) reply(ctx context.Context, message *IncomingMessage) error {
+	var err error
+	if ar.Template != nil {
+		_, err = ar.Client.SendTemplate(ctx, message.From, ar.Template)
+	} else {
+		_, err = ar.Client.SendText(ctx, message.From, &SendTextParams{Body: ar.Text})
+	}
+	return err
+}
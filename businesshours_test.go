@@ -0,0 +1,43 @@
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAutoResponderRetriesFailedReplies(t *testing.T) {
+	var (
+		sends   atomic.Int32
+		failing atomic.Bool
+	)
+	failing.Store(true)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sends.Add(1)
+		if failing.Load() {
+			http.Error(w, `{"error":{"message":"Service temporarily unavailable","code":2}}`, http.StatusServiceUnavailable)
+			return
+		}
+		messagesHandler(w, r)
+	})
+	responder := NewAutoResponder(client, &BusinessHours{}, "We're closed")
+	responder.SkipHandler = true
+	handler := responder.Middleware(MessageHandlerFunc(func(ctx context.Context, message *IncomingMessage) error {
+		return nil
+	}))
+	message := &IncomingMessage{WebhookMessage: &WebhookMessage{ID: "wamid.1", From: "15551234567", Type: MessageTypeText}}
+
+	if err := handler.HandleMessage(context.Background(), message); err == nil {
+		t.Fatal("HandleMessage succeeded, want the error of the failed reply")
+	}
+	failing.Store(false)
+	for range 2 {
+		if err := handler.HandleMessage(context.Background(), message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := sends.Load(); got != 2 {
+		t.Errorf("sent %d replies, want the failed one and a single retry", got)
+	}
+}
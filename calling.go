@@ -0,0 +1,223 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// InteractiveTypeCallPermissionRequest represents a call permission request message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/user-call-permissions
+const InteractiveTypeCallPermissionRequest InteractiveType = "call_permission_request"
+
+// CallEvent represents the event of a call webhook.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/reference#call-webhooks
+type CallEvent string
+
+const (
+	// CallEventConnect is sent when a call is being established.
+	CallEventConnect CallEvent = "connect"
+	// CallEventTerminate is sent when a call ended.
+	CallEventTerminate CallEvent = "terminate"
+)
+
+// CallAction represents an action on a call.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/reference
+type CallAction string
+
+const (
+	// CallActionConnect starts a business-initiated call.
+	CallActionConnect CallAction = "connect"
+	// CallActionPreAccept accepts a user-initiated call before the media connection is set up.
+	CallActionPreAccept CallAction = "pre_accept"
+	// CallActionAccept accepts a user-initiated call.
+	CallActionAccept CallAction = "accept"
+	// CallActionReject rejects a user-initiated call.
+	CallActionReject CallAction = "reject"
+	// CallActionTerminate ends an active call.
+	CallActionTerminate CallAction = "terminate"
+)
+
+// CallSession contains the SDP offer or answer of a call.
+type CallSession struct {
+	// SDPType is "offer" or "answer".
+	SDPType string `json:"sdp_type"`
+	SDP     string `json:"sdp"`
+}
+
+// WebhookCall represents a call event in webhook notifications.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/reference#call-webhooks
+type WebhookCall struct {
+	ID        string         `json:"id"`
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Event     CallEvent      `json:"event"`
+	Direction string         `json:"direction,omitempty"`
+	Timestamp string         `json:"timestamp"`
+	Session   *CallSession   `json:"session,omitempty"`
+	Status    []string       `json:"status,omitempty"`
+	StartTime string         `json:"start_time,omitempty"`
+	EndTime   string         `json:"end_time,omitempty"`
+	Duration  int            `json:"duration,omitempty"`
+	Errors    []WebhookError `json:"errors,omitempty"`
+}
+
+// ManageCallParams contains parameters for initiating, accepting, rejecting or terminating a call.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/reference
+type ManageCallParams struct {
+	// CallID is the ID of the call. Required for all actions except CallActionConnect.
+	CallID string `json:"call_id,omitempty"`
+	// To is the callee of CallActionConnect.
+	To string `json:"to,omitempty"`
+	// Action is the required call action.
+	Action CallAction `json:"action"`
+	// Session is the SDP offer of CallActionConnect or the SDP answer of
+	// CallActionPreAccept and CallActionAccept.
+	Session *CallSession `json:"session,omitempty"`
+}
+
+// Validate validates the call parameters
+func (mcp *ManageCallParams) Validate() error {
+	if mcp == nil {
+		return fmt.Errorf("call parameters cannot be nil")
+	}
+	switch mcp.Action {
+	case CallActionConnect:
+		if mcp.To == "" {
+			return fmt.Errorf("to is required to connect a call")
+		}
+		if mcp.Session == nil {
+			return fmt.Errorf("session is required to connect a call")
+		}
+	case CallActionPreAccept, CallActionAccept:
+		if mcp.Session == nil {
+			return fmt.Errorf("session is required to %s a call", mcp.Action)
+		}
+		fallthrough
+	case CallActionReject, CallActionTerminate:
+		if mcp.CallID == "" {
+			return fmt.Errorf("call_id is required to %s a call", mcp.Action)
+		}
+	default:
+		return fmt.Errorf("unknown call action %q", mcp.Action)
+	}
+	return nil
+}
+
+// ManageCallResponse represents the response of a call action.
+type ManageCallResponse struct {
+	MessagingProduct MessagingProduct `json:"messaging_product"`
+	Calls            []struct {
+		ID string `json:"id"`
+	} `json:"calls,omitempty"`
+	Success bool `json:"success,omitempty"`
+}
+
+// CallSettings are the calling settings of a business phone number.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/call-settings
+type CallSettings struct {
+	// Status is "ENABLED" or "DISABLED".
+	Status string `json:"status,omitempty"`
+	// CallIconVisibility is "DEFAULT" or "DISABLE_ALL".
+	CallIconVisibility string `json:"call_icon_visibility,omitempty"`
+	// CallbackPermissionStatus is "ENABLED" or "DISABLED".
+	CallbackPermissionStatus string           `json:"callback_permission_status,omitempty"`
+	SIP                      *CallSIPSettings `json:"sip,omitempty"`
+}
+
+// CallSIPSettings configure calls to be routed to SIP servers instead of the Graph API.
+type CallSIPSettings struct {
+	// Status is "ENABLED" or "DISABLED".
+	Status  string          `json:"status"`
+	Servers []CallSIPServer `json:"servers,omitempty"`
+}
+
+// CallSIPServer is a SIP server receiving calls.
+type CallSIPServer struct {
+	Hostname             string `json:"hostname"`
+	Port                 int    `json:"port,omitempty"`
+	RequestURIUserParams string `json:"request_uri_user_params,omitempty"`
+	SIPUserPassword      string `json:"sip_user_password,omitempty"`
+	AppID                string `json:"app_id,omitempty"`
+}
+
+// SendCallPermissionRequest asks the user for permission to call them.
+// The answer arrives as an interactive call_permission_reply webhook message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/user-call-permissions
+func (wa *Client) SendCallPermissionRequest(ctx context.Context, recipient, bodyText string) (*MessagesResponse, error) {
+	request := &Request{
+		MessagingProduct: MessagingProductWhatsApp,
+		RecipientType:    RecipientTypeIndividual,
+		To:               recipient,
+		Type:             MessageTypeInteractive,
+		Interactive: &Interactive{
+			Type:   InteractiveTypeCallPermissionRequest,
+			Body:   &Body{Text: bodyText},
+			Action: &Action{Name: string(InteractiveTypeCallPermissionRequest)},
+		},
+	}
+	return wa.SendMessage(ctx, request)
+}
+
+// ManageCall initiates, accepts, rejects or terminates a call.
+//
+// Example usage:
+//
+//	for _, call := range change.Value.Calls {
+//	    if call.Event == CallEventConnect {
+//	        _, err := client.ManageCall(ctx, &ManageCallParams{
+//	            CallID:  call.ID,
+//	            Action:  CallActionAccept,
+//	            Session: &CallSession{SDPType: "answer", SDP: answer},
+//	        })
+//	    }
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/reference
+func (wa *Client) ManageCall(ctx context.Context, params *ManageCallParams) (*ManageCallResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid call parameters: %w", err)
+	}
+	request := struct {
+		MessagingProduct MessagingProduct `json:"messaging_product"`
+		*ManageCallParams
+	}{MessagingProductWhatsApp, params}
+
+	var response ManageCallResponse
+	if err := sendRequest(ctx, wa, "calls", request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetCallSettings returns the calling settings of the phone number.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/call-settings
+func (wa *Client) GetCallSettings(ctx context.Context) (*CallSettings, error) {
+	var response struct {
+		Calling CallSettings `json:"calling"`
+	}
+	query := url.Values{"fields": {"calling"}}
+	if err := graphRequest(ctx, wa, http.MethodGet, []string{wa.PhoneNumberID, "settings"}, query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response.Calling, nil
+}
+
+// UpdateCallSettings updates the calling settings of the phone number.
+// Only the non-empty settings are changed.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/calling/call-settings
+func (wa *Client) UpdateCallSettings(ctx context.Context, settings *CallSettings) (*SuccessResponse, error) {
+	if settings == nil {
+		return nil, fmt.Errorf("call settings cannot be nil")
+	}
+	request := struct {
+		Calling *CallSettings `json:"calling"`
+	}{settings}
+
+	var response SuccessResponse
+	if err := sendRequest(ctx, wa, "settings", request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,133 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// catalogProductFields are the product fields requested by CatalogClient.
+const catalogProductFields = "id,retailer_id,name,description,price,currency,availability,image_url,url"
+
+// Product represents a product of a commerce catalog.
+// https://developers.facebook.com/docs/marketing-api/reference/product-item
+type Product struct {
+	ID           string `json:"id"`
+	RetailerID   string `json:"retailer_id"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Price        string `json:"price,omitempty"`
+	Currency     string `json:"currency,omitempty"`
+	Availability string `json:"availability,omitempty"`
+	ImageURL     string `json:"image_url,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+// OrderItem is an item of an order webhook together with the catalog product it refers to.
+type OrderItem struct {
+	WebhookMessageOrderItem
+	// Product is the catalog product, or nil if it was not found in the catalog.
+	Product *Product
+}
+
+// CatalogClient looks up products of a commerce catalog connected to the
+// WhatsApp Business Account.
+//
+// Example usage:
+//
+//	if message.Order != nil {
+//	    items, err := client.Catalog(message.Order.CatalogID).EnrichOrder(ctx, message.Order)
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    for _, item := range items {
+//	        if item.Product != nil {
+//	            log.Printf("%s x %s", item.Quantity, item.Product.Name)
+//	        }
+//	    }
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/guides/sell-products-and-services
+type CatalogClient struct {
+	client    *Client
+	CatalogID string
+}
+
+// Catalog returns a client for the catalog with the given ID.
+func (wa *Client) Catalog(catalogID string) *CatalogClient {
+	return &CatalogClient{client: wa, CatalogID: catalogID}
+}
+
+// GetProduct returns the product with the given retailer ID (the product SKU
+// used in order webhooks), or an error if the catalog doesn't contain it.
+func (cc *CatalogClient) GetProduct(ctx context.Context, retailerID string) (*Product, error) {
+	products, err := cc.GetProducts(ctx, retailerID)
+	if err != nil {
+		return nil, err
+	}
+	product, ok := products[retailerID]
+	if !ok {
+		return nil, fmt.Errorf("product %q not found in catalog %s", retailerID, cc.CatalogID)
+	}
+	return product, nil
+}
+
+// GetProducts returns the products with the given retailer IDs, keyed by retailer ID.
+// Products missing from the catalog are absent from the result.
+func (cc *CatalogClient) GetProducts(ctx context.Context, retailerIDs ...string) (map[string]*Product, error) {
+	if cc.CatalogID == "" {
+		return nil, fmt.Errorf("catalog ID cannot be empty")
+	}
+	if len(retailerIDs) == 0 {
+		return map[string]*Product{}, nil
+	}
+
+	filter, err := json.Marshal(map[string]any{
+		"retailer_id": map[string]any{"is_any": retailerIDs},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding filter: %w", err)
+	}
+	query := url.Values{
+		"fields": {catalogProductFields},
+		"filter": {string(filter)},
+		"limit":  {fmt.Sprint(len(retailerIDs))},
+	}
+
+	var response struct {
+		Data []Product `json:"data"`
+	}
+	if err := graphRequest(ctx, cc.client, http.MethodGet, []string{cc.CatalogID, "products"}, query, nil, &response); err != nil {
+		return nil, err
+	}
+
+	products := make(map[string]*Product, len(response.Data))
+	for i := range response.Data {
+		products[response.Data[i].RetailerID] = &response.Data[i]
+	}
+	return products, nil
+}
+
+// EnrichOrder looks up the products of all items of the order with a single request.
+func (cc *CatalogClient) EnrichOrder(ctx context.Context, order *WebhookMessageOrder) ([]OrderItem, error) {
+	if order == nil {
+		return nil, fmt.Errorf("order cannot be nil")
+	}
+
+	retailerIDs := make([]string, len(order.ProductItems))
+	for i, item := range order.ProductItems {
+		retailerIDs[i] = item.ProductRetailerID
+	}
+	products, err := cc.GetProducts(ctx, retailerIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]OrderItem, len(order.ProductItems))
+	for i, item := range order.ProductItems {
+		items[i] = OrderItem{WebhookMessageOrderItem: item, Product: products[item.ProductRetailerID]}
+	}
+	return items, nil
+}
@@ -0,0 +1,209 @@
+package whatsapp
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit
+// breaker considers the Graph API (or one of its endpoints) unavailable.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState represents the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through while counting failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all requests with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through to decide whether to close the circuit again.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// DefaultCircuitFailureRatio is the default failure ratio that opens the circuit.
+	DefaultCircuitFailureRatio = 0.5
+	// DefaultCircuitMinRequests is the default number of requests in an interval
+	// before the failure ratio is considered.
+	DefaultCircuitMinRequests = 10
+	// DefaultCircuitInterval is the default length of the interval failures are counted in.
+	DefaultCircuitInterval = time.Minute
+	// DefaultCircuitOpenTimeout is the default time the circuit stays open before a probe request.
+	DefaultCircuitOpenTimeout = 30 * time.Second
+)
+
+// CircuitBreaker stops sending requests to the Graph API while it is failing,
+// so an outage degrades gracefully instead of piling up goroutines waiting on
+// a slow endpoint. Transport errors and 5xx responses count as failures.
+// Zero fields use the corresponding Default* values.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithCircuitBreaker(&CircuitBreaker{
+//	    PerEndpoint: true,
+//	    OnStateChange: func(endpoint string, from, to CircuitState) {
+//	        log.Printf("Circuit for %s: %s -> %s", endpoint, from, to)
+//	    },
+//	}))
+type CircuitBreaker struct {
+	// FailureRatio is the ratio of failed requests in an interval that opens the circuit.
+	FailureRatio float64
+	// MinRequests is the number of requests in an interval needed before the ratio is considered.
+	MinRequests int
+	// Interval is the length of the interval failures are counted in while the circuit is closed.
+	Interval time.Duration
+	// OpenTimeout is how long the circuit stays open before a probe request is let through.
+	OpenTimeout time.Duration
+	// PerEndpoint keeps a separate circuit for every endpoint (method, host and edge,
+	// e.g. "POST graph.facebook.com messages") instead of a single one for the whole API.
+	PerEndpoint bool
+	// OnStateChange, if set, is called whenever a circuit changes its state.
+	// The endpoint is empty unless PerEndpoint is set. It is called with the
+	// breaker locked, so it must not call the breaker's methods.
+	OnStateChange func(endpoint string, from, to CircuitState)
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit is the state of a single circuit.
+type circuit struct {
+	state    CircuitState
+	since    time.Time
+	requests int
+	failures int
+	probing  bool
+}
+
+// WithCircuitBreaker protects all API requests of the client with cb.
+func WithCircuitBreaker(cb *CircuitBreaker) ClientOption {
+	return func(wa *Client) {
+		wa.CircuitBreaker = cb
+	}
+}
+
+// State returns the current state of the circuit for endpoint.
+// The endpoint is ignored unless PerEndpoint is set.
+func (cb *CircuitBreaker) State(endpoint string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.circuit(endpoint, time.Now()).state
+}
+
+// endpoint returns the circuit key of the request.
+func (cb *CircuitBreaker) endpoint(req *http.Request) string {
+	if !cb.PerEndpoint {
+		return ""
+	}
+	// Node IDs (phone numbers, media) vary between requests, edge names don't
+	edge := req.URL.Path[strings.LastIndexByte(req.URL.Path, '/')+1:]
+	if strings.ContainsFunc(edge, func(r rune) bool { return r != '_' && (r < 'a' || r > 'z') }) {
+		edge = "{id}"
+	}
+	return req.Method + " " + req.URL.Host + " " + edge
+}
+
+// circuit returns the circuit for endpoint, moving it to the next state if its time has come.
+// It must be called with cb.mu held.
+func (cb *CircuitBreaker) circuit(endpoint string, now time.Time) *circuit {
+	if cb.circuits == nil {
+		cb.circuits = make(map[string]*circuit)
+	}
+	c, ok := cb.circuits[endpoint]
+	if !ok {
+		c = &circuit{since: now}
+		cb.circuits[endpoint] = c
+	}
+
+	switch {
+	case c.state == CircuitClosed && now.Sub(c.since) >= orDefault(cb.Interval, DefaultCircuitInterval):
+		c.since, c.requests, c.failures = now, 0, 0
+	case c.state == CircuitOpen && now.Sub(c.since) >= orDefault(cb.OpenTimeout, DefaultCircuitOpenTimeout):
+		cb.setState(endpoint, c, CircuitHalfOpen, now)
+	}
+	return c
+}
+
+// allow reports whether a request to endpoint may be sent. If it returns nil,
+// the outcome of the request must be reported with done.
+func (cb *CircuitBreaker) allow(endpoint string) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c := cb.circuit(endpoint, time.Now())
+	switch {
+	case c.state == CircuitOpen, c.state == CircuitHalfOpen && c.probing:
+		return ErrCircuitOpen
+	case c.state == CircuitHalfOpen:
+		c.probing = true
+	}
+	return nil
+}
+
+// done records the outcome of a request allowed by allow. Requests that were
+// canceled by the caller are neither successes nor failures.
+func (cb *CircuitBreaker) done(endpoint string, failed, canceled bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	c := cb.circuit(endpoint, now)
+	if c.state == CircuitHalfOpen {
+		c.probing = false
+		switch {
+		case canceled:
+		case failed:
+			cb.setState(endpoint, c, CircuitOpen, now)
+		default:
+			cb.setState(endpoint, c, CircuitClosed, now)
+		}
+		return
+	}
+	if c.state != CircuitClosed || canceled {
+		return
+	}
+
+	c.requests++
+	if failed {
+		c.failures++
+	}
+	ratio := orDefault(cb.FailureRatio, DefaultCircuitFailureRatio)
+	if c.requests >= orDefault(cb.MinRequests, DefaultCircuitMinRequests) && float64(c.failures)/float64(c.requests) >= ratio {
+		cb.setState(endpoint, c, CircuitOpen, now)
+	}
+}
+
+func (cb *CircuitBreaker) setState(endpoint string, c *circuit, state CircuitState, now time.Time) {
+	from := c.state
+	c.state, c.since, c.requests, c.failures = state, now, 0, 0
+	if cb.OnStateChange != nil {
+		cb.OnStateChange(endpoint, from, state)
+	}
+}
+
+// orDefault returns v, or def if v is the zero value.
+func orDefault[T comparable](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
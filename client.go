@@ -3,16 +3,23 @@ package whatsapp
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -25,27 +32,272 @@ const (
 
 // Client is a Client client that provides methods to interact with the Client Business API.
 type Client struct {
-	AccessToken   string       // AccessToken is the access token for the WhatsApp Business API.
-	BaseURL       string       // BaseURL is the base URL for the WhatsApp Business API.
-	APIVersion    string       // APIVersion is the version of the WhatsApp Business API.
-	PhoneNumberID string       // PhoneNumberID is the ID of the phone number associated with the WhatsApp Business account.
-	Client        *http.Client // Client is the HTTP client used to make requests to the WhatsApp Business API.
+	AccessToken       string       // AccessToken is the access token for the WhatsApp Business API.
+	BaseURL           string       // BaseURL is the base URL for the WhatsApp Business API.
+	APIVersion        string       // APIVersion is the version of the WhatsApp Business API.
+	PhoneNumberID     string       // PhoneNumberID is the ID of the phone number associated with the WhatsApp Business account.
+	BusinessAccountID string       // BusinessAccountID is the ID of the WhatsApp Business Account, required by account-level APIs.
+	Client            *http.Client // Client is the HTTP client used to make requests to the WhatsApp Business API.
+
+	// AppSecret, if set, is used to sign every Graph API request with appsecret_proof,
+	// which is mandatory when the app requires app secret proof for server API calls.
+	AppSecret string
+	// DisableMediaURLRefresh turns off the automatic refresh of expired media URLs
+	// in GetAndDownloadMedia and GetAndDownloadMediaBytes.
+	DisableMediaURLRefresh bool
+	// BeforeRequest interceptors are called before every API request, in order.
+	BeforeRequest []RequestInterceptor
+	// AfterResponse interceptors are called after every API request, in order.
+	AfterResponse []ResponseInterceptor
+	// UserAgent, if set, is the User-Agent header of every API request.
+	UserAgent string
+	// RequestIDHeader, if set, is the header carrying the request ID of every
+	// API request, see WithRequestIDHeader.
+	RequestIDHeader string
+	// CircuitBreaker, if set, stops sending requests while the API is failing.
+	CircuitBreaker *CircuitBreaker
+	// LongTextStrategy controls how SendText handles bodies longer than MaxTextBodyLength.
+	LongTextStrategy LongTextStrategy
+	// MessageStore, if set, records every message accepted by the API.
+	MessageStore MessageStore
+	// IdempotencyStore, if set, suppresses duplicate sends of messages with the same idempotency key.
+	IdempotencyStore IdempotencyStore
+	// IdempotencyTTL is how long an idempotency key suppresses duplicates. Zero means DefaultIdempotencyTTL.
+	IdempotencyTTL time.Duration
+	// FallbackTemplate is the template SendSmart sends when the customer service window is closed.
+	FallbackTemplate *SendTemplateParams
+	// StrictValidation checks every message with ValidateRequest before sending it.
+	StrictValidation bool
+	// DryRun validates and marshals messages without sending them, see WithDryRun.
+	DryRun bool
+	// DryRunFunc, if set, receives the payload of every message in dry-run mode.
+	DryRunFunc DryRunFunc
+	// RateLimiter, if set, paces outgoing messages.
+	RateLimiter RateLimiter
+	// EnableGroups enables SendToGroup, see WithGroups.
+	EnableGroups bool
+	// OnAPIVersionWarning, if set, is called with Graph API version warnings, see WithAPIVersionWarning.
+	OnAPIVersionWarning func(APIVersionWarning)
+	// TemplatePreflight checks template parameters against the template definition, see WithTemplatePreflight.
+	TemplatePreflight bool
+	// TemplateCacheTTL is how long template definitions are cached. Zero means DefaultTemplateCacheTTL.
+	TemplateCacheTTL time.Duration
+	// MediaCache, if set, reuses the media IDs of uploaded content, see WithMediaCache.
+	MediaCache MediaCache
+	// MediaTransformers transform media before it is uploaded, see WithMediaTransformers.
+	MediaTransformers []MediaTransformer
+	// JSONCodec, if set, replaces encoding/json for Graph API requests, see WithJSONCodec.
+	JSONCodec JSONCodec
+	// SanitizeInteractive shortens the texts of interactive messages to the API limits, see WithInteractiveSanitizer.
+	SanitizeInteractive bool
+	// URLShortener, if set, replaces the URLs of text bodies before sending, see WithURLShortener.
+	URLShortener URLShortener
+	// AutoPreviewURL requests link previews for text bodies containing URLs, see WithAutoPreviewURL.
+	AutoPreviewURL bool
+	// Auditor, if set, records every API request, see WithAuditor.
+	Auditor *Auditor
+	// OrderedSends sends the messages to the same recipient one after the other, see WithOrderedSends.
+	OrderedSends bool
+	// TestMode, if set, applies the rules of a test phone number, see WithTestMode.
+	TestMode *TestMode
+	// SendTimeout, UploadTimeout and DownloadTimeout, if positive, limit Graph API
+	// requests, media uploads and media downloads whose context has no deadline.
+	SendTimeout, UploadTimeout, DownloadTimeout time.Duration
+
+	configErr          error          // configErr is an invalid option value reported by every request.
+	apiVersionWarnings sync.Map       // apiVersionWarnings are the warnings reported to OnAPIVersionWarning.
+	templates          templateCache  // templates caches template definitions for TemplatePreflight.
+	recipients         recipientLocks // recipients serializes the sends to every recipient for OrderedSends.
 }
 
+// ClientOption configures optional Client behavior in NewClient.
+type ClientOption func(*Client)
+
 // NewClient creates a new WhatsApp API client with the provided access token and phone number ID.
-func NewClient(accessToken, phoneNumberID string) *Client {
-	return &Client{
+func NewClient(accessToken, phoneNumberID string, opts ...ClientOption) *Client {
+	wa := &Client{
 		AccessToken:   accessToken,
 		BaseURL:       DefaultBaseURL,
 		APIVersion:    DefaultAPIVersion,
 		PhoneNumberID: phoneNumberID,
 		Client:        http.DefaultClient,
 	}
+	for _, opt := range opts {
+		opt(wa)
+	}
+	return wa
+}
+
+// WithAppSecretProof makes the client sign every Graph API request with
+// appsecret_proof, the HMAC-SHA256 of the access token keyed with appSecret.
+// https://developers.facebook.com/docs/graph-api/securing-requests#appsecret_proof
+func WithAppSecretProof(appSecret string) ClientOption {
+	return func(wa *Client) {
+		wa.AppSecret = appSecret
+	}
+}
+
+// appSecretProof computes the appsecret_proof value for the access token.
+func appSecretProof(accessToken, appSecret string) string {
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write([]byte(accessToken))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // SendText sends a text message.
+// Bodies longer than MaxTextBodyLength are handled according to the client's
+// LongTextStrategy. If params.AutoSplit is set, they are always split with
+// SplitText and sent sequentially as separate messages. The returned response
+// then lists the IDs of all sent messages in order. URLs are shortened and
+// previews requested first if the client has a URLShortener or AutoPreviewURL.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/messages/text-messages
 func (wa *Client) SendText(ctx context.Context, recipient string, params *SendTextParams) (*MessagesResponse, error) {
+	params, err := wa.prepareLinks(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	strategy := wa.LongTextStrategy
+	if params.AutoSplit {
+		strategy = LongTextSplit
+	}
+	length := TextLength(params.Body)
+	if length <= MaxTextBodyLength {
+		return wa.sendText(ctx, recipient, params)
+	}
+
+	switch strategy {
+	case LongTextError:
+		return nil, fmt.Errorf("text body has %d characters, maximum is %d", length, MaxTextBodyLength)
+	case LongTextTruncate:
+		truncated := *params
+		truncated.Body = TruncateText(params.Body, MaxTextBodyLength)
+		return wa.sendText(ctx, recipient, &truncated)
+	case LongTextSplit:
+		return wa.sendTextChunks(ctx, recipient, params, SplitText(params.Body, MaxTextBodyLength))
+	case LongTextDocument:
+		return wa.sendTextAsDocument(ctx, recipient, params)
+	default:
+		return wa.sendText(ctx, recipient, params)
+	}
+}
+
+// SendMessage sends a fully assembled message request. The typed Send methods
+// are built on top of it; use it directly for message types and fields they don't cover.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/messages
+func (wa *Client) SendMessage(ctx context.Context, request *Request) (*MessagesResponse, error) {
+	if wa.SanitizeInteractive && request != nil && request.Interactive != nil {
+		sanitized := *request
+		sanitized.Interactive = SanitizeInteractive(request.Interactive)
+		request = &sanitized
+	}
+	if data, ok := CallbackDataFromContext(ctx); ok && request != nil && request.BizOpaqueCallbackData == "" {
+		tagged := *request
+		tagged.BizOpaqueCallbackData = data
+		request = &tagged
+	}
+	if id, ok := ctx.Value(replyToKey{}).(string); ok && request != nil && request.Context == nil {
+		quoted := *request
+		quoted.Context = &RequestContext{MessageID: id}
+		request = &quoted
+	}
+	if err := wa.checkTestRecipient(request); err != nil {
+		return nil, err
+	}
+	if wa.DryRun {
+		return wa.dryRun(ctx, request)
+	}
+	if wa.StrictValidation {
+		if err := ValidateRequest(request); err != nil {
+			return nil, fmt.Errorf("invalid message: %w", err)
+		}
+	}
+
+	if wa.OrderedSends && request != nil {
+		unlock, err := wa.recipients.lock(ctx, orderingKey(request))
+		if err != nil {
+			return nil, fmt.Errorf("waiting for earlier messages to %s: %w", request.To, err)
+		}
+		defer unlock()
+	}
+	release, err := wa.claimIdempotencyKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if wa.RateLimiter != nil {
+		if err := wa.RateLimiter.Wait(ctx); err != nil {
+			if release != nil {
+				release()
+			}
+			return nil, fmt.Errorf("waiting for rate limiter: %w", err)
+		}
+	}
+
+	var response MessagesResponse
+	if err := sendRequest(ctx, wa, "messages", request, &response); err != nil {
+		if release != nil {
+			release()
+		}
+		if observer, ok := wa.RateLimiter.(ErrorObserver); ok {
+			observer.ObserveError(err)
+		}
+		return nil, wa.testModeError(err)
+	}
+	if wa.MessageStore != nil {
+		if err := wa.storeMessages(ctx, request, &response); err != nil {
+			// The message was sent, so the response is returned to prevent resending it
+			return &response, fmt.Errorf("message sent but not stored: %w", err)
+		}
+	}
+	return &response, nil
+}
+
+// sendTextAsDocument uploads the text body as a plain text file and sends it as a document.
+func (wa *Client) sendTextAsDocument(ctx context.Context, recipient string, params *SendTextParams) (*MessagesResponse, error) {
+	upload, err := NewUploadMediaParams(strings.NewReader(params.Body), longTextFilename, string(MimeTypeDocumentText))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload params: %w", err)
+	}
+	uploaded, err := wa.UploadMedia(ctx, upload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload text document: %w", err)
+	}
+	return wa.SendDocument(ctx, recipient, &SendDocumentParams{
+		ID:       uploaded.ID,
+		Filename: longTextFilename,
+	})
+}
+
+// sendTextChunks sends every chunk as a separate text message, preserving the order.
+// On failure it returns the responses collected so far together with the error.
+func (wa *Client) sendTextChunks(ctx context.Context, recipient string, params *SendTextParams, chunks []string) (*MessagesResponse, error) {
+	if len(chunks) == 0 {
+		// The body is blank, so send it as is to fail like any other empty text
+		empty := *params
+		empty.Body = ""
+		return wa.sendText(ctx, recipient, &empty)
+	}
+	merged := &MessagesResponse{MessagingProduct: MessagingProductWhatsApp}
+	for i, chunk := range chunks {
+		chunkParams := *params
+		chunkParams.Body = chunk
+		chunkCtx := ctx
+		if key, ok := IdempotencyKeyFromContext(ctx); ok {
+			// Every chunk is a message of its own, so it needs a key of its own
+			chunkCtx = ContextWithIdempotencyKey(ctx, fmt.Sprintf("%s/%d", key, i+1))
+		}
+		response, err := wa.sendText(chunkCtx, recipient, &chunkParams)
+		if err != nil {
+			return merged, fmt.Errorf("sending chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		if len(merged.Contacts) == 0 {
+			merged.Contacts = response.Contacts
+		}
+		merged.Messages = append(merged.Messages, response.Messages...)
+	}
+	return merged, nil
+}
+
+func (wa *Client) sendText(ctx context.Context, recipient string, params *SendTextParams) (*MessagesResponse, error) {
 	request := &Request{
 		MessagingProduct: MessagingProductWhatsApp,
 		RecipientType:    RecipientTypeIndividual,
@@ -53,11 +305,7 @@ func (wa *Client) SendText(ctx context.Context, recipient string, params *SendTe
 		Type:             MessageTypeText,
 		Text:             params,
 	}
-	var response MessagesResponse
-	if err := sendRequest(ctx, wa, "messages", request, &response); err != nil {
-		return nil, err
-	}
-	return &response, nil
+	return wa.SendMessage(ctx, request)
 }
 
 // SendImage sends an image message.
@@ -70,17 +318,106 @@ func (wa *Client) SendImage(ctx context.Context, recipient string, params *SendI
 		Type:             MessageTypeImage,
 		Image:            params,
 	}
-	var response MessagesResponse
-	if err := sendRequest(ctx, wa, "messages", request, &response); err != nil {
+	return wa.SendMessage(ctx, request)
+}
+
+// SendDocument sends a document message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/document-messages
+func (wa *Client) SendDocument(ctx context.Context, recipient string, params *SendDocumentParams) (*MessagesResponse, error) {
+	request := &Request{
+		MessagingProduct: MessagingProductWhatsApp,
+		RecipientType:    RecipientTypeIndividual,
+		To:               recipient,
+		Type:             MessageTypeDocument,
+		Document:         params,
+	}
+	return wa.SendMessage(ctx, request)
+}
+
+// SendAudio sends an audio message.
+// Set params.Voice to have the audio rendered as a voice note, see SendVoiceNote.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/audio-messages
+func (wa *Client) SendAudio(ctx context.Context, recipient string, params *SendAudioParams) (*MessagesResponse, error) {
+	request := &Request{
+		MessagingProduct: MessagingProductWhatsApp,
+		RecipientType:    RecipientTypeIndividual,
+		To:               recipient,
+		Type:             MessageTypeAudio,
+		Audio:            params,
+	}
+	return wa.SendMessage(ctx, request)
+}
+
+// SendVoiceNote sends previously uploaded OGG/Opus audio as a voice note, which the
+// WhatsApp client renders as a push-to-talk bubble instead of an audio file attachment.
+// Use UploadVoiceNote to validate and upload the audio.
+//
+// Example usage:
+//
+//	uploaded, err := client.UploadVoiceNote(ctx, file, "note.ogg")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	response, err := client.SendVoiceNote(ctx, "1234567890", uploaded.ID)
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/audio-messages
+func (wa *Client) SendVoiceNote(ctx context.Context, recipient, mediaID string) (*MessagesResponse, error) {
+	return wa.SendAudio(ctx, recipient, &SendAudioParams{ID: mediaID, Voice: true})
+}
+
+// UploadVoiceNote validates that the audio in r is OGG with the Opus codec,
+// as required for voice notes, and uploads it.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#upload-media
+func (wa *Client) UploadVoiceNote(ctx context.Context, r io.Reader, filename string) (*UploadMediaResponse, error) {
+	// The Opus identification header is always in the first Ogg page
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read audio content: %w", err)
+	}
+	head = head[:n]
+	if err := ValidateVoiceNote(head); err != nil {
 		return nil, err
 	}
-	return &response, nil
+
+	params, err := NewUploadMediaParams(io.MultiReader(bytes.NewReader(head), r), filename, string(MimeTypeAudioOGG))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload params: %w", err)
+	}
+	return wa.UploadMedia(ctx, params)
+}
+
+// SendSticker sends a sticker message.
+// Use the sticker package to validate sticker files before uploading them.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/sticker-messages
+func (wa *Client) SendSticker(ctx context.Context, recipient string, params *SendStickerParams) (*MessagesResponse, error) {
+	request := &Request{
+		MessagingProduct: MessagingProductWhatsApp,
+		RecipientType:    RecipientTypeIndividual,
+		To:               recipient,
+		Type:             MessageTypeSticker,
+		Sticker:          params,
+	}
+	return wa.SendMessage(ctx, request)
 }
 
 // SendInteractiveButtons sends an interactive reply buttons message.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/messages/interactive-reply-buttons-messages
 func (wa *Client) SendInteractiveButtons(ctx context.Context, recipient string, params *SendInteractiveButtonsParams) (*MessagesResponse, error) {
-	interactive := &Interactive{
+	request := &Request{
+		MessagingProduct: MessagingProductWhatsApp,
+		RecipientType:    RecipientTypeIndividual,
+		To:               recipient,
+		Type:             MessageTypeInteractive,
+		Interactive:      newInteractiveButtons(params),
+	}
+
+	return wa.SendMessage(ctx, request)
+}
+
+func newInteractiveButtons(params *SendInteractiveButtonsParams) *Interactive {
+	return &Interactive{
 		Type:   InteractiveTypeButton,
 		Header: params.Header,
 		Body:   params.Body,
@@ -89,20 +426,20 @@ func (wa *Client) SendInteractiveButtons(ctx context.Context, recipient string,
 			Buttons: params.Buttons,
 		},
 	}
+}
 
+// SendReaction reacts to a message with an emoji.
+// An empty emoji removes a previously sent reaction.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/reaction-messages
+func (wa *Client) SendReaction(ctx context.Context, recipient string, params *SendReactionParams) (*MessagesResponse, error) {
 	request := &Request{
 		MessagingProduct: MessagingProductWhatsApp,
 		RecipientType:    RecipientTypeIndividual,
 		To:               recipient,
-		Type:             MessageTypeInteractive,
-		Interactive:      interactive,
-	}
-
-	var response MessagesResponse
-	if err := sendRequest(ctx, wa, "messages", request, &response); err != nil {
-		return nil, err
+		Type:             MessageTypeReaction,
+		Reaction:         params,
 	}
-	return &response, nil
+	return wa.SendMessage(ctx, request)
 }
 
 // SendInteractiveList sends an interactive list message.
@@ -127,11 +464,7 @@ func (wa *Client) SendInteractiveList(ctx context.Context, recipient string, par
 		Interactive:      interactive,
 	}
 
-	var response MessagesResponse
-	if err := sendRequest(ctx, wa, "messages", request, &response); err != nil {
-		return nil, err
-	}
-	return &response, nil
+	return wa.SendMessage(ctx, request)
 }
 
 // SendInteractiveFlow sends an interactive flow message.
@@ -163,11 +496,7 @@ func (wa *Client) SendInteractiveFlow(ctx context.Context, recipient string, par
 		Interactive:      interactive,
 	}
 
-	var response MessagesResponse
-	if err := sendRequest(ctx, wa, "messages", request, &response); err != nil {
-		return nil, err
-	}
-	return &response, nil
+	return wa.SendMessage(ctx, request)
 }
 
 // SendInteractiveCTAURL sends an interactive call-to-action URL message.
@@ -226,11 +555,7 @@ func (wa *Client) SendInteractiveCTAURL(ctx context.Context, recipient string, p
 		Interactive:      interactive,
 	}
 
-	var response MessagesResponse
-	if err := sendRequest(ctx, wa, "messages", request, &response); err != nil {
-		return nil, err
-	}
-	return &response, nil
+	return wa.SendMessage(ctx, request)
 }
 
 // GetMedia retrieves media information including the download URL for a given media ID.
@@ -293,25 +618,43 @@ func (wa *Client) GetMedia(ctx context.Context, mediaID string) (*MediaResponse,
 //
 // https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#download-media
 func (wa *Client) DownloadMedia(ctx context.Context, mediaURL string) (io.ReadCloser, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	resp, err := wa.downloadMedia(ctx, mediaURL)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+wa.AccessToken)
+	// Return the response body as ReadCloser - caller must close it
+	return resp.Body, nil
+}
 
-	resp, err := wa.Client.Do(req)
+// downloadMedia issues the authenticated media download request and returns the
+// successful response. The caller is responsible for closing the response body.
+func (wa *Client) downloadMedia(ctx context.Context, mediaURL string) (*http.Response, error) {
+	// The timeout covers reading the body, so it is canceled when the body is closed
+	ctx, cancel := withDefaultTimeout(ctx, wa.DownloadTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
 	if err != nil {
+		cancel()
+		return nil, wa.scrub(err)
+	}
+
+	resp, err := wa.do(req, nil)
+	if err != nil {
+		cancel()
 		return nil, err
 	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
 
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close() // Close body since we're returning an error
+		switch resp.StatusCode {
+		case http.StatusForbidden, http.StatusNotFound:
+			return nil, fmt.Errorf("failed to download media: want 200 OK, got %s: %w", resp.Status, ErrMediaURLExpired)
+		}
 		return nil, fmt.Errorf("failed to download media: want 200 OK, got %s", resp.Status)
 	}
 
-	// Return the response body as ReadCloser - caller must close it
-	return resp.Body, nil
+	return resp, nil
 }
 
 // DownloadMediaBytes downloads the actual media content and reads it into memory.
@@ -365,8 +708,20 @@ func (wa *Client) DownloadMediaBytes(ctx context.Context, mediaURL string) ([]by
 //	// Process the media stream...
 //	_, err = io.Copy(destination, reader)
 //
+// If the download URL turns out to be expired, the media information is fetched
+// again and the download is retried once, unless DisableMediaURLRefresh is set.
+// The retry is the second attempt of the download in the RequestInfo passed to
+// interceptors, and all the requests share the same request ID.
+// Once the media information was fetched, it is returned even if the download
+// fails, including if it can't be refreshed.
+//
 // https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media
 func (wa *Client) GetAndDownloadMedia(ctx context.Context, mediaID string) (*MediaResponse, io.ReadCloser, error) {
+	if wa.RequestIDHeader != "" {
+		// All the requests, including the retried download, share the request ID
+		ctx = withRequestID(ctx)
+	}
+
 	// First, get the media information including the download URL
 	mediaInfo, err := wa.GetMedia(ctx, mediaID)
 	if err != nil {
@@ -375,6 +730,15 @@ func (wa *Client) GetAndDownloadMedia(ctx context.Context, mediaID string) (*Med
 
 	// Then download the actual media content
 	content, err := wa.DownloadMedia(ctx, mediaInfo.URL)
+	if errors.Is(err, ErrMediaURLExpired) && !wa.DisableMediaURLRefresh {
+		// The URL is only valid for 5 minutes, so get a fresh one and try again
+		refreshed, refreshErr := wa.GetMedia(ctx, mediaID)
+		if refreshErr != nil {
+			return mediaInfo, nil, fmt.Errorf("failed to refresh media info: %w", refreshErr)
+		}
+		mediaInfo = refreshed
+		content, err = wa.DownloadMedia(withAttempt(ctx, 2), mediaInfo.URL)
+	}
 	if err != nil {
 		return mediaInfo, nil, fmt.Errorf("failed to download media: %w", err)
 	}
@@ -383,18 +747,19 @@ func (wa *Client) GetAndDownloadMedia(ctx context.Context, mediaID string) (*Med
 }
 
 // GetAndDownloadMediaBytes retrieves media information and downloads the media content into memory.
+// Expired download URLs are refreshed the same way as in GetAndDownloadMedia.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media
 func (wa *Client) GetAndDownloadMediaBytes(ctx context.Context, mediaID string) (*MediaResponse, []byte, error) {
-	// First, get the media information including the download URL
-	mediaInfo, err := wa.GetMedia(ctx, mediaID)
+	mediaInfo, reader, err := wa.GetAndDownloadMedia(ctx, mediaID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get media info: %w", err)
+		return mediaInfo, nil, err
 	}
+	defer reader.Close()
 
-	// Then download the actual media content into memory
-	content, err := wa.DownloadMediaBytes(ctx, mediaInfo.URL)
+	// Read the entire content into memory
+	content, err := io.ReadAll(reader)
 	if err != nil {
-		return mediaInfo, nil, fmt.Errorf("failed to download media: %w", err)
+		return mediaInfo, nil, fmt.Errorf("failed to read media content: %w", err)
 	}
 
 	return mediaInfo, content, nil
@@ -402,10 +767,43 @@ func (wa *Client) GetAndDownloadMediaBytes(ctx context.Context, mediaID string)
 
 // UploadMedia uploads media to WhatsApp and returns the media ID that can be used in messages.
 // The media file is uploaded as multipart form data with the specified MIME type.
+// If params.MimeType is empty, it is detected with DetectMimeType.
+// If the client has a MediaCache, content that was uploaded less than
+// MediaIDLifetime ago is not uploaded again and its cached media ID is returned.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#upload-media
 func (wa *Client) UploadMedia(ctx context.Context, params *UploadMediaParams) (*UploadMediaResponse, error) {
+	response, _, err := wa.uploadMedia(ctx, params, true)
+	return response, err
+}
+
+// uploadMedia implements UploadMedia. If useCache is false, the media is uploaded
+// even if it is in the media cache, and the cache entry is replaced.
+// It reports whether the returned media ID came from the cache.
+func (wa *Client) uploadMedia(ctx context.Context, params *UploadMediaParams, useCache bool) (*UploadMediaResponse, bool, error) {
+	ctx, cancel := withDefaultTimeout(ctx, wa.UploadTimeout)
+	defer cancel()
+
+	if params != nil && params.MimeType == "" && params.File != nil {
+		detected := *params
+		mimeType, file, err := DetectMimeType(params.File, params.Filename)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid upload parameters: %w", err)
+		}
+		detected.MimeType, detected.File = mimeType, file
+		params = &detected
+	}
 	if err := params.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid upload parameters: %w", err)
+		return nil, false, fmt.Errorf("invalid upload parameters: %w", err)
+	}
+	if len(wa.MediaTransformers) > 0 {
+		transformed, err := wa.transformMedia(ctx, params)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := transformed.Validate(); err != nil {
+			return nil, false, fmt.Errorf("invalid transformed upload parameters: %w", err)
+		}
+		params = transformed
 	}
 
 	var body bytes.Buffer
@@ -417,10 +815,26 @@ func (wa *Client) UploadMedia(ctx context.Context, params *UploadMediaParams) (*
 
 	part, err := writer.CreatePart(h)
 	if err != nil {
-		return nil, fmt.Errorf("creating multipart part: %w", err)
+		return nil, false, fmt.Errorf("creating multipart part: %w", err)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(part, hash), params.File); err != nil {
+		return nil, false, fmt.Errorf("copying file data: %w", err)
 	}
-	if _, err := io.Copy(part, params.File); err != nil {
-		return nil, fmt.Errorf("copying file data: %w", err)
+
+	var cacheKey string
+	if wa.MediaCache != nil {
+		cacheKey = mediaCacheKey(hash.Sum(nil), params.MimeType)
+	}
+	if cacheKey != "" && useCache {
+		cached, ok, err := wa.MediaCache.Get(ctx, cacheKey)
+		if err != nil {
+			return nil, false, fmt.Errorf("media cache: %w", err)
+		}
+		// Expired media IDs are uploaded again
+		if ok && !cached.Expired() {
+			return &UploadMediaResponse{ID: cached.ID}, true, nil
+		}
 	}
 
 	if err := errors.Join(
@@ -428,46 +842,47 @@ func (wa *Client) UploadMedia(ctx context.Context, params *UploadMediaParams) (*
 		writer.WriteField("type", params.MimeType),
 		writer.Close(),
 	); err != nil {
-		return nil, fmt.Errorf("setting up multipart writer: %w", err)
+		return nil, false, fmt.Errorf("setting up multipart writer: %w", err)
 	}
 
-	u, err := url.JoinPath(wa.BaseURL, wa.APIVersion, wa.PhoneNumberID, "media")
+	u, err := wa.graphURL(wa.PhoneNumberID, "media")
 	if err != nil {
-		return nil, fmt.Errorf("build URL: %w", err)
+		return nil, false, fmt.Errorf("build URL: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, &body)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, false, wa.scrub(fmt.Errorf("creating request: %w", err))
 	}
 
-	req.Header.Set("Authorization", "Bearer "+wa.AccessToken)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	resp, err := wa.Client.Do(req)
+	resp, err := wa.do(req, nil)
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+		return nil, false, fmt.Errorf("executing request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var apiError APIError
-		if decodeErr := json.NewDecoder(resp.Body).Decode(&apiError); decodeErr != nil {
-			return nil, fmt.Errorf("upload status %s", resp.Status)
-		}
-		return nil, fmt.Errorf("WhatsApp API error: %s (code: %d)", apiError.Error.Message, apiError.Error.Code)
+		return nil, false, wa.scrub(decodeAPIError(resp, fmt.Errorf("upload status %s", resp.Status)))
 	}
 
 	var response UploadMediaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+		return nil, false, fmt.Errorf("decoding response: %w", err)
 	}
 
-	return &response, nil
+	if wa.MediaCache != nil && response.ID != "" {
+		if err := wa.MediaCache.Put(ctx, cacheKey, CachedMedia{ID: response.ID, UploadedAt: time.Now()}); err != nil {
+			return &response, false, fmt.Errorf("media uploaded but not cached: %w", err)
+		}
+	}
+	return &response, false, nil
 }
 
 // UploadMediaFromFile is a convenience method that uploads media from a file path.
-// This method automatically opens the file, detects basic MIME types, and uploads the media.
+// This method automatically opens the file and uploads the media. If mimeType is empty,
+// it is detected from the file extension and content with DetectMimeType.
 // For more control over the upload process, use UploadMedia directly.
 //
 // Example usage:
@@ -505,30 +920,24 @@ func (wa *Client) DeleteMedia(ctx context.Context, mediaID string) (*DeleteMedia
 		return nil, fmt.Errorf("media ID cannot be empty")
 	}
 
-	u, err := url.JoinPath(wa.BaseURL, wa.APIVersion, mediaID)
+	u, err := wa.graphURL(mediaID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build URL: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, wa.scrub(fmt.Errorf("failed to create request: %w", err))
 	}
 
-	req.Header.Set("Authorization", "Bearer "+wa.AccessToken)
-
-	resp, err := wa.Client.Do(req)
+	resp, err := wa.do(req, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var apiError APIError
-		if decodeErr := json.NewDecoder(resp.Body).Decode(&apiError); decodeErr != nil {
-			return nil, fmt.Errorf("delete failed with status %s", resp.Status)
-		}
-		return nil, fmt.Errorf("WhatsApp API error: %s (code: %d)", apiError.Error.Message, apiError.Error.Code)
+		return nil, wa.scrub(decodeAPIError(resp, fmt.Errorf("delete failed with status %s", resp.Status)))
 	}
 
 	var response DeleteMediaResponse
@@ -539,60 +948,166 @@ func (wa *Client) DeleteMedia(ctx context.Context, mediaID string) (*DeleteMedia
 	return &response, nil
 }
 
-func sendRequest(ctx context.Context, wa *Client, endpoint string, request any, response any) error {
-	u, err1 := url.JoinPath(wa.BaseURL, wa.APIVersion, wa.PhoneNumberID, endpoint)
-	payloadBytes, err2 := json.Marshal(request)
-	req, err3 := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewBuffer(payloadBytes))
-	if err := errors.Join(err1, err2, err3); err != nil {
-		return err
+// graphURL builds the URL of a Graph API endpoint from the path segments following
+// the API version, attaching the appsecret_proof parameter if the client is configured
+// with an app secret.
+func (wa *Client) graphURL(segments ...string) (string, error) {
+	return wa.graphURLWithQuery(nil, segments...)
+}
+
+// graphURLWithQuery is like graphURL, but also adds the query parameters.
+func (wa *Client) graphURLWithQuery(query url.Values, segments ...string) (string, error) {
+	u, err := url.JoinPath(wa.BaseURL, append([]string{wa.APIVersion}, segments...)...)
+	if err != nil {
+		return "", wa.scrub(err)
+	}
+	if wa.AppSecret != "" {
+		query = maps.Clone(query)
+		if query == nil {
+			query = make(url.Values)
+		}
+		query.Set("appsecret_proof", appSecretProof(wa.AccessToken, wa.AppSecret))
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
 	}
+	return u, nil
+}
 
+// do authorizes the request with the access token and executes it, running
+// the request and response interceptors. The payload is the JSON body of the
+// request, if any, and is only used to describe the request to interceptors.
+func (wa *Client) do(req *http.Request, payload []byte) (*http.Response, error) {
+	if wa.configErr != nil {
+		return nil, wa.scrub(fmt.Errorf("invalid client configuration: %w", wa.configErr))
+	}
 	req.Header.Set("Authorization", "Bearer "+wa.AccessToken)
-	req.Header.Set("Content-Type", "application/json")
+	if wa.UserAgent != "" {
+		req.Header.Set("User-Agent", wa.UserAgent)
+	}
 
-	resp, err := wa.Client.Do(req)
-	if err != nil {
-		return err
+	info := &RequestInfo{
+		Method:  req.Method,
+		URL:     sanitizeURL(req.URL),
+		Attempt: attemptFromContext(req.Context()),
+		Payload: payload,
+	}
+	if wa.RequestIDHeader != "" {
+		if ctx := withRequestID(req.Context()); ctx != req.Context() {
+			req = req.WithContext(ctx)
+		}
+		info.RequestID, _ = RequestIDFromContext(req.Context())
+		req.Header.Set(wa.RequestIDHeader, info.RequestID)
+	}
+	for _, intercept := range wa.BeforeRequest {
+		if err := intercept(req, info); err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		var apiError APIError
-		if decodeErr := json.NewDecoder(resp.Body).Decode(&apiError); decodeErr != nil {
-			return fmt.Errorf("want 200 OK, got %s", resp.Status)
+	var endpoint string
+	if wa.CircuitBreaker != nil {
+		endpoint = wa.CircuitBreaker.endpoint(req)
+		if err := wa.CircuitBreaker.allow(endpoint); err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("WhatsApp API error: %s (code: %d)", apiError.Error.Message, apiError.Error.Code)
 	}
 
-	return json.NewDecoder(resp.Body).Decode(response)
+	start := time.Now()
+	resp, err := wa.Client.Do(req)
+	// Errors of the transport quote the URL, and may quote the request
+	err = wa.scrub(err)
+	if wa.Auditor != nil && wa.Auditor.Record != nil {
+		wa.Auditor.audit(req, payload, resp, err, start)
+	}
+	if wa.CircuitBreaker != nil {
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		wa.CircuitBreaker.done(endpoint, failed, req.Context().Err() != nil)
+	}
+	if err == nil {
+		wa.checkAPIVersion(resp)
+	}
+	for _, intercept := range wa.AfterResponse {
+		intercept(resp, info, err)
+	}
+	return resp, err
+}
+
+// Do calls an arbitrary Graph API endpoint with the client's authentication,
+// interceptors and error decoding, for endpoints not wrapped by the library.
+// The path segments follow the API version. The body, if not nil, is sent as
+// JSON, and the JSON response is decoded into out, if not nil. Unsuccessful
+// responses are returned as *GraphError when the API reports an error.
+//
+// Example usage:
+//
+//	var profile struct {
+//	    Data []struct {
+//	        About string `json:"about"`
+//	    } `json:"data"`
+//	}
+//	err := client.Do(ctx, http.MethodGet, []string{client.PhoneNumberID, "whatsapp_business_profile"}, nil, &profile)
+//
+// https://developers.facebook.com/docs/graph-api/overview
+func (wa *Client) Do(ctx context.Context, method string, pathSegments []string, body, out any) error {
+	if len(pathSegments) == 0 {
+		return fmt.Errorf("path cannot be empty")
+	}
+	return graphRequest(ctx, wa, method, pathSegments, nil, body, out)
+}
+
+func sendRequest(ctx context.Context, wa *Client, endpoint string, request any, response any) error {
+	return graphRequest(ctx, wa, http.MethodPost, []string{wa.PhoneNumberID, endpoint}, nil, request, response)
 }
 
 func sendGetRequest(ctx context.Context, wa *Client, mediaID string, response any) error {
-	u, err := url.JoinPath(wa.BaseURL, wa.APIVersion, mediaID)
+	return graphRequest(ctx, wa, http.MethodGet, []string{mediaID}, nil, nil, response)
+}
+
+// graphRequest sends a Graph API request to the endpoint at the path segments
+// following the API version. The request, if not nil, is sent as JSON, and the
+// JSON response is decoded into response, if not nil.
+func graphRequest(ctx context.Context, wa *Client, method string, segments []string, query url.Values, request any, response any) error {
+	ctx, cancel := withDefaultTimeout(ctx, wa.SendTimeout)
+	defer cancel()
+
+	u, err := wa.graphURLWithQuery(query, segments...)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
 	if err != nil {
-		return err
+		return wa.scrub(err)
+	}
+	var payloadBytes []byte
+	if request != nil {
+		payload, release, err := encodeJSON(wa.JSONCodec, request)
+		if err != nil {
+			return err
+		}
+		// The buffer is reused once the transport closed the body, which may be after Do returned
+		body := newPooledBody(payload, release)
+		defer body.done()
+		req.Body, _ = body.open()
+		req.GetBody = body.open
+		req.ContentLength = int64(len(payload))
+		req.Header.Set("Content-Type", "application/json")
+		payloadBytes = payload
 	}
 
-	req.Header.Set("Authorization", "Bearer "+wa.AccessToken)
-
-	resp, err := wa.Client.Do(req)
+	resp, err := wa.do(req, payloadBytes)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var mediaError MediaError
-		if decodeErr := json.NewDecoder(resp.Body).Decode(&mediaError); decodeErr != nil {
-			return fmt.Errorf("want 200 OK, got %s", resp.Status)
-		}
-		return fmt.Errorf("media API error: %s (code: %d)", mediaError.Error.Message, mediaError.Error.Code)
+		return wa.scrub(decodeAPIError(resp, fmt.Errorf("want 200 OK, got %s", resp.Status)))
 	}
 
-	return json.NewDecoder(resp.Body).Decode(response)
+	if response == nil {
+		return nil
+	}
+	return decodeJSON(wa.JSONCodec, resp.Body, response)
 }
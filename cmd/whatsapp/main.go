@@ -0,0 +1,324 @@
+// Command whatsapp performs ad-hoc operations with the WhatsApp Business API,
+// such as sending test messages, uploading and downloading media, inspecting
+// templates and checking a webhook endpoint. It is meant for support engineers
+// and CI smoke tests.
+//
+// The credentials are taken from the environment, and can be overridden with flags:
+//
+//	WHATSAPP_TOKEN                 access token (-token)
+//	WHATSAPP_PHONE_NUMBER_ID       phone number ID (-phone-number-id)
+//	WHATSAPP_BUSINESS_ACCOUNT_ID   business account ID, for templates (-business-account-id)
+//	WHATSAPP_API_VERSION           Graph API version (-api-version)
+//
+// Example usage:
+//
+//	whatsapp send-text -to 1234567890 -body "Hello from CI"
+//	whatsapp upload -file brochure.pdf
+//	whatsapp download -id 1234567890 -o media.bin
+//	whatsapp templates -name order_update -lang en_US
+//	whatsapp webhook-verify -url https://example.com/webhook -verify-token secret -app-secret appsecret
+//	whatsapp webhook-record -addr :8080 -dir webhooks -forward http://localhost:9000/webhook
+//	whatsapp webhook-replay -dir webhooks -url http://localhost:9000/webhook -app-secret test-secret
+//	whatsapp schema -dir schemas
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yarcat/whatsapp-go"
+)
+
+// command is a subcommand of the tool.
+type command struct {
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"send-text":      {"send a text message", sendText},
+	"send-template":  {"send a template message", sendTemplate},
+	"upload":         {"upload a media file and print its media ID", upload},
+	"media-info":     {"print the information of a media ID", mediaInfo},
+	"download":       {"download the content of a media ID", download},
+	"templates":      {"list templates, or print the definition of one", templates},
+	"webhook-verify": {"check that a webhook endpoint answers verification and notifications", webhookVerify},
+	"webhook-record": {"record notifications while forwarding them to a webhook", webhookRecord},
+	"webhook-replay": {"replay recorded notifications, signed, against a webhook", webhookReplay},
+	"schema":         {"print or write the JSON Schemas of the payload models", schema},
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := cmd.run(ctx, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "whatsapp %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: whatsapp <command> [flags]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'whatsapp <command> -h' for the flags of a command.\n")
+}
+
+// clientFlags are the flags of the commands calling the API.
+type clientFlags struct {
+	token, phoneNumberID, businessAccountID, apiVersion string
+	timeout                                             time.Duration
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *clientFlags) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cf := &clientFlags{}
+	fs.StringVar(&cf.token, "token", os.Getenv("WHATSAPP_TOKEN"), "access `token`")
+	fs.StringVar(&cf.phoneNumberID, "phone-number-id", os.Getenv("WHATSAPP_PHONE_NUMBER_ID"), "phone number `ID`")
+	fs.StringVar(&cf.businessAccountID, "business-account-id", os.Getenv("WHATSAPP_BUSINESS_ACCOUNT_ID"), "business account `ID`")
+	fs.StringVar(&cf.apiVersion, "api-version", os.Getenv("WHATSAPP_API_VERSION"), "Graph API `version`, e.g. v23.0")
+	fs.DurationVar(&cf.timeout, "timeout", time.Minute, "timeout of API requests")
+	return fs, cf
+}
+
+// client returns the client configured by the flags.
+func (cf *clientFlags) client() (*whatsapp.Client, error) {
+	if cf.token == "" {
+		return nil, errors.New("missing access token, set WHATSAPP_TOKEN or -token")
+	}
+	opts := []whatsapp.ClientOption{
+		whatsapp.WithTunedTransport(),
+		whatsapp.WithSendTimeout(cf.timeout),
+		whatsapp.WithUploadTimeout(cf.timeout),
+		whatsapp.WithDownloadTimeout(cf.timeout),
+	}
+	if cf.businessAccountID != "" {
+		opts = append(opts, whatsapp.WithBusinessAccountID(cf.businessAccountID))
+	}
+	if cf.apiVersion != "" {
+		opts = append(opts, whatsapp.WithAPIVersion(cf.apiVersion))
+	}
+	return whatsapp.NewClient(cf.token, cf.phoneNumberID, opts...), nil
+}
+
+// requirePhoneNumber returns an error if the phone number ID is missing.
+func (cf *clientFlags) requirePhoneNumber() error {
+	if cf.phoneNumberID == "" {
+		return errors.New("missing phone number ID, set WHATSAPP_PHONE_NUMBER_ID or -phone-number-id")
+	}
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func sendText(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("send-text")
+	to := fs.String("to", "", "recipient phone number")
+	body := fs.String("body", "", "message text")
+	previewURL := fs.Bool("preview-url", false, "render a preview of the first URL")
+	fs.Parse(args)
+	if *to == "" || *body == "" {
+		return errors.New("-to and -body are required")
+	}
+	if err := cf.requirePhoneNumber(); err != nil {
+		return err
+	}
+
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+	response, err := client.SendText(ctx, *to, &whatsapp.SendTextParams{Body: *body, PreviewURL: *previewURL})
+	if err != nil {
+		return err
+	}
+	return printJSON(response)
+}
+
+func sendTemplate(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("send-template")
+	to := fs.String("to", "", "recipient phone number")
+	name := fs.String("name", "", "template name")
+	lang := fs.String("lang", "en_US", "template language")
+	var params []whatsapp.TemplateParameter
+	fs.Func("param", "positional body parameter, repeat for each `value` in order", func(value string) error {
+		params = append(params, whatsapp.TextParameter(value))
+		return nil
+	})
+	fs.Func("named", "named body parameter, repeat for each `name=value`", func(value string) error {
+		name, text, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("want name=value, got %q", value)
+		}
+		params = append(params, whatsapp.NamedTextParameter(name, text))
+		return nil
+	})
+	fs.Parse(args)
+	if *to == "" || *name == "" {
+		return errors.New("-to and -name are required")
+	}
+	if err := cf.requirePhoneNumber(); err != nil {
+		return err
+	}
+
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+	template := &whatsapp.SendTemplateParams{Name: *name, Language: &whatsapp.TemplateLanguage{Code: *lang}}
+	if len(params) > 0 {
+		template.Components = []whatsapp.TemplateComponent{whatsapp.BodyComponent(params...)}
+	}
+	response, err := client.SendTemplate(ctx, *to, template)
+	if err != nil {
+		return err
+	}
+	return printJSON(response)
+}
+
+func upload(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("upload")
+	file := fs.String("file", "", "`path` of the file to upload")
+	mimeType := fs.String("mime", "", "MIME type, detected if empty")
+	fs.Parse(args)
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+	if err := cf.requirePhoneNumber(); err != nil {
+		return err
+	}
+
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+	response, err := client.UploadMediaFromFile(ctx, *file, *mimeType)
+	if err != nil {
+		return err
+	}
+	return printJSON(response)
+}
+
+func mediaInfo(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("media-info")
+	id := fs.String("id", "", "media ID")
+	fs.Parse(args)
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+	info, err := client.GetMedia(ctx, *id)
+	if err != nil {
+		return err
+	}
+	return printJSON(info)
+}
+
+func download(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("download")
+	id := fs.String("id", "", "media ID")
+	output := fs.String("o", "", "output `path`, stdout if empty")
+	fs.Parse(args)
+	if *id == "" {
+		return errors.New("-id is required")
+	}
+
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+	info, content, err := client.GetAndDownloadMedia(ctx, *id)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	n, err := io.Copy(out, content)
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "wrote %d bytes of %s to %s\n", n, info.MimeType, *output)
+	}
+	return nil
+}
+
+func templates(ctx context.Context, args []string) error {
+	fs, cf := newFlagSet("templates")
+	name := fs.String("name", "", "template name, lists all templates if empty")
+	lang := fs.String("lang", "en_US", "template language")
+	fs.Parse(args)
+	if cf.businessAccountID == "" {
+		return errors.New("missing business account ID, set WHATSAPP_BUSINESS_ACCOUNT_ID or -business-account-id")
+	}
+
+	client, err := cf.client()
+	if err != nil {
+		return err
+	}
+	if *name != "" {
+		template, err := client.GetTemplate(ctx, *name, *lang)
+		if err != nil {
+			return err
+		}
+		return printJSON(template)
+	}
+
+	var response struct {
+		Data []whatsapp.MessageTemplate `json:"data"`
+	}
+	if err := client.Do(ctx, http.MethodGet, []string{cf.businessAccountID, "message_templates"}, nil, &response); err != nil {
+		return err
+	}
+	for _, template := range response.Data {
+		fmt.Printf("%-40s %-8s %-12s %s\n", template.Name, template.Language, template.Category, template.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/yarcat/whatsapp-go"
+	"github.com/yarcat/whatsapp-go/webhookdev"
+)
+
+func webhookRecord(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("webhook-record", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "`address` to listen on")
+	dir := fs.String("dir", "webhooks", "`directory` to store the notifications in")
+	forward := fs.String("forward", "", "`URL` of a webhook to forward the requests to, e.g. a local handler")
+	verifyToken := fs.String("verify-token", os.Getenv("WHATSAPP_VERIFY_TOKEN"), "verify `token` answered without -forward")
+	fs.Parse(args)
+
+	var next http.Handler
+	if *forward != "" {
+		target, err := url.Parse(*forward)
+		if err != nil {
+			return fmt.Errorf("invalid -forward URL: %w", err)
+		}
+		next = httputil.NewSingleHostReverseProxy(target)
+	} else {
+		// Without a handler to forward to, answer verification and accept every notification
+		webhook := whatsapp.NewWebhook(*verifyToken, "", whatsapp.WebhookHandlerFunc(
+			func(ctx context.Context, w http.ResponseWriter, r *whatsapp.WebhookRequest) {
+				w.WriteHeader(http.StatusOK)
+			}))
+		webhook.AllowUnsigned = true
+		next = webhook
+	}
+
+	recorder := webhookdev.NewRecorder(*dir, next)
+	recorder.OnError = func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	server := &http.Server{Addr: *addr, Handler: recorder, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	fmt.Fprintf(os.Stderr, "recording notifications to %s, listening on %s\n", *dir, *addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func webhookReplay(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("webhook-replay", flag.ExitOnError)
+	dir := fs.String("dir", "webhooks", "`directory` of the recorded notifications")
+	endpoint := fs.String("url", "", "webhook `URL` to replay the notifications to")
+	appSecret := fs.String("app-secret", os.Getenv("WHATSAPP_APP_SECRET"), "app `secret` to sign the notifications with")
+	fs.Parse(args)
+	if *endpoint == "" || *appSecret == "" {
+		return errors.New("-url and -app-secret are required")
+	}
+
+	replayer := &webhookdev.Replayer{AppSecret: *appSecret, URL: *endpoint, Client: &http.Client{Timeout: 30 * time.Second}}
+	results, err := replayer.Replay(ctx, *dir)
+	failed := 0
+	for _, result := range results {
+		if result.Err == nil && result.StatusCode != http.StatusOK {
+			failed++
+		}
+		fmt.Printf("%d %s\n", result.StatusCode, result.File)
+	}
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d notifications were not accepted", failed, len(results))
+	}
+	return nil
+}
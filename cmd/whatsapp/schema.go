@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yarcat/whatsapp-go"
+)
+
+func schema(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	name := fs.String("type", "", "`name` of the payload model to print, e.g. Request")
+	dir := fs.String("dir", "", "`directory` to write the schemas of all payload models to, as <name>.schema.json")
+	fs.Parse(args)
+	if (*name == "") == (*dir == "") {
+		names := make([]string, 0, len(whatsapp.SchemaTypes))
+		for name := range whatsapp.SchemaTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("one of -type or -dir is required; types: %s", strings.Join(names, ", "))
+	}
+
+	if *name != "" {
+		v, ok := whatsapp.SchemaTypes[*name]
+		if !ok {
+			return fmt.Errorf("unknown payload model %q", *name)
+		}
+		schema, err := whatsapp.JSONSchema(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Println(string(schema))
+		return err
+	}
+
+	schemas, err := whatsapp.JSONSchemas()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	for name, schema := range schemas {
+		if err := os.WriteFile(filepath.Join(*dir, name+".schema.json"), append(schema, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d schemas to %s\n", len(schemas), *dir)
+	return nil
+}
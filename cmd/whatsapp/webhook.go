@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yarcat/whatsapp-go/webhookdev"
+)
+
+// samplePayload is a status notification sent by webhook-verify if no payload file is given.
+const samplePayload = `{"object":"whatsapp_business_account","entry":[{"id":"0","changes":[{"field":"messages","value":{"messaging_product":"whatsapp","metadata":{"display_phone_number":"15550000000","phone_number_id":"0"},"statuses":[{"id":"wamid.webhook-verify","status":"sent","timestamp":"%d","recipient_id":"15550000001"}]}}]}]}`
+
+func webhookVerify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("webhook-verify", flag.ExitOnError)
+	endpoint := fs.String("url", "", "webhook `URL`")
+	verifyToken := fs.String("verify-token", os.Getenv("WHATSAPP_VERIFY_TOKEN"), "verify `token` configured for the webhook")
+	appSecret := fs.String("app-secret", os.Getenv("WHATSAPP_APP_SECRET"), "app `secret` to sign a test notification with, skipped if empty")
+	payloadFile := fs.String("payload", "", "`path` of the notification to send, a status update if empty")
+	fs.Parse(args)
+	if *endpoint == "" || *verifyToken == "" {
+		return errors.New("-url and -verify-token are required")
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	if err := checkChallenge(ctx, client, *endpoint, *verifyToken); err != nil {
+		return fmt.Errorf("verification: %w", err)
+	}
+	fmt.Println("ok   verification challenge answered")
+	if *appSecret == "" {
+		return nil
+	}
+
+	payload := []byte(fmt.Sprintf(samplePayload, time.Now().Unix()))
+	if *payloadFile != "" {
+		var err error
+		if payload, err = os.ReadFile(*payloadFile); err != nil {
+			return err
+		}
+	}
+	status, err := postNotification(ctx, client, *endpoint, payload, *appSecret)
+	if err != nil {
+		return fmt.Errorf("notification: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("signed notification answered with %d, want 200", status)
+	}
+	fmt.Println("ok   signed notification accepted")
+
+	if status, err = postNotification(ctx, client, *endpoint, payload, *appSecret+"-invalid"); err != nil {
+		return fmt.Errorf("notification: %w", err)
+	}
+	if status < 400 || status >= 500 {
+		return fmt.Errorf("notification with an invalid signature answered with %d, want 4xx", status)
+	}
+	fmt.Println("ok   invalid signature rejected")
+	return nil
+}
+
+// checkChallenge sends a verification request and checks that the challenge is echoed.
+func checkChallenge(ctx context.Context, client *http.Client, endpoint, verifyToken string) error {
+	var nonce [8]byte
+	rand.Read(nonce[:])
+	challenge := hex.EncodeToString(nonce[:])
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	query.Set("hub.mode", "subscribe")
+	query.Set("hub.verify_token", verifyToken)
+	query.Set("hub.challenge", challenge)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("answered with %s, want 200 OK", resp.Status)
+	}
+	if got := strings.TrimSpace(string(body)); got != challenge {
+		return fmt.Errorf("answered with %q, want the challenge %q", got, challenge)
+	}
+	return nil
+}
+
+// postNotification sends payload signed with appSecret and returns the response status.
+func postNotification(ctx context.Context, client *http.Client, endpoint string, payload []byte, appSecret string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature-256", webhookdev.Sign(payload, appSecret))
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,160 @@
+package whatsapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// JSONCodec encodes and decodes JSON. It allows replacing encoding/json on
+// hot paths with a faster implementation. The APIs of popular libraries like
+// jsoniter and sonic implement it directly.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithJSONCodec(jsoniter.ConfigCompatibleWithStandardLibrary))
+//
+//	webhook := NewWebhook(verifyToken, appSecret, handler)
+//	webhook.JSONCodec = sonic.ConfigStd
+type JSONCodec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// StdJSONCodec is the JSONCodec using encoding/json. It is used if no codec is configured.
+var StdJSONCodec JSONCodec = stdJSONCodec{}
+
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (stdJSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// WithJSONCodec makes the client encode requests and decode responses of the
+// Graph API with codec instead of encoding/json.
+func WithJSONCodec(codec JSONCodec) ClientOption {
+	return func(wa *Client) {
+		wa.JSONCodec = codec
+	}
+}
+
+// decodeJSON decodes the JSON value read from r into v with codec, or with a
+// streaming encoding/json decoder if codec is nil. Like json.Unmarshal, it
+// rejects anything but whitespace after the value.
+func decodeJSON(codec JSONCodec, r io.Reader, v any) error {
+	if codec == nil {
+		decoder := json.NewDecoder(r)
+		if err := decoder.Decode(v); err != nil {
+			return err
+		}
+		_, err := decoder.Token()
+		var syntaxErr *json.SyntaxError
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil && !errors.As(err, &syntaxErr):
+			return err
+		}
+		return errors.New("invalid data after top-level JSON value")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(data, v)
+}
+
+// maxPooledJSONBuffer is the capacity above which encoding buffers are not
+// returned to the pool, so that a single large request doesn't pin its memory.
+const maxPooledJSONBuffer = 64 << 10
+
+// jsonBuffer is a pooled buffer with an encoder writing to it.
+type jsonBuffer struct {
+	bytes.Buffer
+	encoder *json.Encoder
+}
+
+var jsonBufferPool = sync.Pool{
+	New: func() any {
+		b := new(jsonBuffer)
+		b.encoder = json.NewEncoder(&b.Buffer)
+		return b
+	},
+}
+
+// encodeJSON encodes v with codec, or with a pooled encoding/json encoder if
+// codec is nil. The returned payload is only valid until release is called.
+func encodeJSON(codec JSONCodec, v any) (payload []byte, release func(), err error) {
+	if codec != nil {
+		payload, err = codec.Marshal(v)
+		return payload, func() {}, err
+	}
+
+	b := jsonBufferPool.Get().(*jsonBuffer)
+	release = func() {
+		if b.Cap() <= maxPooledJSONBuffer {
+			b.Reset()
+			jsonBufferPool.Put(b)
+		}
+	}
+	if err := b.encoder.Encode(v); err != nil {
+		release()
+		return nil, nil, err
+	}
+	// Unlike json.Marshal, the encoder terminates values with a newline
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), release, nil
+}
+
+// pooledBody is a request body reading a payload encoded into a pooled
+// buffer, which is released once the sender and the transport are done with
+// it. The transport may read and close the body after the response was
+// returned, e.g. on an early error response or with HTTP/2, and may read it
+// again through GetBody to resend the request.
+type pooledBody struct {
+	mu      sync.Mutex
+	payload []byte
+	refs    int // refs are the sender and the open readers of payload.
+	release func()
+}
+
+// newPooledBody returns the body of payload, referenced by the sender until
+// it calls done.
+func newPooledBody(payload []byte, release func()) *pooledBody {
+	return &pooledBody{payload: payload, refs: 1, release: release}
+}
+
+// open returns a reader of the payload, for Request.Body and Request.GetBody.
+func (b *pooledBody) open() (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.refs == 0 {
+		return nil, errors.New("request body already released")
+	}
+	b.refs++
+	return &pooledBodyReader{Reader: bytes.NewReader(b.payload), body: b}, nil
+}
+
+// done drops a reference to the payload, and releases it after the last one.
+func (b *pooledBody) done() {
+	b.mu.Lock()
+	b.refs--
+	last := b.refs == 0
+	b.mu.Unlock()
+	if last {
+		b.release()
+	}
+}
+
+// pooledBodyReader is a reader of a pooledBody, dropping its reference when closed.
+type pooledBodyReader struct {
+	*bytes.Reader
+	body *pooledBody
+	once sync.Once
+}
+
+// Close implements io.Closer.
+func (r *pooledBodyReader) Close() error {
+	r.once.Do(r.body.done)
+	return nil
+}
@@ -0,0 +1,223 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestClient returns a client sending its requests to handler.
+func newTestClient(t testing.TB, handler http.HandlerFunc, opts ...ClientOption) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := NewClient("test-token", "123456", opts...)
+	client.BaseURL = server.URL
+	return client
+}
+
+// messagesHandler answers every request with a sent message.
+func messagesHandler(w http.ResponseWriter, r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, `{"messaging_product":"whatsapp","contacts":[{"input":"15551234567","wa_id":"15551234567"}],"messages":[{"id":"wamid.1"}]}`)
+}
+
+func testTextRequest(body string) *Request {
+	return &Request{
+		MessagingProduct: MessagingProductWhatsApp,
+		RecipientType:    RecipientTypeIndividual,
+		To:               "15551234567",
+		Type:             MessageTypeText,
+		Text:             &SendTextParams{Body: body},
+	}
+}
+
+func TestPayloadsOutliveReleasedBuffers(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		payloads [][]byte
+		records  []*AuditRecord
+	)
+	client := newTestClient(t, messagesHandler)
+	client.AfterResponse = append(client.AfterResponse, func(resp *http.Response, info *RequestInfo, err error) {
+		// The payload is valid until the request completed, and copied to be kept
+		mu.Lock()
+		defer mu.Unlock()
+		payloads = append(payloads, bytes.Clone(info.Payload))
+	})
+	client.Auditor = &Auditor{
+		IncludePayload: true,
+		RedactFields:   []string{},
+		Record: func(ctx context.Context, record *AuditRecord) {
+			mu.Lock()
+			defer mu.Unlock()
+			records = append(records, record)
+		},
+	}
+
+	bodies := []string{"first message", "second"}
+	for _, body := range bodies {
+		if _, err := client.SendMessage(context.Background(), testTextRequest(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	overwritePooledBuffers()
+
+	for i, body := range bodies {
+		want, err := json.Marshal(testTextRequest(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(payloads[i], want) {
+			t.Errorf("RequestInfo.Payload %d = %s, want %s", i, payloads[i], want)
+		}
+		// The auditor re-encodes the payload, so the values are compared
+		var got, wantValue any
+		json.Unmarshal(want, &wantValue)
+		if err := json.Unmarshal(records[i].Payload, &got); err != nil {
+			t.Fatalf("AuditRecord.Payload %d = %s: %v", i, records[i].Payload, err)
+		}
+		if !reflect.DeepEqual(got, wantValue) {
+			t.Errorf("AuditRecord.Payload %d = %s, want %s", i, records[i].Payload, want)
+		}
+	}
+}
+
+func TestPreviewOutlivesReleasedBuffers(t *testing.T) {
+	client := NewClient("test-token", "123456")
+	payload, err := client.Preview(testTextRequest("previewed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	overwritePooledBuffers()
+	want := mustMarshal(t, testTextRequest("previewed"))
+	if !bytes.Equal(payload, want) {
+		t.Errorf("Preview = %s, want %s", payload, want)
+	}
+}
+
+// lateBodyTransport answers every request with a sent message before reading
+// its body, which it keeps to be read after the response was returned.
+type lateBodyTransport struct {
+	bodies []io.ReadCloser
+}
+
+func (t *lateBodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.bodies = append(t.bodies, req.Body)
+	recorder := httptest.NewRecorder()
+	messagesHandler(recorder, httptest.NewRequest(http.MethodPost, "/", http.NoBody))
+	return recorder.Result(), nil
+}
+
+func TestRequestBodyOutlivesResponse(t *testing.T) {
+	transport := &lateBodyTransport{}
+	client := NewClient("test-token", "123456", WithHTTPClient(&http.Client{Transport: transport}))
+	if _, err := client.SendMessage(context.Background(), testTextRequest("read late")); err != nil {
+		t.Fatal(err)
+	}
+	overwritePooledBuffers()
+
+	body := transport.bodies[0]
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body.Close()
+	if want := mustMarshal(t, testTextRequest("read late")); !bytes.Equal(payload, want) {
+		t.Errorf("request body read after the response = %s, want %s", payload, want)
+	}
+}
+
+// overwritePooledBuffers encodes requests into the pooled buffers, which
+// overwrites the payloads still referencing them.
+func overwritePooledBuffers() {
+	for range 10 {
+		_, release, _ := encodeJSON(nil, testTextRequest("overwritten"))
+		release()
+	}
+}
+
+func mustMarshal(t testing.TB, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkEncodeJSON(b *testing.B) {
+	request := testTextRequest("Your order has shipped and will arrive on Tuesday.")
+	for _, bc := range []struct {
+		name  string
+		codec JSONCodec
+	}{
+		{"pooled", nil},
+		{"unpooled", StdJSONCodec},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_, release, err := encodeJSON(bc.codec, request)
+				if err != nil {
+					b.Fatal(err)
+				}
+				release()
+			}
+		})
+	}
+}
+
+func BenchmarkSendRequest(b *testing.B) {
+	request := testTextRequest("Your order has shipped and will arrive on Tuesday.")
+	for _, bc := range []struct {
+		name string
+		opts []ClientOption
+	}{
+		{"pooled", nil},
+		{"unpooled", []ClientOption{WithJSONCodec(StdJSONCodec)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			client := newTestClient(b, messagesHandler, bc.opts...)
+			ctx := context.Background()
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := client.SendMessage(ctx, request); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeJSONTrailingData(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+		wantErr     bool
+	}{
+		{"single value", `{"id":"wamid.1"}`, false},
+		{"trailing whitespace", "{\"id\":\"wamid.1\"}\n\t ", false},
+		{"second value", `{"id":"wamid.1"}{"id":"wamid.2"}`, true},
+		{"trailing garbage", `{"id":"wamid.1"} garbage`, true},
+		{"trailing delimiter", `{"id":"wamid.1"}}`, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var v struct{ ID string }
+			err := decodeJSON(nil, strings.NewReader(tc.input), &v)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("decodeJSON(%q) = %v, want error %t", tc.input, err, tc.wantErr)
+			}
+			if err := json.Unmarshal([]byte(tc.input), &v); (err != nil) != tc.wantErr {
+				t.Errorf("json.Unmarshal(%q) = %v, decodeJSON should agree", tc.input, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxConsumerErrorBytes is the length of the response body kept as the
+	// error of an envelope the webhook rejected.
+	maxConsumerErrorBytes = 512
+	// consumerRetryDelay is the delay after a failed Receive.
+	consumerRetryDelay = time.Second
+)
+
+// ErrReceiverClosed is returned by a Receiver that has no more envelopes.
+var ErrReceiverClosed = errors.New("receiver closed")
+
+// WebhookEnvelope is a webhook notification forwarded through a queue, e.g.
+// by an API gateway publishing the requests of Meta to SQS or Pub/Sub.
+type WebhookEnvelope struct {
+	// Body is the request body as sent by Meta.
+	Body []byte
+	// Header holds the forwarded request headers. With the
+	// X-Hub-Signature-256 header of Meta, the signature is verified as for
+	// HTTP requests; without it, the Webhook must AllowUnsigned.
+	Header http.Header
+	// RemoteIP is the IP address of the original client, if forwarded. It
+	// only ends up in the WebhookContext; AllowedNetworks isn't checked.
+	RemoteIP string
+	// Done, if set, is called once the envelope is handled, with nil if the
+	// webhook accepted it, e.g. to delete it from the queue, or with the
+	// error, to leave it for redelivery.
+	Done func(err error)
+}
+
+// Receiver is a source of webhook envelopes, such as a queue subscription.
+// Receive blocks until an envelope is available, and returns
+// ErrReceiverClosed when there are no more, or the error of ctx. It must be
+// safe for concurrent use.
+type Receiver interface {
+	Receive(ctx context.Context) (*WebhookEnvelope, error)
+}
+
+// ReceiverFunc is a function type that implements the Receiver interface.
+type ReceiverFunc func(ctx context.Context) (*WebhookEnvelope, error)
+
+// Receive calls the function with the given parameters.
+func (f ReceiverFunc) Receive(ctx context.Context) (*WebhookEnvelope, error) {
+	return f(ctx)
+}
+
+// ChannelReceiver returns a Receiver of the envelopes sent to ch, which is
+// closed when ch is closed.
+func ChannelReceiver(ch <-chan *WebhookEnvelope) Receiver {
+	return ReceiverFunc(func(ctx context.Context) (*WebhookEnvelope, error) {
+		select {
+		case envelope, ok := <-ch:
+			if !ok {
+				return nil, ErrReceiverClosed
+			}
+			return envelope, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+}
+
+// WebhookConsumer passes the envelopes of a Receiver through a Webhook, so
+// services receiving notifications from a queue instead of HTTP reuse its
+// signature checks, replay filtering, event sink, message store, routing and
+// middleware. An envelope is handled like an HTTP request; it is accepted if
+// the webhook responds with a 2xx status. The IDs of the messages and
+// statuses of rejected envelopes are forgotten by SeenIDs, so the envelopes
+// are handled again when they are redelivered after a Nack.
+//
+// Example usage:
+//
+//	consumer := NewWebhookConsumer(webhook, ReceiverFunc(func(ctx context.Context) (*WebhookEnvelope, error) {
+//	    message, err := subscription.Next(ctx)
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return &WebhookEnvelope{
+//	        Body:   message.Data,
+//	        Header: http.Header{"X-Hub-Signature-256": {message.Attributes["signature"]}},
+//	        Done: func(err error) {
+//	            if err == nil {
+//	                message.Ack()
+//	            } else {
+//	                message.Nack()
+//	            }
+//	        },
+//	    }, nil
+//	}))
+//	consumer.Concurrency = 8
+//	consumer.Start(ctx)
+//	...
+//	ShutdownAll(ctx, consumer, webhook)
+type WebhookConsumer struct {
+	// Webhook handles the envelopes.
+	Webhook *Webhook
+	// Receiver supplies the envelopes.
+	Receiver Receiver
+	// Concurrency is the number of envelopes handled at the same time, one if
+	// zero. Envelopes handled concurrently may be handled out of order.
+	Concurrency int
+	// OnError, if set, is called with the errors of Receive, other than the
+	// ones of shutting down, and with the errors of rejected envelopes.
+	// Receiving is retried a second after an error.
+	OnError func(err error)
+
+	mu   sync.Mutex
+	stop chan struct{} // stop is closed by Shutdown.
+	done chan struct{} // done is closed when the workers returned.
+}
+
+// NewWebhookConsumer creates a WebhookConsumer passing the envelopes of receiver through webhook.
+func NewWebhookConsumer(webhook *Webhook, receiver Receiver) *WebhookConsumer {
+	return &WebhookConsumer{Webhook: webhook, Receiver: receiver}
+}
+
+// Handle passes envelope through the webhook, and returns an error if the
+// webhook rejected it or its handler panicked. It doesn't call the Done
+// function of the envelope.
+func (c *WebhookConsumer) Handle(ctx context.Context, envelope *WebhookEnvelope) (err error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", bytes.NewReader(envelope.Body))
+	if err != nil {
+		return err
+	}
+	if envelope.Header != nil {
+		r.Header = envelope.Header.Clone()
+	}
+	r.RemoteAddr = envelope.RemoteIP
+	w := &consumerResponse{header: make(http.Header)}
+	defer func() {
+		// A panic would kill the worker and leave the envelope unacknowledged
+		if v := recover(); v != nil {
+			err = fmt.Errorf("webhook panicked: %v", v)
+		}
+	}()
+	c.Webhook.handleWebhookPOST(w, r)
+	if status := w.statusCode(); status < 200 || status > 299 {
+		return fmt.Errorf("webhook responded with %d %s: %s", status, http.StatusText(status), strings.TrimSpace(w.body.String()))
+	}
+	return nil
+}
+
+// Start implements Lifecycle. It starts Concurrency workers handling the
+// envelopes of the Receiver until it is closed or Shutdown is called.
+func (c *WebhookConsumer) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		return fmt.Errorf("webhook consumer already started")
+	}
+	c.stop, c.done = make(chan struct{}), make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		c.run(ctx, stop)
+	}(c.stop, c.done)
+	return nil
+}
+
+// Shutdown implements Lifecycle. It stops receiving envelopes and waits for
+// the ones being handled.
+func (c *WebhookConsumer) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	stop, done := c.stop, c.done
+	if stop != nil {
+		select {
+		case <-stop:
+		default:
+			close(stop)
+		}
+	}
+	c.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run handles envelopes with Concurrency workers until the receiver is
+// closed, ctx is done or stop is closed.
+func (c *WebhookConsumer) run(ctx context.Context, stop <-chan struct{}) {
+	// Receiving stops on stop, while envelopes being handled finish with ctx
+	receiveCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-receiveCtx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range max(c.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.work(ctx, receiveCtx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work receives and handles envelopes until receiving fails for good.
+func (c *WebhookConsumer) work(ctx, receiveCtx context.Context) {
+	for {
+		envelope, err := c.Receiver.Receive(receiveCtx)
+		if err != nil {
+			if receiveCtx.Err() != nil || errors.Is(err, ErrReceiverClosed) {
+				return
+			}
+			c.report(fmt.Errorf("receiving webhook envelope: %w", err))
+			timer := time.NewTimer(consumerRetryDelay)
+			select {
+			case <-timer.C:
+			case <-receiveCtx.Done():
+				timer.Stop()
+				return
+			}
+			continue
+		}
+		err = c.Handle(ctx, envelope)
+		if err != nil {
+			c.report(err)
+		}
+		if envelope.Done != nil {
+			envelope.Done(err)
+		}
+	}
+}
+
+// report passes err to OnError, if set.
+func (c *WebhookConsumer) report(err error) {
+	if c.OnError != nil {
+		c.OnError(err)
+	}
+}
+
+// consumerResponse records the response of the webhook to an envelope.
+type consumerResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer // body is the beginning of the response body.
+}
+
+func (cr *consumerResponse) Header() http.Header {
+	return cr.header
+}
+
+func (cr *consumerResponse) WriteHeader(status int) {
+	if cr.status == 0 {
+		cr.status = status
+	}
+}
+
+func (cr *consumerResponse) Write(p []byte) (int, error) {
+	cr.WriteHeader(http.StatusOK)
+	if n := maxConsumerErrorBytes - cr.body.Len(); n > 0 {
+		cr.body.Write(p[:min(n, len(p))])
+	}
+	return len(p), nil
+}
+
+// statusCode returns the status of the response, 200 if the webhook wrote none.
+func (cr *consumerResponse) statusCode() int {
+	if cr.status == 0 {
+		return http.StatusOK
+	}
+	return cr.status
+}
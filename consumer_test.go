@@ -0,0 +1,35 @@
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWebhookConsumerHandleRecoversPanics(t *testing.T) {
+	var calls int
+	wh := NewWebhook("verify", testAppSecret, WebhookHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *WebhookRequest) {
+		calls++
+		if calls == 1 {
+			panic("handler failed")
+		}
+	}))
+	wh.SeenIDs = NewMemorySeenCache(time.Hour)
+	consumer := NewWebhookConsumer(wh, nil)
+
+	envelope := &WebhookEnvelope{
+		Body:   []byte(replayTestBody),
+		Header: newSignedWebhookRequest(replayTestBody).Header,
+	}
+	if err := consumer.Handle(context.Background(), envelope); err == nil {
+		t.Fatal("Handle of a panicking handler returned no error")
+	}
+	// The redelivery of the nacked envelope isn't dropped as a replay
+	if err := consumer.Handle(context.Background(), envelope); err != nil {
+		t.Fatalf("Handle of the redelivery: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,231 @@
+package whatsapp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// conversationLifetime is how long a conversation lasts if its status doesn't tell.
+const conversationLifetime = 24 * time.Hour
+
+// PriceTable lists the prices of billable conversations, or messages under
+// per-message pricing, by pricing category, taken from the rate cards of Meta.
+// https://developers.facebook.com/docs/whatsapp/pricing
+//
+// Example usage:
+//
+//	prices := &PriceTable{
+//	    Currency: "USD",
+//	    Rates:    map[PricingCategory]float64{PricingCategoryMarketing: 0.025, PricingCategoryUtility: 0.004},
+//	    Countries: map[string]map[PricingCategory]float64{
+//	        "49": {PricingCategoryMarketing: 0.1365, PricingCategoryUtility: 0.0456},
+//	    },
+//	}
+type PriceTable struct {
+	// Currency is the ISO 4217 code of the prices.
+	Currency string
+	// Rates are the prices of the categories for recipients of countries
+	// missing from Countries. Categories missing from it are free.
+	Rates map[PricingCategory]float64
+	// Countries are the prices by country calling code, e.g. "1" or "49",
+	// matched against the beginning of the recipient phone number.
+	Countries map[string]map[PricingCategory]float64
+}
+
+// Price returns the price of a billable conversation or message of the category with the recipient.
+func (p *PriceTable) Price(recipient string, category PricingCategory) float64 {
+	// Calling codes are at most three digits, the longest one matching wins
+	for n := min(3, len(recipient)); n > 0; n-- {
+		if rates, ok := p.Countries[recipient[:n]]; ok {
+			return rates[category]
+		}
+	}
+	return p.Rates[category]
+}
+
+// ExchangeRates are the values of currencies relative to a common base, e.g.
+// {"USD": 1, "EUR": 0.92} for amounts of one US dollar.
+type ExchangeRates map[string]float64
+
+// Convert converts amount from one currency to another.
+func (r ExchangeRates) Convert(amount float64, from, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, ok := r[from]
+	if !ok || fromRate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for %q", from)
+	}
+	toRate, ok := r[to]
+	if !ok || toRate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for %q", to)
+	}
+	return amount / fromRate * toRate, nil
+}
+
+// CostEntry is the estimated cost of the conversations and messages of a pricing category in a campaign.
+type CostEntry struct {
+	// Campaign is the biz_opaque_callback_data of the messages, empty for untagged messages.
+	Campaign string `json:"campaign"`
+	// Category is the pricing category of the conversations.
+	Category PricingCategory `json:"category"`
+	// Conversations is the number of conversations under conversation-based
+	// pricing, Messages the number of messages under per-message pricing, and
+	// Billable the number of billable ones of both.
+	Conversations int `json:"conversations"`
+	Messages      int `json:"messages"`
+	Billable      int `json:"billable"`
+	// Cost is the estimated cost of the billable conversations and messages in Currency.
+	Cost     float64 `json:"cost"`
+	Currency string  `json:"currency"`
+}
+
+// costKey identifies a CostEntry.
+type costKey struct {
+	campaign string
+	category PricingCategory
+}
+
+// CostTracker estimates the spend of sent messages from the pricing of their
+// status notifications, by campaign and pricing category. Campaigns are taken
+// from the biz_opaque_callback_data of the messages, see
+// ContextWithCallbackData. Both conversation-based and per-message pricing
+// are supported: every conversation, or message, is counted once, although
+// all its statuses carry the pricing. It is an EventSink, and is safe for
+// concurrent use.
+//
+// The estimate uses the prices of the PriceTable, not the actual invoice, and
+// is only as complete as the received notifications.
+//
+// Example usage:
+//
+//	costs := NewCostTracker(prices)
+//	webhook.EventSink = MultiEventSink(reporter, costs)
+//	...
+//	log.Printf("spring sale cost %.2f %s", costs.Total("spring-sale"), prices.Currency)
+type CostTracker struct {
+	// Prices are the prices of the conversations.
+	Prices *PriceTable
+
+	mu      sync.Mutex
+	entries map[costKey]*CostEntry
+	seen    map[string]time.Time // seen are the expiration times of the counted conversations.
+	sweepAt int                  // sweepAt is the size of seen at which expired conversations are removed.
+}
+
+// NewCostTracker creates a CostTracker estimating costs with prices.
+func NewCostTracker(prices *PriceTable) *CostTracker {
+	return &CostTracker{
+		Prices:  prices,
+		entries: make(map[costKey]*CostEntry),
+		seen:    make(map[string]time.Time),
+	}
+}
+
+// WriteEvent implements EventSink by recording the statuses of request.
+func (ct *CostTracker) WriteEvent(ctx context.Context, request *WebhookRequest) error {
+	for _, status := range request.Statuses() {
+		ct.Record(status.WebhookStatus)
+	}
+	return nil
+}
+
+// Record adds the pricing of a status notification to the costs of its
+// campaign, unless its conversation, or message under per-message pricing,
+// was already counted. Statuses without pricing are ignored.
+func (ct *CostTracker) Record(status *WebhookStatus) {
+	if status.Pricing == nil {
+		return
+	}
+	id, expiration := status.ID, time.Now().Add(conversationLifetime)
+	if conversation := status.Conversation; conversation != nil && conversation.ID != "" && !status.Pricing.PerMessage() {
+		id = conversation.ID
+		if t, ok := parseUnixTime(conversation.ExpirationTimestamp); ok {
+			expiration = t
+		}
+	}
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	if ct.counted(id, expiration) {
+		return
+	}
+	key := costKey{campaign: status.BizOpaqueCallbackData, category: status.Pricing.Category}
+	entry := ct.entries[key]
+	if entry == nil {
+		entry = &CostEntry{Campaign: key.campaign, Category: key.category}
+		if ct.Prices != nil {
+			entry.Currency = ct.Prices.Currency
+		}
+		ct.entries[key] = entry
+	}
+	if status.Pricing.PerMessage() {
+		entry.Messages++
+	} else {
+		entry.Conversations++
+	}
+	if status.Pricing.Billable {
+		entry.Billable++
+		if ct.Prices != nil {
+			entry.Cost += ct.Prices.Price(status.RecipientID, status.Pricing.Category)
+		}
+	}
+}
+
+// counted reports whether the conversation was counted already, and marks it
+// counted until expiration otherwise.
+func (ct *CostTracker) counted(id string, expiration time.Time) bool {
+	now := time.Now()
+	if expires, ok := ct.seen[id]; ok && now.Before(expires) {
+		return true
+	}
+	if len(ct.seen) >= ct.sweepAt {
+		for id, expires := range ct.seen {
+			if !now.Before(expires) {
+				delete(ct.seen, id)
+			}
+		}
+		ct.sweepAt = max(2*len(ct.seen), 1024)
+	}
+	ct.seen[id] = expiration
+	return false
+}
+
+// Costs returns the costs of all campaigns and categories, sorted by campaign and category.
+func (ct *CostTracker) Costs() []CostEntry {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	costs := make([]CostEntry, 0, len(ct.entries))
+	for _, entry := range ct.entries {
+		costs = append(costs, *entry)
+	}
+	slices.SortFunc(costs, func(a, b CostEntry) int {
+		return cmp.Or(cmp.Compare(a.Campaign, b.Campaign), cmp.Compare(a.Category, b.Category))
+	})
+	return costs
+}
+
+// Total returns the estimated cost of the campaign in the currency of the prices.
+func (ct *CostTracker) Total(campaign string) float64 {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	var total float64
+	for key, entry := range ct.entries {
+		if key.campaign == campaign {
+			total += entry.Cost
+		}
+	}
+	return total
+}
+
+// Reset removes all costs and counted conversations.
+func (ct *CostTracker) Reset() {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	clear(ct.entries)
+	clear(ct.seen)
+}
@@ -0,0 +1,68 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// DryRunFunc receives the request and the exact JSON body a client in dry-run mode
+// would have posted.
+type DryRunFunc func(request *Request, payload []byte)
+
+// WithDryRun stops the client from sending messages. Every message is validated
+// with ValidateRequest and marshaled, the payload is passed to fn, if not nil,
+// and an empty MessagesResponse without message IDs is returned. Other API calls,
+// such as media uploads, are not affected.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithDryRun(func(request *Request, payload []byte) {
+//	    log.Printf("Would send to %s: %s", request.To, payload)
+//	}))
+func WithDryRun(fn DryRunFunc) ClientOption {
+	return func(wa *Client) {
+		wa.DryRun = true
+		wa.DryRunFunc = fn
+	}
+}
+
+// Preview validates the request with ValidateRequest and returns the exact JSON
+// body that would be posted to the messages endpoint, without sending it.
+//
+// Example usage:
+//
+//	payload, err := client.Preview(&Request{
+//	    MessagingProduct: MessagingProductWhatsApp,
+//	    RecipientType:    RecipientTypeIndividual,
+//	    To:               "1234567890",
+//	    Type:             MessageTypeText,
+//	    Text:             &SendTextParams{Body: "Hello!"},
+//	})
+func (wa *Client) Preview(request *Request) ([]byte, error) {
+	if err := ValidateRequest(request); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	payload, release, err := encodeJSON(wa.JSONCodec, request)
+	if err != nil {
+		return nil, fmt.Errorf("encoding message: %w", err)
+	}
+	defer release()
+	// The payload of a pooled buffer is reused once released
+	return bytes.Clone(payload), nil
+}
+
+// dryRun handles a message in dry-run mode.
+func (wa *Client) dryRun(ctx context.Context, request *Request) (*MessagesResponse, error) {
+	payload, err := wa.Preview(request)
+	if err != nil {
+		return nil, err
+	}
+	if wa.DryRunFunc != nil {
+		wa.DryRunFunc(request, payload)
+	}
+	return &MessagesResponse{
+		MessagingProduct: MessagingProductWhatsApp,
+		Contacts:         []MessagesResponseContact{{Input: request.To}},
+	}, nil
+}
@@ -0,0 +1,316 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sealedVersion is the format version of sealed data.
+const sealedVersion = 1
+
+// ErrUnknownKey is returned by a KeyProvider for unknown key IDs.
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// KeyProvider supplies the AES keys of the encrypted stores, 16, 24 or 32
+// bytes long. Keys are identified by an ID stored with the data, so keys can
+// be rotated while data encrypted with older keys is still read. Implement it
+// on top of a KMS, e.g. by decrypting data keys with it, or use Keyring.
+type KeyProvider interface {
+	// CurrentKey returns the ID and the key new data is encrypted with.
+	CurrentKey(ctx context.Context) (id string, key []byte, err error)
+	// Key returns the key with the ID, or ErrUnknownKey.
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// Keyring is a KeyProvider holding the keys in memory.
+//
+// Example usage:
+//
+//	keys := &Keyring{Current: "2024-06", Keys: map[string][]byte{
+//	    "2024-01": oldKey,
+//	    "2024-06": newKey,
+//	}}
+type Keyring struct {
+	// Current is the ID of the key new data is encrypted with.
+	Current string
+	// Keys are the keys by ID.
+	Keys map[string][]byte
+}
+
+// CurrentKey implements KeyProvider.
+func (k *Keyring) CurrentKey(ctx context.Context) (string, []byte, error) {
+	key, err := k.Key(ctx, k.Current)
+	return k.Current, key, err
+}
+
+// Key implements KeyProvider.
+func (k *Keyring) Key(ctx context.Context, id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+// seal encrypts plaintext with the current key using AES-GCM. The additional
+// data binds the ciphertext to its record, so it can't be moved to another one.
+func seal(ctx context.Context, keys KeyProvider, plaintext, additionalData []byte) ([]byte, error) {
+	id, key, err := keys.CurrentKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting encryption key: %w", err)
+	}
+	if len(id) > 255 {
+		return nil, fmt.Errorf("encryption key ID is longer than 255 bytes")
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	// version, key ID length, key ID, nonce, ciphertext
+	sealed := append([]byte{sealedVersion, byte(len(id))}, id...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed = append(sealed, nonce...)
+	return aead.Seal(sealed, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts data sealed by seal.
+func open(ctx context.Context, keys KeyProvider, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < 2 || sealed[0] != sealedVersion || len(sealed) < 2+int(sealed[1]) {
+		return nil, fmt.Errorf("invalid encrypted data")
+	}
+	id, rest := string(sealed[2:2+int(sealed[1])]), sealed[2+int(sealed[1]):]
+	key, err := keys.Key(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting encryption key: %w", err)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted data")
+	}
+	plaintext, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// newGCM creates an AES-GCM cipher with key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealRequest encrypts the JSON encoding of request for the record with the ID.
+func sealRequest(ctx context.Context, keys KeyProvider, id string, request *Request) ([]byte, error) {
+	plaintext, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("encoding message: %w", err)
+	}
+	return seal(ctx, keys, plaintext, []byte(id))
+}
+
+// openRequest decrypts a request sealed by sealRequest.
+func openRequest(ctx context.Context, keys KeyProvider, id string, sealed []byte) (*Request, error) {
+	plaintext, err := open(ctx, keys, sealed, []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("message %s: %w", id, err)
+	}
+	var request Request
+	if err := json.Unmarshal(plaintext, &request); err != nil {
+		return nil, fmt.Errorf("decoding message %s: %w", id, err)
+	}
+	return &request, nil
+}
+
+// EncryptedScheduleStore is a ScheduleStore encrypting the messages of
+// another one with AES-GCM, for deployments that must not store message
+// contents and recipients in plain text. The inner store receives the
+// messages with Request nil and the encrypted request in Sealed, while the ID
+// and SendAt stay readable for scheduling.
+//
+// Example usage:
+//
+//	store := NewEncryptedScheduleStore(redisScheduleStore, keys)
+//	scheduler := NewScheduler(client, store)
+type EncryptedScheduleStore struct {
+	// Store keeps the encrypted messages.
+	Store ScheduleStore
+	// Keys supplies the encryption keys.
+	Keys KeyProvider
+	// OnError, if set, is called once with every message Due can't decrypt,
+	// e.g. because its key was removed. Such messages are skipped, and Due
+	// reads further messages instead, so they don't hold up the others. They
+	// stay in Store, and are decrypted again by every call to Due, until they
+	// are deleted, e.g. by OnError.
+	OnError func(message *ScheduledMessage, err error)
+
+	mu       sync.Mutex
+	reported map[string]bool // reported are the IDs of the messages reported to OnError.
+}
+
+// NewEncryptedScheduleStore creates an EncryptedScheduleStore keeping the messages in store.
+func NewEncryptedScheduleStore(store ScheduleStore, keys KeyProvider) *EncryptedScheduleStore {
+	return &EncryptedScheduleStore{Store: store, Keys: keys}
+}
+
+// Put implements ScheduleStore.
+func (s *EncryptedScheduleStore) Put(ctx context.Context, message *ScheduledMessage) error {
+	sealed, err := sealRequest(ctx, s.Keys, message.ID, message.Request)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.reported, message.ID)
+	s.mu.Unlock()
+	return s.Store.Put(ctx, &ScheduledMessage{ID: message.ID, SendAt: message.SendAt, Sealed: sealed})
+}
+
+// Due implements ScheduleStore. Messages that can't be decrypted are
+// skipped and reported to OnError, and further messages are read from Store
+// in their place, so only a short batch means there are no more due messages.
+func (s *EncryptedScheduleStore) Due(ctx context.Context, now time.Time, limit int) ([]*ScheduledMessage, error) {
+	requests := make(map[string]*Request) // requests are the decrypted requests by message ID.
+	for n := limit; ; {
+		due, err := s.Store.Due(ctx, now, n)
+		if err != nil {
+			return nil, err
+		}
+		opened := make([]*ScheduledMessage, 0, limit)
+		failed := 0
+		for _, message := range due {
+			if len(opened) == limit {
+				break
+			}
+			request, ok := requests[message.ID]
+			if !ok {
+				request, err = openRequest(ctx, s.Keys, message.ID, message.Sealed)
+				if err != nil {
+					failed++
+					s.report(message, err)
+					continue
+				}
+				requests[message.ID] = request
+			}
+			opened = append(opened, &ScheduledMessage{ID: message.ID, SendAt: message.SendAt, Request: request})
+		}
+		if len(opened) == limit || len(due) < n {
+			return opened, nil
+		}
+		// Store has more messages, read past the ones that can't be decrypted
+		n = limit + failed
+	}
+}
+
+// report calls OnError with message, unless it was reported before.
+func (s *EncryptedScheduleStore) report(message *ScheduledMessage, err error) {
+	if s.OnError == nil {
+		return
+	}
+	s.mu.Lock()
+	reported := s.reported[message.ID]
+	if !reported {
+		if s.reported == nil {
+			s.reported = make(map[string]bool)
+		}
+		s.reported[message.ID] = true
+	}
+	s.mu.Unlock()
+	if !reported {
+		s.OnError(message, err)
+	}
+}
+
+// Delete implements ScheduleStore.
+func (s *EncryptedScheduleStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.reported, id)
+	s.mu.Unlock()
+	return s.Store.Delete(ctx, id)
+}
+
+// PurgeUser implements UserPurger by decrypting the messages of Store and
+// deleting the ones scheduled for the user, which needs Store to implement
+// SealedPurger. Messages that can't be decrypted are kept and reported in the
+// error. Messages stored in plain text are purged by Store, if it implements
+// UserPurger.
+func (s *EncryptedScheduleStore) PurgeUser(ctx context.Context, waID string) error {
+	return purgeSealed(ctx, s.Store, s.Keys, waID)
+}
+
+// EncryptedMessageStore is a MessageStore encrypting the requests of the
+// messages of another one with AES-GCM, like EncryptedScheduleStore. The
+// inner store receives the messages with Request nil and the encrypted
+// request in Sealed, while their IDs and statuses stay readable.
+//
+// Example usage:
+//
+//	store := NewEncryptedMessageStore(sqlMessageStore, keys)
+//	client := NewClient(token, phoneNumberID, WithMessageStore(store))
+//	webhook.MessageStore = store
+type EncryptedMessageStore struct {
+	// Store keeps the encrypted messages.
+	Store MessageStore
+	// Keys supplies the encryption keys.
+	Keys KeyProvider
+}
+
+// NewEncryptedMessageStore creates an EncryptedMessageStore keeping the messages in store.
+func NewEncryptedMessageStore(store MessageStore, keys KeyProvider) *EncryptedMessageStore {
+	return &EncryptedMessageStore{Store: store, Keys: keys}
+}
+
+// Save implements MessageStore.
+func (s *EncryptedMessageStore) Save(ctx context.Context, message *StoredMessage) error {
+	sealed, err := sealRequest(ctx, s.Keys, message.ID, message.Request)
+	if err != nil {
+		return err
+	}
+	stored := *message
+	stored.Request, stored.Sealed = nil, sealed
+	return s.Store.Save(ctx, &stored)
+}
+
+// UpdateStatus implements MessageStore.
+func (s *EncryptedMessageStore) UpdateStatus(ctx context.Context, status *WebhookStatus) error {
+	return s.Store.UpdateStatus(ctx, status)
+}
+
+// Get implements MessageStore.
+func (s *EncryptedMessageStore) Get(ctx context.Context, id string) (*StoredMessage, error) {
+	message, err := s.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	opened := *message
+	if message.Sealed == nil {
+		// Only the status of the message arrived so far
+		return &opened, nil
+	}
+	if opened.Request, err = openRequest(ctx, s.Keys, message.ID, message.Sealed); err != nil {
+		return nil, err
+	}
+	opened.Sealed = nil
+	return &opened, nil
+}
+
+// PurgeUser implements UserPurger by decrypting the messages of Store and
+// deleting the ones sent to the user, like EncryptedScheduleStore.PurgeUser.
+func (s *EncryptedMessageStore) PurgeUser(ctx context.Context, waID string) error {
+	return purgeSealed(ctx, s.Store, s.Keys, waID)
+}
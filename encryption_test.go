@@ -0,0 +1,157 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEncryptedScheduleStoreDueSkipsUndecryptable(t *testing.T) {
+	ctx := context.Background()
+	keys := &Keyring{Current: "old", Keys: map[string][]byte{
+		"old": bytes.Repeat([]byte{1}, 32),
+		"new": bytes.Repeat([]byte{2}, 32),
+	}}
+	var failed []string
+	store := NewEncryptedScheduleStore(NewMemoryScheduleStore(), keys)
+	store.OnError = func(message *ScheduledMessage, err error) {
+		if !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("OnError(%s) = %v, want %v", message.ID, err, ErrUnknownKey)
+		}
+		failed = append(failed, message.ID)
+	}
+
+	sendAt := time.Now().Add(-time.Minute)
+	if err := store.Put(ctx, &ScheduledMessage{ID: "lost", SendAt: sendAt, Request: testTextRequest("lost")}); err != nil {
+		t.Fatal(err)
+	}
+	keys.Current = "new"
+	delete(keys.Keys, "old")
+	if err := store.Put(ctx, &ScheduledMessage{ID: "kept", SendAt: sendAt.Add(time.Second), Request: testTextRequest("kept")}); err != nil {
+		t.Fatal(err)
+	}
+
+	due, err := store.Due(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("Due: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != "kept" || due[0].Request.Text.Body != "kept" {
+		t.Errorf("Due returned %+v, want the decryptable message only", due)
+	}
+	if len(failed) != 1 || failed[0] != "lost" {
+		t.Errorf("OnError called for %v, want [lost]", failed)
+	}
+}
+
+func TestEncryptedScheduleStoreDueReadsPastUndecryptable(t *testing.T) {
+	ctx := context.Background()
+	keys := &Keyring{Current: "old", Keys: map[string][]byte{
+		"old": bytes.Repeat([]byte{1}, 32),
+		"new": bytes.Repeat([]byte{2}, 32),
+	}}
+	reported := 0
+	store := NewEncryptedScheduleStore(NewMemoryScheduleStore(), keys)
+	store.OnError = func(message *ScheduledMessage, err error) { reported++ }
+
+	sendAt := time.Now().Add(-time.Hour)
+	for i := range 5 {
+		id := fmt.Sprint("lost-", i)
+		if err := store.Put(ctx, &ScheduledMessage{ID: id, SendAt: sendAt.Add(time.Duration(i) * time.Second), Request: testTextRequest(id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys.Current = "new"
+	delete(keys.Keys, "old")
+	for i := range 3 {
+		id := fmt.Sprint("kept-", i)
+		if err := store.Put(ctx, &ScheduledMessage{ID: id, SendAt: sendAt.Add(time.Minute + time.Duration(i)*time.Second), Request: testTextRequest(id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range 2 {
+		due, err := store.Due(ctx, time.Now(), 2)
+		if err != nil {
+			t.Fatalf("Due: %v", err)
+		}
+		if len(due) != 2 || due[0].ID != "kept-0" || due[1].ID != "kept-1" {
+			t.Errorf("Due returned %d messages %+v, want a full batch of the decryptable ones", len(due), due)
+		}
+	}
+	if reported != 5 {
+		t.Errorf("OnError called %d times, want once per undecryptable message", reported)
+	}
+
+	due, err := store.Due(ctx, time.Now(), 5)
+	if err != nil {
+		t.Fatalf("Due: %v", err)
+	}
+	if len(due) != 3 {
+		t.Errorf("Due returned %d messages, want the 3 decryptable ones", len(due))
+	}
+}
+
+func TestEncryptedStoresPurgeUser(t *testing.T) {
+	ctx := context.Background()
+	keys := &Keyring{Current: "k", Keys: map[string][]byte{"k": bytes.Repeat([]byte{1}, 32)}}
+	request := func(to string) *Request {
+		request := testTextRequest("hello")
+		request.To = to
+		return request
+	}
+
+	t.Run("messages", func(t *testing.T) {
+		inner := NewMemoryMessageStore()
+		inner.Save(ctx, &StoredMessage{ID: "wamid.plain", Request: request("15551234567")})
+		store := NewEncryptedMessageStore(inner, keys)
+		store.Save(ctx, &StoredMessage{ID: "wamid.1", Request: request("15551234567")})
+		store.Save(ctx, &StoredMessage{ID: "wamid.2", Request: request("15557654321")})
+
+		if err := store.PurgeUser(ctx, "+1 555 123 4567"); err != nil {
+			t.Fatal(err)
+		}
+		for id, kept := range map[string]bool{"wamid.plain": false, "wamid.1": false, "wamid.2": true} {
+			if _, err := store.Get(ctx, id); (err == nil) != kept {
+				t.Errorf("Get(%s) after PurgeUser = %v, want kept %v", id, err, kept)
+			}
+		}
+	})
+
+	t.Run("schedule", func(t *testing.T) {
+		store := NewEncryptedScheduleStore(NewMemoryScheduleStore(), keys)
+		sendAt := time.Now().Add(-time.Minute)
+		store.Put(ctx, &ScheduledMessage{ID: "1", SendAt: sendAt, Request: request("15551234567")})
+		store.Put(ctx, &ScheduledMessage{ID: "2", SendAt: sendAt, Request: request("15557654321")})
+
+		if err := store.PurgeUser(ctx, "15551234567"); err != nil {
+			t.Fatal(err)
+		}
+		due, err := store.Due(ctx, time.Now(), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(due) != 1 || due[0].ID != "2" {
+			t.Errorf("Due after PurgeUser = %+v, want the message to the other user", due)
+		}
+	})
+
+	t.Run("undecryptable", func(t *testing.T) {
+		keys := &Keyring{Current: "old", Keys: map[string][]byte{"old": bytes.Repeat([]byte{1}, 32)}}
+		store := NewEncryptedMessageStore(NewMemoryMessageStore(), keys)
+		store.Save(ctx, &StoredMessage{ID: "wamid.1", Request: request("15551234567")})
+		delete(keys.Keys, "old")
+		if err := store.PurgeUser(ctx, "15551234567"); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("PurgeUser = %v, want %v", err, ErrUnknownKey)
+		}
+	})
+
+	t.Run("unsupported store", func(t *testing.T) {
+		store := NewEncryptedMessageStore(struct{ MessageStore }{NewMemoryMessageStore()}, keys)
+		if err := store.PurgeUser(ctx, "15551234567"); err == nil {
+			t.Error("PurgeUser of a store without SealedPurger succeeded, want an error")
+		}
+	})
+}
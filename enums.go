@@ -0,0 +1,349 @@
+package whatsapp
+
+import (
+	"context"
+)
+
+// The enum-like string types of the package are open-ended: Meta adds new
+// values, such as message types or pricing categories, without a new API
+// version, and they are decoded as is. IsKnown reports whether a value is one
+// of the constants of this version of the package, so switch statements can
+// tell new values from handled ones, and OnUnknownEnum of the Webhook reports
+// them as they arrive.
+
+// UnknownEnumHandler is called for an enum-like field of a notification whose
+// value isn't known, with the path of the field, e.g. "messages.type" or
+// "statuses.pricing.category", and the value. See Webhook.OnUnknownEnum.
+//
+// Example usage:
+//
+//	webhook.OnUnknownEnum = func(ctx context.Context, field, value string) {
+//	    log.Printf("webhook: unknown %s %q, consider upgrading whatsapp-go", field, value)
+//	}
+type UnknownEnumHandler func(ctx context.Context, field, value string)
+
+// enumValue is an enum-like value that can tell whether it's known.
+type enumValue interface {
+	~string
+	IsKnown() bool
+}
+
+// reportUnknown calls handler if value is set and unknown.
+func reportUnknown[T enumValue](ctx context.Context, handler UnknownEnumHandler, field string, value T) {
+	if value != "" && !value.IsKnown() {
+		handler(ctx, field, string(value))
+	}
+}
+
+// reportUnknownEnums calls handler for every unknown enum value of the request.
+func (r *WebhookRequest) reportUnknownEnums(ctx context.Context, handler UnknownEnumHandler) {
+	for _, entry := range r.Entry {
+		for _, change := range entry.Changes {
+			value := &change.Value
+			for i := range value.Messages {
+				value.Messages[i].reportUnknownEnums(ctx, handler)
+			}
+			for _, status := range value.Statuses {
+				reportUnknown(ctx, handler, "statuses.status", status.Status)
+				if status.Conversation != nil && status.Conversation.Origin != nil {
+					reportUnknown(ctx, handler, "statuses.conversation.origin.type", status.Conversation.Origin.Type)
+				}
+				if pricing := status.Pricing; pricing != nil {
+					reportUnknown(ctx, handler, "statuses.pricing.pricing_model", pricing.PricingModel)
+					reportUnknown(ctx, handler, "statuses.pricing.type", pricing.Type)
+					reportUnknown(ctx, handler, "statuses.pricing.category", pricing.Category)
+				}
+			}
+			for _, call := range value.Calls {
+				reportUnknown(ctx, handler, "calls.event", call.Event)
+			}
+			reportUnknown(ctx, handler, "decision", value.Decision)
+			if change.Field == WebhookFieldPhoneNumberQualityUpdate {
+				reportUnknown(ctx, handler, "event", value.QualityEvent())
+				reportUnknown(ctx, handler, "current_limit", value.CurrentLimit)
+				reportUnknown(ctx, handler, "old_limit", value.OldLimit)
+			} else {
+				reportUnknown(ctx, handler, "event", value.Event)
+			}
+			if value.BanInfo != nil {
+				for _, state := range value.BanInfo.WABABanState {
+					reportUnknown(ctx, handler, "ban_info.waba_ban_state", state)
+				}
+			}
+			for _, restriction := range value.RestrictionInfo {
+				reportUnknown(ctx, handler, "restriction_info.restriction_type", restriction.RestrictionType)
+			}
+			reportUnknown(ctx, handler, "alert_severity", value.AlertSeverity)
+		}
+	}
+}
+
+// reportUnknownEnums calls handler for every unknown enum value of the message.
+func (m *WebhookMessage) reportUnknownEnums(ctx context.Context, handler UnknownEnumHandler) {
+	reportUnknown(ctx, handler, "messages.type", m.Type)
+	if m.Interactive != nil {
+		reportUnknown(ctx, handler, "messages.interactive.type", m.Interactive.Type)
+	}
+	if m.System != nil {
+		reportUnknown(ctx, handler, "messages.system.type", m.System.Type)
+	}
+	if m.Referral != nil {
+		reportUnknown(ctx, handler, "messages.referral.source_type", m.Referral.SourceType)
+		reportUnknown(ctx, handler, "messages.referral.media_type", m.Referral.MediaType)
+	}
+	for _, contact := range m.Contacts {
+		for _, address := range contact.Addresses {
+			reportUnknown(ctx, handler, "messages.contacts.addresses.type", address.Type)
+		}
+		for _, email := range contact.Emails {
+			reportUnknown(ctx, handler, "messages.contacts.emails.type", email.Type)
+		}
+		for _, phone := range contact.Phones {
+			reportUnknown(ctx, handler, "messages.contacts.phones.type", phone.Type)
+		}
+		for _, url := range contact.URLs {
+			reportUnknown(ctx, handler, "messages.contacts.urls.type", url.Type)
+		}
+	}
+	if m.Ephemeral != nil {
+		m.Ephemeral.reportUnknownEnums(ctx, handler)
+	}
+}
+
+// IsKnown reports whether t is one of the MessageType constants.
+func (t MessageType) IsKnown() bool {
+	switch t {
+	case MessageTypeText, MessageTypeImage, MessageTypeAudio,
+		MessageTypeVideo, MessageTypeDocument, MessageTypeSticker,
+		MessageTypeLocation, MessageTypeContacts, MessageTypeButton,
+		MessageTypeInteractive, MessageTypeOrder, MessageTypeSystem,
+		MessageTypeReaction, MessageTypeTemplate, MessageTypeEphemeral,
+		MessageTypeUnknown, MessageTypeUnsupported:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the InteractiveType constants.
+func (t InteractiveType) IsKnown() bool {
+	switch t {
+	case InteractiveTypeCallPermissionRequest, InteractiveTypeFlow, InteractiveTypeButton,
+		InteractiveTypeList, InteractiveTypeCTAURL, InteractiveTypeButtonReply,
+		InteractiveTypeListReply:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the MessageStatus constants.
+func (t MessageStatus) IsKnown() bool {
+	switch t {
+	case MessageStatusSent, MessageStatusDelivered, MessageStatusRead,
+		MessageStatusFailed:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the SystemMessageType constants.
+func (t SystemMessageType) IsKnown() bool {
+	switch t {
+	case SystemMessageTypeUserChangedNumber:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the ReferralSourceType constants.
+func (t ReferralSourceType) IsKnown() bool {
+	switch t {
+	case ReferralSourceTypeAd, ReferralSourceTypePost:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the ReferralMediaType constants.
+func (t ReferralMediaType) IsKnown() bool {
+	switch t {
+	case ReferralMediaTypeImage, ReferralMediaTypeVideo:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the ConversationOriginType constants.
+func (t ConversationOriginType) IsKnown() bool {
+	switch t {
+	case ConversationOriginTypeReferralConversion, ConversationOriginTypeUserInitiated, ConversationOriginTypeBusinessInitiated:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the PricingModel constants.
+func (t PricingModel) IsKnown() bool {
+	switch t {
+	case PricingModelCBP, PricingModelPMP:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the PricingType constants.
+func (t PricingType) IsKnown() bool {
+	switch t {
+	case PricingTypeRegular, PricingTypeFreeCustomerService, PricingTypeFreeEntryPoint:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the PricingCategory constants.
+func (t PricingCategory) IsKnown() bool {
+	switch t {
+	case PricingCategoryReferralConversion, PricingCategoryUserInitiated, PricingCategoryBusinessInitiated,
+		PricingCategoryMarketing, PricingCategoryUtility, PricingCategoryAuthentication,
+		PricingCategoryAuthenticationInternational, PricingCategoryService:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the ContactAddressType constants.
+func (t ContactAddressType) IsKnown() bool {
+	switch t {
+	case ContactAddressTypeHome, ContactAddressTypeWork:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the ContactEmailType constants.
+func (t ContactEmailType) IsKnown() bool {
+	switch t {
+	case ContactEmailTypeHome, ContactEmailTypeWork:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the ContactPhoneType constants.
+func (t ContactPhoneType) IsKnown() bool {
+	switch t {
+	case ContactPhoneTypeHome, ContactPhoneTypeWork:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the ContactURLType constants.
+func (t ContactURLType) IsKnown() bool {
+	switch t {
+	case ContactURLTypeHome, ContactURLTypeWork:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the CallEvent constants.
+func (t CallEvent) IsKnown() bool {
+	switch t {
+	case CallEventConnect, CallEventTerminate:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the FlowStatus constants.
+func (t FlowStatus) IsKnown() bool {
+	switch t {
+	case FlowStatusDraft, FlowStatusPublished, FlowStatusDeprecated,
+		FlowStatusBlocked, FlowStatusThrottled:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the FlowCategory constants.
+func (t FlowCategory) IsKnown() bool {
+	switch t {
+	case FlowCategorySignUp, FlowCategorySignIn, FlowCategoryAppointmentBooking,
+		FlowCategoryLeadGeneration, FlowCategoryContactUs, FlowCategoryCustomerSupport,
+		FlowCategorySurvey, FlowCategoryOther:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the AccountReviewDecision constants.
+func (t AccountReviewDecision) IsKnown() bool {
+	switch t {
+	case AccountReviewDecisionApproved, AccountReviewDecisionRejected, AccountReviewDecisionPending,
+		AccountReviewDecisionDeferred:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the AccountUpdateEvent constants.
+func (t AccountUpdateEvent) IsKnown() bool {
+	switch t {
+	case AccountUpdateEventVerifiedAccount, AccountUpdateEventDisabledUpdate, AccountUpdateEventAccountViolation,
+		AccountUpdateEventAccountRestriction, AccountUpdateEventAccountDeleted, AccountUpdateEventPartnerAdded,
+		AccountUpdateEventPartnerRemoved:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the BanState constants.
+func (t BanState) IsKnown() bool {
+	switch t {
+	case BanStateScheduleForDisable, BanStateDisable, BanStateReinstate:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the RestrictionType constants.
+func (t RestrictionType) IsKnown() bool {
+	switch t {
+	case RestrictionTypeAddPhoneNumber, RestrictionTypeBusinessInitiatedMessaging, RestrictionTypeCustomerInitiatedMessaging:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the AlertSeverity constants.
+func (t AlertSeverity) IsKnown() bool {
+	switch t {
+	case AlertSeverityCritical, AlertSeverityWarning, AlertSeverityInformational:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the QualityUpdateEvent constants.
+func (t QualityUpdateEvent) IsKnown() bool {
+	switch t {
+	case QualityUpdateEventFlagged, QualityUpdateEventUnflagged, QualityUpdateEventUpgrade,
+		QualityUpdateEventDowngrade, QualityUpdateEventOnboarding:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the QualityRating constants.
+func (t QualityRating) IsKnown() bool {
+	switch t {
+	case QualityRatingGreen, QualityRatingYellow, QualityRatingRed, QualityRatingUnknown:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether t is one of the MessagingLimit constants.
+func (t MessagingLimit) IsKnown() bool {
+	return t.Conversations() != 0
+}
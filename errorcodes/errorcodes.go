@@ -0,0 +1,230 @@
+// Package errorcodes catalogs the documented WhatsApp Cloud API error codes and
+// classifies them, so error handling logic reads as intent instead of magic numbers.
+//
+// Example usage:
+//
+//	_, err := client.SendText(ctx, recipient, params)
+//	switch code, _ := errorcodes.FromError(err); {
+//	case code == errorcodes.ReEngagementMessage:
+//	    // The 24 hour window is closed, send a template instead
+//	case errorcodes.IsRetryable(err):
+//	    // Back off and try again later
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+package errorcodes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yarcat/whatsapp-go"
+)
+
+// Category classifies error codes by how they should be handled.
+type Category int
+
+const (
+	// CategoryUnknown is the category of undocumented error codes.
+	CategoryUnknown Category = iota
+	// CategoryRetryable errors are temporary; the request may succeed if retried later.
+	CategoryRetryable
+	// CategoryPermanent errors will fail again unless the request is changed.
+	CategoryPermanent
+	// CategoryAuth errors are caused by the access token or app permissions.
+	CategoryAuth
+)
+
+// String returns the name of the category.
+func (c Category) String() string {
+	switch c {
+	case CategoryRetryable:
+		return "retryable"
+	case CategoryPermanent:
+		return "permanent"
+	case CategoryAuth:
+		return "auth"
+	default:
+		return "unknown"
+	}
+}
+
+// Code is a Graph API error code.
+type Code int
+
+// Documented Cloud API error codes.
+//
+// The documented AuthException code 0 is deliberately left out: it can't be told
+// apart from an error without a code, so authentication failures are classified
+// by their OAuth codes instead.
+const (
+	APIUnknown                 Code = 1
+	APIService                 Code = 2
+	APIMethod                  Code = 3
+	APITooManyCalls            Code = 4
+	PermissionDenied           Code = 10
+	APIPermission              Code = 200
+	InvalidParameter           Code = 100
+	SessionInvalid             Code = 102
+	AccessTokenExpired         Code = 190
+	PhoneNumberDeleted         Code = 33
+	TemporarilyBlocked         Code = 368
+	AccountRateLimit           Code = 80007
+	CloudAPIThroughput         Code = 130429
+	UserInExperiment           Code = 130472
+	CountryRestricted          Code = 130497
+	SomethingWentWrong         Code = 131000
+	AccessDenied               Code = 131005
+	RequiredParameterMissing   Code = 131008
+	ParameterValueInvalid      Code = 131009
+	ServiceUnavailable         Code = 131016
+	RecipientIsSender          Code = 131021
+	MessageUndeliverable       Code = 131026
+	AccountLocked              Code = 131031
+	DisplayNameApprovalNeeded  Code = 131037
+	BusinessPaymentIssue       Code = 131042
+	IncorrectCertificate       Code = 131045
+	ReEngagementMessage        Code = 131047
+	SpamRateLimit              Code = 131048
+	EcosystemEngagement        Code = 131049
+	MarketingMessagesStopped   Code = 131050
+	UnsupportedMessageType     Code = 131051
+	MediaDownloadError         Code = 131052
+	MediaUploadError           Code = 131053
+	PairRateLimit              Code = 131056
+	AccountInMaintenanceMode   Code = 131057
+	TemplateParamCountMismatch Code = 132000
+	TemplateDoesNotExist       Code = 132001
+	TemplateTextTooLong        Code = 132005
+	TemplatePolicyViolated     Code = 132007
+	TemplateParamFormat        Code = 132012
+	TemplatePaused             Code = 132015
+	TemplateDisabled           Code = 132016
+	FlowBlocked                Code = 132068
+	FlowThrottled              Code = 132069
+	IncompleteDeregistration   Code = 133000
+	PhoneNumberNotRegistered   Code = 133010
+	GenericUserError           Code = 135000
+)
+
+// Info describes a documented error code.
+type Info struct {
+	Code        Code
+	Name        string
+	Description string
+	Category    Category
+}
+
+var catalog = map[Code]Info{
+	APIUnknown:                 {APIUnknown, "API Unknown", "Invalid request or possible server error", CategoryRetryable},
+	APIService:                 {APIService, "API Service", "Temporary downtime or overload", CategoryRetryable},
+	APIMethod:                  {APIMethod, "API Method", "Capability or permissions issue", CategoryAuth},
+	APITooManyCalls:            {APITooManyCalls, "API Too Many Calls", "The app has reached its API call rate limit", CategoryRetryable},
+	PermissionDenied:           {PermissionDenied, "Permission Denied", "Permission is either not granted or has been removed", CategoryAuth},
+	APIPermission:              {APIPermission, "API Permission", "Permission is either not granted or has been removed", CategoryAuth},
+	InvalidParameter:           {InvalidParameter, "Invalid parameter", "The request included invalid or unsupported parameters", CategoryPermanent},
+	SessionInvalid:             {SessionInvalid, "Session key invalid", "The session key is invalid or no longer valid", CategoryAuth},
+	AccessTokenExpired:         {AccessTokenExpired, "Access token has expired", "The access token has expired", CategoryAuth},
+	PhoneNumberDeleted:         {PhoneNumberDeleted, "Parameter value is not valid", "The business phone number has been deleted", CategoryPermanent},
+	TemporarilyBlocked:         {TemporarilyBlocked, "Temporarily blocked for policies violations", "The account has been restricted or disabled for violating a platform policy", CategoryPermanent},
+	AccountRateLimit:           {AccountRateLimit, "Rate limit issues", "The WhatsApp Business Account has reached its rate limit", CategoryRetryable},
+	CloudAPIThroughput:         {CloudAPIThroughput, "Rate limit hit", "Cloud API message throughput has been reached", CategoryRetryable},
+	UserInExperiment:           {UserInExperiment, "User's number is part of an experiment", "Marketing message was not sent due to an experiment", CategoryPermanent},
+	CountryRestricted:          {CountryRestricted, "Business account is restricted", "The business can't message users in this country", CategoryPermanent},
+	SomethingWentWrong:         {SomethingWentWrong, "Something went wrong", "Message failed to send due to an unknown error", CategoryRetryable},
+	AccessDenied:               {AccessDenied, "Access denied", "Permission is either not granted or has been removed", CategoryAuth},
+	RequiredParameterMissing:   {RequiredParameterMissing, "Required parameter is missing", "The request is missing a required parameter", CategoryPermanent},
+	ParameterValueInvalid:      {ParameterValueInvalid, "Parameter value is not valid", "One or more parameter values are invalid", CategoryPermanent},
+	ServiceUnavailable:         {ServiceUnavailable, "Service unavailable", "A service is temporarily unavailable", CategoryRetryable},
+	RecipientIsSender:          {RecipientIsSender, "Recipient cannot be sender", "The sender and recipient phone numbers are the same", CategoryPermanent},
+	MessageUndeliverable:       {MessageUndeliverable, "Message undeliverable", "Unable to deliver the message", CategoryPermanent},
+	AccountLocked:              {AccountLocked, "Account has been locked", "The account was locked for a policy violation or invalid data", CategoryPermanent},
+	DisplayNameApprovalNeeded:  {DisplayNameApprovalNeeded, "Display name approval needed", "The phone number's display name must be approved first", CategoryPermanent},
+	BusinessPaymentIssue:       {BusinessPaymentIssue, "Business eligibility payment issue", "There was an error related to the payment method", CategoryPermanent},
+	IncorrectCertificate:       {IncorrectCertificate, "Incorrect certificate", "Message failed to send due to a phone number registration error", CategoryPermanent},
+	ReEngagementMessage:        {ReEngagementMessage, "Re-engagement message", "More than 24 hours have passed since the recipient last replied", CategoryPermanent},
+	SpamRateLimit:              {SpamRateLimit, "Spam rate limit hit", "Message failed to send because of restrictions on how many messages can be sent", CategoryRetryable},
+	EcosystemEngagement:        {EcosystemEngagement, "Meta chose not to deliver", "Message was not delivered to maintain healthy ecosystem engagement", CategoryRetryable},
+	MarketingMessagesStopped:   {MarketingMessagesStopped, "User stopped marketing messages", "The user has stopped receiving marketing messages", CategoryPermanent},
+	UnsupportedMessageType:     {UnsupportedMessageType, "Unsupported message type", "The message type is not supported", CategoryPermanent},
+	MediaDownloadError:         {MediaDownloadError, "Media download error", "Unable to download the media sent by the user", CategoryPermanent},
+	MediaUploadError:           {MediaUploadError, "Media upload error", "Unable to upload the media used in the message", CategoryPermanent},
+	PairRateLimit:              {PairRateLimit, "Pair rate limit hit", "Too many messages sent to the same phone number in a short period", CategoryRetryable},
+	AccountInMaintenanceMode:   {AccountInMaintenanceMode, "Account in maintenance mode", "The business account is in maintenance mode", CategoryRetryable},
+	TemplateParamCountMismatch: {TemplateParamCountMismatch, "Template param count mismatch", "The number of parameters doesn't match the template definition", CategoryPermanent},
+	TemplateDoesNotExist:       {TemplateDoesNotExist, "Template does not exist", "The template doesn't exist in the specified language or hasn't been approved", CategoryPermanent},
+	TemplateTextTooLong:        {TemplateTextTooLong, "Template hydrated text too long", "The translated text is too long", CategoryPermanent},
+	TemplatePolicyViolated:     {TemplatePolicyViolated, "Template format character policy violated", "Template content violates a WhatsApp policy", CategoryPermanent},
+	TemplateParamFormat:        {TemplateParamFormat, "Template parameter format mismatch", "A variable parameter value is formatted incorrectly", CategoryPermanent},
+	TemplatePaused:             {TemplatePaused, "Template is paused", "The template is paused due to low quality", CategoryPermanent},
+	TemplateDisabled:           {TemplateDisabled, "Template is disabled", "The template has been paused too many times and is permanently disabled", CategoryPermanent},
+	FlowBlocked:                {FlowBlocked, "Flow is blocked", "The flow is in a blocked state", CategoryPermanent},
+	FlowThrottled:              {FlowThrottled, "Flow is throttled", "Too many messages were sent using this flow in the last hour", CategoryRetryable},
+	IncompleteDeregistration:   {IncompleteDeregistration, "Incomplete deregistration", "A previous deregistration attempt failed", CategoryPermanent},
+	PhoneNumberNotRegistered:   {PhoneNumberNotRegistered, "Phone number not registered", "The phone number is not registered on the WhatsApp Business Platform", CategoryPermanent},
+	GenericUserError:           {GenericUserError, "Generic user error", "Unknown error with the request parameters", CategoryPermanent},
+}
+
+// Lookup returns the catalog entry for a raw error code.
+func Lookup(code int) (Info, bool) {
+	info, ok := catalog[Code(code)]
+	if !ok && code > int(APIPermission) && code < 300 {
+		// Codes 200-299 are all permission errors
+		info, ok = catalog[APIPermission], true
+		info.Code = Code(code)
+	}
+	return info, ok
+}
+
+// String returns the documented name of the code.
+func (c Code) String() string {
+	if info, ok := Lookup(int(c)); ok {
+		return info.Name
+	}
+	return fmt.Sprintf("error code %d", int(c))
+}
+
+// Category returns the category of the code.
+func (c Code) Category() Category {
+	info, _ := Lookup(int(c))
+	return info.Category
+}
+
+// FromError returns the error code of a *whatsapp.GraphError wrapped in err.
+func FromError(err error) (Code, bool) {
+	var graphErr *whatsapp.GraphError
+	if !errors.As(err, &graphErr) {
+		return 0, false
+	}
+	return Code(graphErr.Code), true
+}
+
+// FromAPIError returns the error code of a decoded API error response.
+func FromAPIError(apiError *whatsapp.APIError) Code {
+	return Code(apiError.Error.Code)
+}
+
+// CategoryOf returns the category of the API error wrapped in err,
+// or CategoryUnknown if err isn't an API error.
+func CategoryOf(err error) Category {
+	code, ok := FromError(err)
+	if !ok {
+		return CategoryUnknown
+	}
+	return code.Category()
+}
+
+// IsRetryable reports whether err is an API error that may succeed if retried later.
+func IsRetryable(err error) bool {
+	return CategoryOf(err) == CategoryRetryable
+}
+
+// IsPermanent reports whether err is an API error that will fail again unless the request is changed.
+func IsPermanent(err error) bool {
+	return CategoryOf(err) == CategoryPermanent
+}
+
+// IsAuth reports whether err is an API error caused by the access token or app permissions.
+func IsAuth(err error) bool {
+	return CategoryOf(err) == CategoryAuth
+}
@@ -0,0 +1,138 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// GraphError is returned when the WhatsApp Business API responds with an error object.
+// Use errors.As to inspect it, or the errorcodes package to classify it.
+//
+// Example usage:
+//
+//	_, err := client.SendText(ctx, recipient, params)
+//	var graphErr *GraphError
+//	if errors.As(err, &graphErr) && graphErr.Code == 131047 {
+//	    // The 24 hour window is closed, send a template instead
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+type GraphError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Message is the human-readable error message.
+	Message string
+	// Type is the error type, e.g. "OAuthException".
+	Type string
+	// Code is the Graph API error code.
+	Code int
+	// Subcode is the Graph API error subcode, if any.
+	Subcode int
+	// Details contains additional details from error_data, if any.
+	Details string
+	// FBTraceID is the trace ID to reference when contacting Meta support.
+	FBTraceID string
+	// RequestID is the ID of the failed request, as set with
+	// ContextWithRequestID or generated for WithRequestIDHeader, to correlate
+	// FBTraceID with the logs of the application.
+	RequestID string
+}
+
+// Error implements the error interface.
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("WhatsApp API error: %s (code: %d)", e.Message, e.Code)
+}
+
+// NewGraphError converts a decoded API error response into a GraphError.
+func NewGraphError(statusCode int, apiError *APIError) *GraphError {
+	return &GraphError{
+		StatusCode: statusCode,
+		Message:    apiError.Error.Message,
+		Type:       apiError.Error.Type,
+		Code:       apiError.Error.Code,
+		Subcode:    apiError.Error.ErrorSubcode,
+		Details:    apiError.Error.ErrorData.Details,
+		FBTraceID:  apiError.Error.FBTraceID,
+	}
+}
+
+// maxErrorBodyBytes limits how much of an error response body is read.
+const maxErrorBodyBytes = 64 << 10
+
+// decodeAPIError reads the error response of a failed request and returns it as a
+// *GraphError. If the body doesn't contain a Graph API error, fallback is returned.
+func decodeAPIError(resp *http.Response, fallback error) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if err != nil {
+		return fallback
+	}
+	err = parseAPIError(resp.StatusCode, body, fallback)
+	if graphErr, ok := err.(*GraphError); ok && resp.Request != nil {
+		graphErr.RequestID, _ = RequestIDFromContext(resp.Request.Context())
+	}
+	return err
+}
+
+// parseAPIError parses body as a Graph API error response.
+// If it doesn't contain an error, fallback is returned.
+func parseAPIError(statusCode int, body []byte, fallback error) error {
+	var apiError APIError
+	if err := json.Unmarshal(body, &apiError); err != nil || (apiError.Error.Code == 0 && apiError.Error.Message == "") {
+		return fallback
+	}
+	return NewGraphError(statusCode, &apiError)
+}
+
+// Err converts the webhook error into a *GraphError, the same error type returned
+// when a request fails, so delivery failures can be handled like send failures.
+func (we *WebhookError) Err() *GraphError {
+	message := we.Message
+	if message == "" {
+		message = we.Title
+	}
+	details := we.Details
+	if we.ErrorData != nil && we.ErrorData.Details != "" {
+		details = we.ErrorData.Details
+	}
+	return &GraphError{
+		Message: message,
+		Code:    we.Code,
+		Details: details,
+	}
+}
+
+// FailedReason returns the reason a message failed to be delivered, or nil if the
+// status is not MessageStatusFailed. The reason wraps a *GraphError for every error
+// reported in the status, so it can be inspected with errors.As or classified with
+// the errorcodes package.
+//
+// Example usage:
+//
+//	for _, status := range value.Statuses {
+//	    if err := FailedReason(&status); err != nil {
+//	        if errorcodes.IsRetryable(err) {
+//	            // Schedule a resend
+//	        }
+//	    }
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/payload-examples
+func FailedReason(status *WebhookStatus) error {
+	if status == nil || status.Status != MessageStatusFailed {
+		return nil
+	}
+	switch len(status.Errors) {
+	case 0:
+		return fmt.Errorf("message %s failed without error details", status.ID)
+	case 1:
+		return status.Errors[0].Err()
+	}
+	errs := make([]error, len(status.Errors))
+	for i := range status.Errors {
+		errs[i] = status.Errors[i].Err()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// formattingMarkers are the characters WhatsApp uses for inline formatting.
+const formattingMarkers = "*_~`"
+
+// zeroWidthSpace is inserted after formatting markers to keep them from formatting.
+const zeroWidthSpace = "\u200b"
+
+// EscapeFormatting returns text with its formatting markers (*, _, ~ and `)
+// neutralized, so that user data displays as typed instead of turning bold,
+// italic, strikethrough or monospace. WhatsApp has no escape character, so an
+// invisible zero-width space is inserted after every marker.
+func EscapeFormatting(text string) string {
+	if !strings.ContainsAny(text, formattingMarkers) {
+		return text
+	}
+	var out strings.Builder
+	out.Grow(len(text) + 4*len(zeroWidthSpace))
+	for _, r := range text {
+		out.WriteRune(r)
+		if strings.ContainsRune(formattingMarkers, r) {
+			out.WriteString(zeroWidthSpace)
+		}
+	}
+	return out.String()
+}
+
+// Sprintf formats like fmt.Sprintf, escaping the formatting markers of the
+// interpolated arguments with EscapeFormatting. The format itself keeps its
+// formatting, so messages can combine styled templates with untrusted data.
+// Arguments only formatted with %T or %p are passed to fmt unchanged.
+//
+// Example usage:
+//
+//	body := Sprintf("Hello *%s*, your order _%s_ has shipped", customer.Name, order.ID)
+//	// A name like "*VIP* Bob" is displayed as typed, inside the bold greeting
+func Sprintf(format string, args ...any) string {
+	unescaped := typeVerbArgs(format, len(args))
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		if unescaped[i] {
+			// fmt formats %T and %p before calling Format, so they need the value itself
+			escaped[i] = arg
+			continue
+		}
+		switch arg.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+			float32, float64, complex64, complex128, bool:
+			// Numbers can't contain markers, and may be widths or precisions of other verbs
+			escaped[i] = arg
+		default:
+			escaped[i] = escapedArg{arg}
+		}
+	}
+	return fmt.Sprintf(format, escaped...)
+}
+
+// escapedArg is an argument of Sprintf, escaped once formatted.
+type escapedArg struct {
+	value any
+}
+
+// Format implements fmt.Formatter.
+func (a escapedArg) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, EscapeFormatting(fmt.Sprintf(fmt.FormatString(f, verb), a.value)))
+}
+
+// typeVerbArgs reports which of the n arguments of format are only formatted
+// with %T or %p, following the argument numbering of fmt.
+func typeVerbArgs(format string, n int) []bool {
+	typeVerb := make([]bool, n)
+	otherVerb := make([]bool, n)
+	use := func(arg int, verb rune) {
+		if arg < 0 || arg >= n {
+			return
+		}
+		if verb == 'T' || verb == 'p' {
+			typeVerb[arg] = true
+		} else {
+			otherVerb[arg] = true
+		}
+	}
+	// argIndex applies an explicit argument index like [2] starting at i
+	argIndex := func(i, arg int) (int, int) {
+		if i >= len(format) || format[i] != '[' {
+			return i, arg
+		}
+		end := strings.IndexByte(format[i:], ']')
+		if end < 0 {
+			return i, arg
+		}
+		if index, err := strconv.Atoi(format[i+1 : i+end]); err == nil && index > 0 {
+			arg = index - 1
+		}
+		return i + end + 1, arg
+	}
+	// number skips a width or precision, which consumes an argument if it is *
+	number := func(i, arg int) (int, int) {
+		if i < len(format) && format[i] == '*' {
+			use(arg, '*')
+			return i + 1, arg + 1
+		}
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			i++
+		}
+		return i, arg
+	}
+
+	arg := 0
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		i++
+		for i < len(format) && strings.IndexByte("#0+- ", format[i]) >= 0 {
+			i++
+		}
+		i, arg = argIndex(i, arg)
+		i, arg = number(i, arg)
+		if i < len(format) && format[i] == '.' {
+			i, arg = argIndex(i+1, arg)
+			i, arg = number(i, arg)
+		}
+		i, arg = argIndex(i, arg)
+		if i >= len(format) {
+			break
+		}
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		i += size
+		if verb == '%' {
+			continue
+		}
+		use(arg, verb)
+		arg++
+	}
+
+	for i := range typeVerb {
+		typeVerb[i] = typeVerb[i] && !otherVerb[i]
+	}
+	return typeVerb
+}
@@ -0,0 +1,29 @@
+package whatsapp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSprintf(t *testing.T) {
+	order := &struct{ ID string }{ID: "*42*"}
+	for _, tc := range []struct {
+		name   string
+		format string
+		args   []any
+		want   string
+	}{
+		{"escapes strings", "Hi *%s*", []any{"_Bob_"}, "Hi *_\u200bBob_\u200b*"},
+		{"keeps numbers", "%*d|%.2f", []any{4, 7, 1.5}, "   7|1.50"},
+		{"type", "%T", []any{order}, fmt.Sprintf("%T", order)},
+		{"pointer", "%p", []any{order}, fmt.Sprintf("%p", order)},
+		{"indexed type", "%[2]T %[1]s", []any{"_x_", 3}, "int _\u200bx_\u200b"},
+		{"percent", "100%% %T", []any{"_x_"}, "100% string"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Sprintf(tc.format, tc.args...); got != tc.want {
+				t.Errorf("Sprintf(%q, %v) = %q, want %q", tc.format, tc.args, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EventVersion is the version of the Event envelope schema produced by this package.
+const EventVersion = 1
+
+// EventType represents the type of the payload of an Event.
+type EventType string
+
+const (
+	// EventTypeMessage events carry an IncomingMessage.
+	EventTypeMessage EventType = "message"
+	// EventTypeStatus events carry a StatusUpdate.
+	EventTypeStatus EventType = "status"
+	// EventTypeCall events carry a WebhookCall.
+	EventTypeCall EventType = "call"
+)
+
+// Event is a versioned envelope for a single webhook event, meant to be
+// forwarded across services, e.g. through Kafka, and parsed again with ParseEvent.
+// Its JSON schema only changes together with EventVersion.
+//
+// Example usage:
+//
+//	// Producer
+//	events, err := EventsFromRequest(req)
+//	...
+//	for _, event := range events {
+//	    data, err := json.Marshal(event)
+//	    ...
+//	    producer.Send(event.PhoneNumberID, data)
+//	}
+//
+//	// Consumer
+//	event, err := ParseEvent(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if event.Type == EventTypeMessage {
+//	    message, err := event.Message()
+//	    ...
+//	}
+type Event struct {
+	// Version is the envelope schema version.
+	Version int `json:"version"`
+	// Type is the type of the payload.
+	Type EventType `json:"type"`
+	// ID is the ID of the message or call the event is about.
+	ID string `json:"id"`
+	// PhoneNumberID is the ID of the business phone number, identifying the tenant.
+	PhoneNumberID string `json:"phone_number_id"`
+	// Timestamp is the time of the event as reported by the webhook.
+	Timestamp time.Time `json:"timestamp"`
+	// Payload is the JSON encoded event.
+	Payload json.RawMessage `json:"payload"`
+}
+
+// EventsFromRequest splits a webhook notification into events, one per
+// message, status and call.
+func EventsFromRequest(request *WebhookRequest) ([]Event, error) {
+	var events []Event
+	for _, message := range request.Messages() {
+		event, err := newEvent(EventTypeMessage, message.ID, message.Metadata.PhoneNumberID, message.Timestamp, message)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	for _, status := range request.Statuses() {
+		event, err := newEvent(EventTypeStatus, status.ID, status.Metadata.PhoneNumberID, status.Timestamp, status)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	for _, entry := range request.Entry {
+		for _, change := range entry.Changes {
+			for _, call := range change.Value.Calls {
+				event, err := newEvent(EventTypeCall, call.ID, change.Value.Metadata.PhoneNumberID, call.Timestamp, call)
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event)
+			}
+		}
+	}
+	return events, nil
+}
+
+func newEvent(eventType EventType, id, phoneNumberID, timestamp string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("encoding %s event payload: %w", eventType, err)
+	}
+	eventTime, ok := parseUnixTime(timestamp)
+	if !ok {
+		eventTime = time.Now()
+	}
+	eventTime = eventTime.UTC()
+	return Event{
+		Version:       EventVersion,
+		Type:          eventType,
+		ID:            id,
+		PhoneNumberID: phoneNumberID,
+		Timestamp:     eventTime,
+		Payload:       data,
+	}, nil
+}
+
+// ParseEvent decodes an event envelope, rejecting unsupported schema versions.
+func ParseEvent(data []byte) (*Event, error) {
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("decoding event: %w", err)
+	}
+	if event.Version < 1 || event.Version > EventVersion {
+		return nil, fmt.Errorf("unsupported event version %d", event.Version)
+	}
+	return &event, nil
+}
+
+// Message decodes the payload of an EventTypeMessage event.
+func (e *Event) Message() (*IncomingMessage, error) {
+	var message IncomingMessage
+	if err := e.decode(EventTypeMessage, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// Status decodes the payload of an EventTypeStatus event.
+func (e *Event) Status() (*StatusUpdate, error) {
+	var status StatusUpdate
+	if err := e.decode(EventTypeStatus, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Call decodes the payload of an EventTypeCall event.
+func (e *Event) Call() (*WebhookCall, error) {
+	var call WebhookCall
+	if err := e.decode(EventTypeCall, &call); err != nil {
+		return nil, err
+	}
+	return &call, nil
+}
+
+func (e *Event) decode(eventType EventType, v any) error {
+	if e.Type != eventType {
+		return fmt.Errorf("event is a %s event, not a %s event", e.Type, eventType)
+	}
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("decoding %s event payload: %w", eventType, err)
+	}
+	return nil
+}
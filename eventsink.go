@@ -0,0 +1,104 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// EventSink receives every webhook notification before it is handled, e.g. to
+// write it to Kafka, SQS or a database outbox. If it fails, the notification is
+// not handled and Meta delivers it again later.
+type EventSink interface {
+	WriteEvent(ctx context.Context, request *WebhookRequest) error
+}
+
+// EventSinkFunc is a function type that implements the EventSink interface.
+type EventSinkFunc func(ctx context.Context, request *WebhookRequest) error
+
+// WriteEvent calls the function with the given parameters.
+func (f EventSinkFunc) WriteEvent(ctx context.Context, request *WebhookRequest) error {
+	return f(ctx, request)
+}
+
+// MultiEventSink returns an EventSink writing every notification to all sinks
+// in order. It stops at the first sink that fails.
+//
+// Example usage:
+//
+//	webhook.EventSink = MultiEventSink(sink, reporter, costs)
+func MultiEventSink(sinks ...EventSink) EventSink {
+	return EventSinkFunc(func(ctx context.Context, request *WebhookRequest) error {
+		for _, sink := range sinks {
+			if err := sink.WriteEvent(ctx, request); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// JSONLinesSink is an EventSink writing every notification as a line of JSON.
+// It is safe for concurrent use.
+//
+// Example usage:
+//
+//	sink, err := OpenJSONLinesFile("events.jsonl")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer sink.Close()
+//
+//	webhook := NewWebhook(verifyToken, appSecret, handler)
+//	webhook.EventSink = sink
+type JSONLinesSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesSink creates a sink writing to w.
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{w: w}
+}
+
+// OpenJSONLinesFile creates a sink appending to the file at path, creating it if needed.
+func OpenJSONLinesFile(path string) (*JSONLinesSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening event file: %w", err)
+	}
+	return NewJSONLinesSink(f), nil
+}
+
+// WriteEvent implements EventSink.
+func (s *JSONLinesSink) WriteEvent(ctx context.Context, request *WebhookRequest) error {
+	line, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(line); err != nil {
+		return fmt.Errorf("writing event: %w", err)
+	}
+	if f, ok := s.w.(*os.File); ok {
+		// The event must be durable before Meta is told it was received
+		return f.Sync()
+	}
+	return nil
+}
+
+// Close closes the underlying writer if it is an io.Closer.
+func (s *JSONLinesSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
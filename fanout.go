@@ -0,0 +1,104 @@
+package whatsapp
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultFanOutConcurrency is the default number of concurrent sends of the ToMany helpers.
+const DefaultFanOutConcurrency = 10
+
+// RateLimiter paces outgoing messages. Wait blocks until a message may be
+// sent, or returns an error if ctx is done first. *rate.Limiter of
+// golang.org/x/time/rate implements it. Limiters that also implement
+// ErrorObserver learn about the failed messages, see AdaptiveRateLimiter.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimiter makes the client wait for limiter before sending every message.
+func WithRateLimiter(limiter RateLimiter) ClientOption {
+	return func(wa *Client) {
+		wa.RateLimiter = limiter
+	}
+}
+
+// RecipientResult is the outcome of a message to a single recipient of a fan-out.
+type RecipientResult struct {
+	Response *MessagesResponse
+	Err      error
+}
+
+// SendTextToMany sends the same text message to every recipient, with at most
+// concurrency messages in flight (zero or less means DefaultFanOutConcurrency),
+// and returns the results keyed by recipient. The Cloud API has no
+// multi-recipient endpoint, so every recipient gets a message of its own. The
+// message to every recipient has a key of its own derived from the idempotency
+// key of ctx, if any: the key followed by "/" and the recipient.
+//
+// Example usage:
+//
+//	results := client.SendTextToMany(ctx, recipients, &SendTextParams{Body: "We're open today!"}, 0)
+//	for recipient, result := range results {
+//	    if result.Err != nil {
+//	        log.Printf("Failed to send to %s: %v", recipient, result.Err)
+//	    }
+//	}
+func (wa *Client) SendTextToMany(ctx context.Context, recipients []string, params *SendTextParams, concurrency int) map[string]RecipientResult {
+	return wa.fanOut(ctx, recipients, concurrency, func(ctx context.Context, recipient string) (*MessagesResponse, error) {
+		return wa.SendText(ctx, recipient, params)
+	})
+}
+
+// SendTemplateToMany sends the same template message to every recipient, like SendTextToMany.
+func (wa *Client) SendTemplateToMany(ctx context.Context, recipients []string, params *SendTemplateParams, concurrency int) map[string]RecipientResult {
+	return wa.fanOut(ctx, recipients, concurrency, func(ctx context.Context, recipient string) (*MessagesResponse, error) {
+		return wa.SendTemplate(ctx, recipient, params)
+	})
+}
+
+// fanOut calls send for every distinct recipient with bounded concurrency.
+// Every recipient is sent with an idempotency key of its own.
+func (wa *Client) fanOut(ctx context.Context, recipients []string, concurrency int, send func(context.Context, string) (*MessagesResponse, error)) map[string]RecipientResult {
+	var (
+		results = make(map[string]RecipientResult, len(recipients))
+		seen    = make(map[string]bool, len(recipients))
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+	if concurrency <= 0 {
+		concurrency = DefaultFanOutConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	setResult := func(recipient string, result RecipientResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[recipient] = result
+	}
+	for _, recipient := range recipients {
+		if seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			setResult(recipient, RecipientResult{Err: ctx.Err()})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			sendCtx := ctx
+			if key, ok := IdempotencyKeyFromContext(ctx); ok {
+				// Every recipient gets a message of its own, so it needs a key of its own
+				sendCtx = ContextWithIdempotencyKey(ctx, key+"/"+recipient)
+			}
+			response, err := send(sendCtx, recipient)
+			setResult(recipient, RecipientResult{Response: response, Err: err})
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,27 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSendTextToManyIdempotencyKeys(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	client := newTestClient(t, messagesHandler, WithIdempotency(store, 0))
+	ctx := ContextWithIdempotencyKey(context.Background(), "announcement")
+	recipients := []string{"15551234567", "15557654321", "15550001111"}
+
+	for _, concurrency := range []int{-1, 0, 2} {
+		results := client.SendTextToMany(ctx, recipients, &SendTextParams{Body: "We're open today!"}, concurrency)
+		for _, recipient := range recipients {
+			err := results[recipient].Err
+			if concurrency == -1 && err != nil {
+				t.Errorf("first send to %s: %v", recipient, err)
+			}
+			if concurrency != -1 && !errors.Is(err, ErrDuplicateMessage) {
+				t.Errorf("repeated send to %s = %v, want %v", recipient, err, ErrDuplicateMessage)
+			}
+		}
+	}
+}
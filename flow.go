@@ -0,0 +1,153 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// DefaultFlowMessageVersion is the flow message version used by FlowMessageBuilder.
+const DefaultFlowMessageVersion = "3"
+
+// flowScreenNameRegexp matches valid flow screen IDs.
+var flowScreenNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// ValidateFlowScreenName checks that screen is a valid flow screen ID.
+// SUCCESS is reserved for the terminal screen and can't be navigated to.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowjson#screens
+func ValidateFlowScreenName(screen string) error {
+	if !flowScreenNameRegexp.MatchString(screen) {
+		return fmt.Errorf("invalid screen name %q: must start with a letter and contain only letters, digits and underscores", screen)
+	}
+	if screen == "SUCCESS" {
+		return fmt.Errorf("screen name %q is reserved", screen)
+	}
+	return nil
+}
+
+// FlowMessageBuilder assembles SendInteractiveFlowParams, marshaling a typed
+// first-screen data struct into the flow action payload instead of requiring
+// a hand-built map[string]interface{}.
+//
+// Example usage:
+//
+//	type AppointmentData struct {
+//	    Department string `json:"department"`
+//	    Slots      []string `json:"slots"`
+//	}
+//
+//	params, err := NewFlowMessageBuilder("1234567890", "flow-token", "Book now").
+//	    Body("Book your appointment").
+//	    Navigate("APPOINTMENT", AppointmentData{Department: "cardiology", Slots: slots}).
+//	    Build()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	response, err := client.SendInteractiveFlow(ctx, "1234567890", params)
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/interactive-flow-messages
+type FlowMessageBuilder struct {
+	header *Header
+	body   *Body
+	footer *Footer
+	flow   FlowParameters
+	errs   []error
+}
+
+// NewFlowMessageBuilder starts a flow message for the flow with the given ID.
+// The flow action defaults to FlowActionDataExchange until Navigate is called,
+// and the flow message version defaults to DefaultFlowMessageVersion.
+func NewFlowMessageBuilder(flowID, flowToken, flowCTA string) *FlowMessageBuilder {
+	return &FlowMessageBuilder{
+		flow: FlowParameters{
+			FlowMessageVersion: DefaultFlowMessageVersion,
+			FlowID:             flowID,
+			FlowToken:          flowToken,
+			FlowCTA:            flowCTA,
+			FlowAction:         FlowActionDataExchange,
+		},
+	}
+}
+
+// Version overrides the flow message version.
+func (b *FlowMessageBuilder) Version(version string) *FlowMessageBuilder {
+	b.flow.FlowMessageVersion = version
+	return b
+}
+
+// Mode sets the flow mode, e.g. FlowModeDraft for testing unpublished flows.
+func (b *FlowMessageBuilder) Mode(mode FlowMode) *FlowMessageBuilder {
+	b.flow.FlowMode = mode
+	return b
+}
+
+// Header sets the optional message header.
+func (b *FlowMessageBuilder) Header(header *Header) *FlowMessageBuilder {
+	b.header = header
+	return b
+}
+
+// Body sets the required message body text.
+func (b *FlowMessageBuilder) Body(text string) *FlowMessageBuilder {
+	b.body = &Body{Text: text}
+	return b
+}
+
+// Footer sets the optional message footer text.
+func (b *FlowMessageBuilder) Footer(text string) *FlowMessageBuilder {
+	b.footer = &Footer{Text: text}
+	return b
+}
+
+// Navigate makes the flow open on screen with data as its initial data. The data
+// must marshal to a JSON object; it is typically a struct with json tags matching
+// the screen's data model. A nil data sends no initial data.
+func (b *FlowMessageBuilder) Navigate(screen string, data any) *FlowMessageBuilder {
+	b.flow.FlowAction = FlowActionNavigate
+	payload := &FlowActionPayload{Screen: screen}
+	if err := ValidateFlowScreenName(screen); err != nil {
+		b.errs = append(b.errs, err)
+	}
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err == nil {
+			err = json.Unmarshal(encoded, &payload.Data)
+		}
+		if err != nil {
+			b.errs = append(b.errs, fmt.Errorf("screen data must marshal to a JSON object: %w", err))
+		}
+	}
+	b.flow.FlowActionPayload = payload
+	return b
+}
+
+// DataExchange makes the flow request its first screen from the flow data endpoint.
+func (b *FlowMessageBuilder) DataExchange() *FlowMessageBuilder {
+	b.flow.FlowAction = FlowActionDataExchange
+	b.flow.FlowActionPayload = nil
+	return b
+}
+
+// Build validates the message and returns the parameters for SendInteractiveFlow.
+func (b *FlowMessageBuilder) Build() (*SendInteractiveFlowParams, error) {
+	errs := append([]error(nil), b.errs...)
+	if b.body == nil || b.body.Text == "" {
+		errs = append(errs, fmt.Errorf("body is required"))
+	}
+	if err := b.flow.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid flow message: %w", err)
+	}
+
+	flow := b.flow
+	return &SendInteractiveFlowParams{
+		Header:         b.header,
+		Body:           b.body,
+		Footer:         b.footer,
+		FlowParameters: &flow,
+	}, nil
+}
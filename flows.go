@@ -0,0 +1,374 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// FlowStatus represents the status of a flow.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi
+type FlowStatus string
+
+const (
+	// FlowStatusDraft represents a flow that is being edited and can only be sent in draft mode.
+	FlowStatusDraft FlowStatus = "DRAFT"
+	// FlowStatusPublished represents a published flow that can be sent to users.
+	FlowStatusPublished FlowStatus = "PUBLISHED"
+	// FlowStatusDeprecated represents a flow that can no longer be sent.
+	FlowStatusDeprecated FlowStatus = "DEPRECATED"
+	// FlowStatusBlocked represents a flow blocked because its endpoint is unhealthy.
+	FlowStatusBlocked FlowStatus = "BLOCKED"
+	// FlowStatusThrottled represents a flow throttled because its endpoint is unhealthy.
+	FlowStatusThrottled FlowStatus = "THROTTLED"
+)
+
+// FlowCategory represents the category of a flow.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi
+type FlowCategory string
+
+const (
+	// FlowCategorySignUp represents a flow that signs users up.
+	FlowCategorySignUp FlowCategory = "SIGN_UP"
+	// FlowCategorySignIn represents a flow that signs users in.
+	FlowCategorySignIn FlowCategory = "SIGN_IN"
+	// FlowCategoryAppointmentBooking represents a flow that books appointments.
+	FlowCategoryAppointmentBooking FlowCategory = "APPOINTMENT_BOOKING"
+	// FlowCategoryLeadGeneration represents a flow that collects leads.
+	FlowCategoryLeadGeneration FlowCategory = "LEAD_GENERATION"
+	// FlowCategoryContactUs represents a flow that lets users get in touch.
+	FlowCategoryContactUs FlowCategory = "CONTACT_US"
+	// FlowCategoryCustomerSupport represents a flow that provides customer support.
+	FlowCategoryCustomerSupport FlowCategory = "CUSTOMER_SUPPORT"
+	// FlowCategorySurvey represents a flow that surveys users.
+	FlowCategorySurvey FlowCategory = "SURVEY"
+	// FlowCategoryOther represents a flow that fits no other category.
+	FlowCategoryOther FlowCategory = "OTHER"
+)
+
+// FlowValidationError is a problem found in a flow JSON by the Flows API.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi
+type FlowValidationError struct {
+	Error       string `json:"error"`
+	ErrorType   string `json:"error_type"`
+	Message     string `json:"message"`
+	LineStart   int    `json:"line_start"`
+	LineEnd     int    `json:"line_end"`
+	ColumnStart int    `json:"column_start"`
+	ColumnEnd   int    `json:"column_end"`
+}
+
+// FlowValidationErrors is returned when the Flows API accepted the flow JSON
+// but reported validation errors in it. A flow with validation errors can't be published.
+type FlowValidationErrors []FlowValidationError
+
+// Error implements the error interface.
+func (fve FlowValidationErrors) Error() string {
+	messages := make([]string, len(fve))
+	for i, e := range fve {
+		messages[i] = fmt.Sprintf("%d:%d: %s: %s", e.LineStart, e.ColumnStart, e.Error, e.Message)
+	}
+	return "flow JSON validation failed: " + strings.Join(messages, "; ")
+}
+
+// Flow represents a flow of the WhatsApp Business Account.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi
+type Flow struct {
+	ID               string                `json:"id"`
+	Name             string                `json:"name"`
+	Status           FlowStatus            `json:"status"`
+	Categories       []FlowCategory        `json:"categories"`
+	ValidationErrors []FlowValidationError `json:"validation_errors,omitempty"`
+	JSONVersion      string                `json:"json_version,omitempty"`
+	DataAPIVersion   string                `json:"data_api_version,omitempty"`
+	EndpointURI      string                `json:"endpoint_uri,omitempty"`
+	Preview          *FlowPreview          `json:"preview,omitempty"`
+}
+
+// FlowPreview contains a web preview link of a flow.
+type FlowPreview struct {
+	PreviewURL string `json:"preview_url"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// FlowAsset is an asset of a flow, such as its flow JSON.
+type FlowAsset struct {
+	Name        string `json:"name"`
+	AssetType   string `json:"asset_type"`
+	DownloadURL string `json:"download_url"`
+}
+
+// CreateFlowParams contains parameters for creating a flow.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#create
+type CreateFlowParams struct {
+	// Name is the required name of the flow.
+	Name string `json:"name"`
+	// Categories is the required list of flow categories.
+	Categories []FlowCategory `json:"categories"`
+	// FlowJSON is the optional flow JSON to upload on creation.
+	FlowJSON string `json:"flow_json,omitempty"`
+	// Publish publishes the flow right after creation. Requires FlowJSON.
+	Publish bool `json:"publish,omitempty"`
+	// CloneFlowID is the optional ID of a flow to copy the flow JSON from.
+	CloneFlowID string `json:"clone_flow_id,omitempty"`
+	// EndpointURI is the optional URL of the flow data endpoint.
+	EndpointURI string `json:"endpoint_uri,omitempty"`
+}
+
+// Validate validates the create flow parameters
+func (cfp *CreateFlowParams) Validate() error {
+	if cfp == nil {
+		return fmt.Errorf("create flow parameters cannot be nil")
+	}
+	if cfp.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(cfp.Categories) == 0 {
+		return fmt.Errorf("at least one category is required")
+	}
+	if cfp.Publish && cfp.FlowJSON == "" {
+		return fmt.Errorf("flow_json is required to publish")
+	}
+	return nil
+}
+
+// CreateFlowResponse represents the response from creating a flow.
+type CreateFlowResponse struct {
+	ID               string                `json:"id"`
+	Success          bool                  `json:"success"`
+	ValidationErrors []FlowValidationError `json:"validation_errors,omitempty"`
+}
+
+// UpdateFlowJSONResponse represents the response from updating a flow JSON.
+type UpdateFlowJSONResponse struct {
+	Success          bool                  `json:"success"`
+	ValidationErrors []FlowValidationError `json:"validation_errors,omitempty"`
+}
+
+// FlowsResponse represents a page of flows.
+type FlowsResponse struct {
+	Data   []Flow `json:"data"`
+	Paging Paging `json:"paging"`
+}
+
+// Paging contains the cursors of a paginated Graph API response.
+// https://developers.facebook.com/docs/graph-api/results
+type Paging struct {
+	Cursors struct {
+		Before string `json:"before"`
+		After  string `json:"after"`
+	} `json:"cursors"`
+	Next     string `json:"next,omitempty"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// SuccessResponse represents the response of Graph API calls that only report success.
+type SuccessResponse struct {
+	Success bool `json:"success"`
+}
+
+// flowFields are the flow fields requested by GetFlow.
+const flowFields = "id,name,status,categories,validation_errors,json_version,data_api_version,endpoint_uri,preview.invalidate(false)"
+
+// WithBusinessAccountID sets the WhatsApp Business Account ID required by
+// account-level APIs such as flow management.
+func WithBusinessAccountID(businessAccountID string) ClientOption {
+	return func(wa *Client) {
+		wa.BusinessAccountID = businessAccountID
+	}
+}
+
+// businessAccount returns the WhatsApp Business Account ID, or an error if it's not configured.
+func (wa *Client) businessAccount() (string, error) {
+	if wa.BusinessAccountID == "" {
+		return "", errors.New("business account ID is not configured")
+	}
+	return wa.BusinessAccountID, nil
+}
+
+// CreateFlow creates a flow in the WhatsApp Business Account. If the flow JSON
+// was provided and has validation errors, they are returned as FlowValidationErrors
+// together with the response, since the flow itself is created as a draft.
+//
+// Example usage:
+//
+//	flowJSON, err := os.ReadFile("flows/signup.json")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	response, err := client.CreateFlow(ctx, &CreateFlowParams{
+//	    Name:       "Sign up",
+//	    Categories: []FlowCategory{FlowCategorySignUp},
+//	    FlowJSON:   string(flowJSON),
+//	})
+//
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#create
+func (wa *Client) CreateFlow(ctx context.Context, params *CreateFlowParams) (*CreateFlowResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid create flow parameters: %w", err)
+	}
+	wabaID, err := wa.businessAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	var response CreateFlowResponse
+	if err := graphRequest(ctx, wa, http.MethodPost, []string{wabaID, "flows"}, nil, params, &response); err != nil {
+		return nil, err
+	}
+	if len(response.ValidationErrors) > 0 {
+		return &response, FlowValidationErrors(response.ValidationErrors)
+	}
+	return &response, nil
+}
+
+// UpdateFlowJSON uploads a new flow JSON for a draft flow. Validation errors
+// reported by the API are returned as FlowValidationErrors together with the response.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#update-json
+func (wa *Client) UpdateFlowJSON(ctx context.Context, flowID string, flowJSON io.Reader) (*UpdateFlowJSONResponse, error) {
+	if flowID == "" {
+		return nil, fmt.Errorf("flow ID cannot be empty")
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx, wa.SendTimeout)
+	defer cancel()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="flow.json"`)
+	h.Set("Content-Type", "application/json")
+
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return nil, fmt.Errorf("creating multipart part: %w", err)
+	}
+	if _, err := io.Copy(part, flowJSON); err != nil {
+		return nil, fmt.Errorf("copying flow JSON: %w", err)
+	}
+	if err := errors.Join(
+		writer.WriteField("name", "flow.json"),
+		writer.WriteField("asset_type", "FLOW_JSON"),
+		writer.Close(),
+	); err != nil {
+		return nil, fmt.Errorf("setting up multipart writer: %w", err)
+	}
+
+	u, err := wa.graphURL(flowID, "assets")
+	if err != nil {
+		return nil, fmt.Errorf("build URL: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, &body)
+	if err != nil {
+		return nil, wa.scrub(fmt.Errorf("creating request: %w", err))
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := wa.do(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, wa.scrub(decodeAPIError(resp, fmt.Errorf("update flow JSON status %s", resp.Status)))
+	}
+
+	var response UpdateFlowJSONResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if len(response.ValidationErrors) > 0 {
+		return &response, FlowValidationErrors(response.ValidationErrors)
+	}
+	return &response, nil
+}
+
+// PublishFlow publishes a draft flow. Published flows can't be modified anymore.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#publish
+func (wa *Client) PublishFlow(ctx context.Context, flowID string) (*SuccessResponse, error) {
+	return wa.flowAction(ctx, http.MethodPost, flowID, "publish")
+}
+
+// DeprecateFlow deprecates a published flow so it can no longer be sent.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#deprecate
+func (wa *Client) DeprecateFlow(ctx context.Context, flowID string) (*SuccessResponse, error) {
+	return wa.flowAction(ctx, http.MethodPost, flowID, "deprecate")
+}
+
+// DeleteFlow deletes a draft flow.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#delete
+func (wa *Client) DeleteFlow(ctx context.Context, flowID string) (*SuccessResponse, error) {
+	return wa.flowAction(ctx, http.MethodDelete, flowID)
+}
+
+func (wa *Client) flowAction(ctx context.Context, method, flowID string, segments ...string) (*SuccessResponse, error) {
+	if flowID == "" {
+		return nil, fmt.Errorf("flow ID cannot be empty")
+	}
+	var response SuccessResponse
+	if err := graphRequest(ctx, wa, method, append([]string{flowID}, segments...), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListFlows returns a page of flows of the WhatsApp Business Account.
+// Pass the Paging.Cursors.After value of the previous page to get the next one,
+// or an empty string for the first page.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#list
+func (wa *Client) ListFlows(ctx context.Context, after string) (*FlowsResponse, error) {
+	wabaID, err := wa.businessAccount()
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if after != "" {
+		query.Set("after", after)
+	}
+
+	var response FlowsResponse
+	if err := graphRequest(ctx, wa, http.MethodGet, []string{wabaID, "flows"}, query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetFlow returns the details of a flow, including a web preview URL.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#details
+func (wa *Client) GetFlow(ctx context.Context, flowID string) (*Flow, error) {
+	if flowID == "" {
+		return nil, fmt.Errorf("flow ID cannot be empty")
+	}
+	var response Flow
+	query := url.Values{"fields": {flowFields}}
+	if err := graphRequest(ctx, wa, http.MethodGet, []string{flowID}, query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetFlowAssets returns the assets of a flow, such as the download URL of its flow JSON.
+// https://developers.facebook.com/docs/whatsapp/flows/reference/flowsapi#asset-list
+func (wa *Client) GetFlowAssets(ctx context.Context, flowID string) ([]FlowAsset, error) {
+	if flowID == "" {
+		return nil, fmt.Errorf("flow ID cannot be empty")
+	}
+	var response struct {
+		Data []FlowAsset `json:"data"`
+	}
+	if err := graphRequest(ctx, wa, http.MethodGet, []string{flowID, "assets"}, nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
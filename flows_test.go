@@ -0,0 +1,26 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateFlowJSONSendTimeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true}`))
+	}, WithSendTimeout(10*time.Millisecond))
+
+	_, err := client.UpdateFlowJSON(context.Background(), "flow-1", strings.NewReader(`{"version":"6.0"}`))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("UpdateFlowJSON = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
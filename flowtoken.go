@@ -0,0 +1,175 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultFlowTokenTTL is the period a flow token is valid for, if the TTL of
+// its FlowTokens is not set.
+const DefaultFlowTokenTTL = 24 * time.Hour
+
+var (
+	// ErrInvalidFlowToken is returned for flow tokens that weren't minted by
+	// FlowTokens, were tampered with, or belong to another user or flow.
+	ErrInvalidFlowToken = errors.New("invalid flow token")
+	// ErrFlowTokenExpired is returned for flow tokens whose TTL has passed.
+	ErrFlowTokenExpired = errors.New("flow token expired")
+)
+
+// flowTokenHeader is the JOSE header of flow tokens.
+type flowTokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// FlowTokenClaims are the claims of a flow token.
+type FlowTokenClaims struct {
+	// WaID is the WhatsApp ID of the user the flow was sent to.
+	WaID string `json:"sub"`
+	// FlowID is the ID of the flow.
+	FlowID string `json:"flow_id"`
+	// IssuedAt and ExpiresAt are Unix timestamps.
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+	// Nonce makes every token unique, so it can identify the flow session.
+	Nonce string `json:"jti"`
+}
+
+// FlowTokens mints and verifies flow_token values signed with HMAC-SHA256,
+// as JWTs with the HS256 algorithm. The flow token is the only thing
+// identifying a flow session to the flow data endpoint, so an endpoint
+// accepting arbitrary tokens lets anyone drive the flow, e.g. to enumerate
+// data. Tokens minted by FlowTokens are bound to the user and the flow, and
+// expire. Keys are identified by the kid header, so they can be rotated.
+//
+// Example usage:
+//
+//	tokens := NewFlowTokens(&Keyring{Current: "k1", Keys: map[string][]byte{"k1": secret}})
+//	token, err := tokens.Mint(ctx, recipient, flowID)
+//	params, err := NewFlowMessageBuilder(flowID, token, "Book now").Body("Pick a slot").DataExchange().Build()
+//
+//	// In the flow data endpoint:
+//	claims, err := tokens.Verify(ctx, request.FlowToken, "", flowID)
+//	if err != nil {
+//	    return http.StatusUnauthorized
+//	}
+type FlowTokens struct {
+	// Keys supplies the signing keys.
+	Keys KeyProvider
+	// TTL is the period tokens are valid for, DefaultFlowTokenTTL if zero.
+	TTL time.Duration
+}
+
+// NewFlowTokens creates FlowTokens signing with the keys.
+func NewFlowTokens(keys KeyProvider) *FlowTokens {
+	return &FlowTokens{Keys: keys}
+}
+
+// Mint returns a new flow token for the flow with flowID sent to waID.
+func (ft *FlowTokens) Mint(ctx context.Context, waID, flowID string) (string, error) {
+	if waID == "" || flowID == "" {
+		return "", fmt.Errorf("wa_id and flow ID are required to mint a flow token")
+	}
+	id, key, err := ft.Keys.CurrentKey(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting signing key: %w", err)
+	}
+	if len(key) == 0 {
+		return "", fmt.Errorf("signing key %q is empty", id)
+	}
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	header, err := json.Marshal(flowTokenHeader{Algorithm: "HS256", Type: "JWT", KeyID: id})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(FlowTokenClaims{
+		WaID:      waID,
+		FlowID:    flowID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(orDefault(ft.TTL, DefaultFlowTokenTTL)).Unix(),
+		Nonce:     base64.RawURLEncoding.EncodeToString(nonce),
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signFlowToken(key, signed)), nil
+}
+
+// Verify checks the signature and the expiry of token, and returns its
+// claims. If waID or flowID is not empty, the token must be bound to it. The
+// flow data endpoint doesn't receive the wa_id of the user, so it checks the
+// flow ID only, while the wa_id is checked for the flow responses in webhooks.
+func (ft *FlowTokens) Verify(ctx context.Context, token, waID, flowID string) (*FlowTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidFlowToken)
+	}
+	var header flowTokenHeader
+	if err := decodeFlowTokenPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Algorithm != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidFlowToken, header.Algorithm)
+	}
+	key, err := ft.Keys.Key(ctx, header.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidFlowToken, err)
+	}
+	if len(key) == 0 {
+		// Anyone can sign with an empty key
+		return nil, fmt.Errorf("%w: signing key %q is empty", ErrInvalidFlowToken, header.KeyID)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, signFlowToken(key, parts[0]+"."+parts[1])) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidFlowToken)
+	}
+
+	var claims FlowTokenClaims
+	if err := decodeFlowTokenPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("%w at %s", ErrFlowTokenExpired, time.Unix(claims.ExpiresAt, 0).UTC().Format(time.RFC3339))
+	}
+	if waID != "" && !samePhoneNumber(claims.WaID, waID) {
+		return nil, fmt.Errorf("%w: minted for another user", ErrInvalidFlowToken)
+	}
+	if flowID != "" && claims.FlowID != flowID {
+		return nil, fmt.Errorf("%w: minted for flow %s", ErrInvalidFlowToken, claims.FlowID)
+	}
+	return &claims, nil
+}
+
+// signFlowToken returns the HMAC-SHA256 of the signed part of a token.
+func signFlowToken(key []byte, signed string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+// decodeFlowTokenPart decodes a base64url-encoded JSON part of a token into v.
+func decodeFlowTokenPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFlowToken, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFlowToken, err)
+	}
+	return nil
+}
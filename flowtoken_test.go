@@ -0,0 +1,49 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestFlowTokensRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	tokens := NewFlowTokens(&Keyring{Current: "k1", Keys: map[string][]byte{"k1": []byte("secret")}})
+	token, err := tokens.Mint(ctx, "15551234567", "flow-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := tokens.Verify(ctx, token, "+1 555 123 4567", "flow-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.WaID != "15551234567" || claims.FlowID != "flow-1" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if _, err := tokens.Verify(ctx, token, "", "flow-2"); !errors.Is(err, ErrInvalidFlowToken) {
+		t.Errorf("Verify for another flow = %v, want %v", err, ErrInvalidFlowToken)
+	}
+}
+
+func TestFlowTokensVerifyRejectsEmptyKey(t *testing.T) {
+	ctx := context.Background()
+	tokens := NewFlowTokens(&Keyring{Current: "k1", Keys: map[string][]byte{"k1": []byte("secret"), "empty": {}}})
+	if _, err := (&FlowTokens{Keys: &Keyring{Current: "empty", Keys: map[string][]byte{"empty": {}}}}).Mint(ctx, "15551234567", "flow-1"); err == nil {
+		t.Error("Mint with an empty key succeeded")
+	}
+
+	// A token signed with an empty key, as anyone can forge one
+	encode := base64.RawURLEncoding.EncodeToString
+	signed := encode([]byte(`{"alg":"HS256","typ":"JWT","kid":"empty"}`)) + "." +
+		encode([]byte(`{"sub":"15551234567","flow_id":"flow-1","iat":1700000000,"exp":4102444800,"jti":"x"}`))
+	mac := hmac.New(sha256.New, nil)
+	mac.Write([]byte(signed))
+	forged := signed + "." + encode(mac.Sum(nil))
+
+	if _, err := tokens.Verify(ctx, forged, "", "flow-1"); !errors.Is(err, ErrInvalidFlowToken) {
+		t.Errorf("Verify of a token signed with an empty key = %v, want %v", err, ErrInvalidFlowToken)
+	}
+}
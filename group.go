@@ -0,0 +1,54 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrGroupsDisabled is returned by SendToGroup unless the client was created with WithGroups.
+var ErrGroupsDisabled = errors.New("group messaging is not enabled, see WithGroups")
+
+// WithGroups enables SendToGroup. Group messaging is being rolled out
+// gradually and is only available to eligible business accounts.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/groups
+func WithGroups() ClientOption {
+	return func(wa *Client) {
+		wa.EnableGroups = true
+	}
+}
+
+// SendToGroup sends the message to the group with the given ID. The messaging
+// product, recipient type and recipient of the request are set accordingly.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithGroups())
+//	response, err := client.SendToGroup(ctx, groupID, &Request{
+//	    Type: MessageTypeText,
+//	    Text: &SendTextParams{Body: "Hello, everyone!"},
+//	})
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/groups
+func (wa *Client) SendToGroup(ctx context.Context, groupID string, request *Request) (*MessagesResponse, error) {
+	if !wa.EnableGroups {
+		return nil, ErrGroupsDisabled
+	}
+	if groupID == "" {
+		return nil, fmt.Errorf("group ID cannot be empty")
+	}
+	if request == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	groupRequest := *request
+	groupRequest.MessagingProduct = MessagingProductWhatsApp
+	groupRequest.RecipientType = RecipientTypeGroup
+	groupRequest.To = groupID
+	return wa.SendMessage(ctx, &groupRequest)
+}
+
+// IsGroup reports whether the message was sent to a group. For group messages,
+// From is the participant who sent the message.
+func (m *WebhookMessage) IsGroup() bool {
+	return m.GroupID != ""
+}
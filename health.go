@@ -0,0 +1,57 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultReadyTimeout limits how long the readiness endpoint of Webhook.Mux waits for ReadyCheck.
+const DefaultReadyTimeout = 5 * time.Second
+
+// Mux returns a ServeMux that serves the webhook at path next to the health
+// endpoints of a Kubernetes deployment:
+//
+//   - /healthz answers 200 as long as the process serves requests (liveness).
+//   - /readyz answers 200 if ReadyCheck succeeds and 503 with its error otherwise,
+//     or after Shutdown was called (readiness).
+//
+// Example usage:
+//
+//	webhook := NewWebhook(verifyToken, appSecret, handler)
+//	webhook.ReadyCheck = func(ctx context.Context) error {
+//	    return db.PingContext(ctx)
+//	}
+//	log.Fatal(http.ListenAndServe(":8080", webhook.Mux("/webhook")))
+func (wh *Webhook) Mux(path string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle(path, wh)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET /readyz", wh.serveReady)
+	return mux
+}
+
+// serveReady answers readiness probes.
+func (wh *Webhook) serveReady(w http.ResponseWriter, r *http.Request) {
+	if err := wh.ready(r.Context()); err != nil {
+		http.Error(w, fmt.Sprintf("not ready: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+// ready reports whether the webhook can handle notifications.
+func (wh *Webhook) ready(ctx context.Context) error {
+	if wh.inflight.isClosing() {
+		return ErrShuttingDown
+	}
+	if wh.ReadyCheck == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, DefaultReadyTimeout)
+	defer cancel()
+	return wh.ReadyCheck(ctx)
+}
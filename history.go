@@ -0,0 +1,201 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+)
+
+// EstimateTokens estimates the number of language model tokens of text, as
+// one token per four characters, which is close for English and errs on the
+// short side for other languages. It is meant for budgets, not billing.
+func EstimateTokens(text string) int {
+	return (TextLength(text) + 3) / 4
+}
+
+// History keeps the recent messages exchanged with every user, e.g. as the
+// memory of a ResponderBot or for the conversation view of a support
+// dashboard. Conversations are truncated to MaxTurns and MaxTokens as turns
+// are added, dropping the oldest turns, and forgotten after IdleTimeout. It
+// is safe for concurrent use, and its JSON encoding maps the WhatsApp IDs of
+// the users to their turns.
+//
+// Example usage:
+//
+//	history := &History{MaxTurns: 100, IdleTimeout: 7 * 24 * time.Hour}
+//	router.Use(history.Middleware)
+//	bot := NewResponderBot(client, responder)
+//	bot.History = history
+//
+//	// In the dashboard:
+//	json.NewEncoder(w).Encode(history.Conversation(waID))
+type History struct {
+	// MaxTurns, if positive, is the number of turns kept per user.
+	MaxTurns int
+	// MaxTokens, if positive, is the number of tokens, as estimated by
+	// EstimateTokens, kept per user. The last turn is kept even if it is longer.
+	MaxTokens int
+	// IdleTimeout, if positive, is the period without turns after which a
+	// conversation is forgotten.
+	IdleTimeout time.Duration
+
+	mu            sync.Mutex
+	conversations map[string][]ConversationTurn // conversations are the turns by user.
+	sweepAt       int                           // sweepAt is the size of conversations at which idle ones are removed.
+}
+
+// NewHistory creates a History keeping maxTurns turns per user.
+func NewHistory(maxTurns int) *History {
+	return &History{MaxTurns: maxTurns}
+}
+
+// Add appends turn to the conversation with the user with WhatsApp ID waID.
+// Turns without a time are added at the current time. A turn with the message
+// ID of a turn of the conversation isn't added again, so a message can be
+// added by several components, and redelivered notifications are ignored.
+func (h *History) Add(waID string, turn ConversationTurn) {
+	now := time.Now()
+	if turn.Time.IsZero() {
+		turn.Time = now
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conversations == nil {
+		h.conversations = make(map[string][]ConversationTurn)
+	}
+	if h.IdleTimeout > 0 && len(h.conversations) >= h.sweepAt {
+		for user, turns := range h.conversations {
+			if h.idle(turns, now) {
+				delete(h.conversations, user)
+			}
+		}
+		h.sweepAt = max(2*len(h.conversations), 1024)
+	}
+	turns := h.conversations[waID]
+	if h.idle(turns, now) {
+		turns = nil
+	}
+	if turn.MessageID != "" && slices.ContainsFunc(turns, func(added ConversationTurn) bool {
+		return added.MessageID == turn.MessageID
+	}) {
+		return
+	}
+	h.conversations[waID] = historyWindow(append(turns, turn), h.MaxTurns, h.MaxTokens)
+}
+
+// AddIncoming appends the text of message, as returned by InboundText, to the
+// conversation with its sender. Messages without text and group messages are
+// ignored.
+func (h *History) AddIncoming(message *IncomingMessage) {
+	text := InboundText(message.WebhookMessage)
+	if text == "" || message.IsGroup() {
+		return
+	}
+	// Add replaces the zero time of invalid timestamps
+	h.Add(message.From, ConversationTurn{
+		Role:      ConversationRoleUser,
+		Text:      text,
+		Time:      message.Time(),
+		MessageID: message.ID,
+	})
+}
+
+// Middleware returns next wrapped with the recording of incoming messages. It
+// is a MessageMiddleware.
+func (h *History) Middleware(next MessageHandler) MessageHandler {
+	return MessageHandlerFunc(func(ctx context.Context, message *IncomingMessage) error {
+		h.AddIncoming(message)
+		return next.HandleMessage(ctx, message)
+	})
+}
+
+// Conversation returns a copy of the turns of the conversation with the user,
+// oldest first, or nil if there are none.
+func (h *History) Conversation(waID string) []ConversationTurn {
+	return h.Window(waID, 0, 0)
+}
+
+// Window returns a copy of the most recent turns of the conversation with the
+// user that fit maxTurns and maxTokens, if positive, e.g. to fit the context
+// of a language model. The last turn is returned even if it is longer.
+func (h *History) Window(waID string, maxTurns, maxTokens int) []ConversationTurn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	turns := h.conversations[waID]
+	if h.idle(turns, time.Now()) {
+		return nil
+	}
+	return append([]ConversationTurn(nil), historyWindow(turns, maxTurns, maxTokens)...)
+}
+
+// PurgeUser implements UserPurger by forgetting the conversation with the user.
+func (h *History) PurgeUser(ctx context.Context, waID string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for user := range h.conversations {
+		if samePhoneNumber(user, waID) {
+			delete(h.conversations, user)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h *History) MarshalJSON() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conversations := make(map[string][]ConversationTurn, len(h.conversations))
+	now := time.Now()
+	for user, turns := range h.conversations {
+		if !h.idle(turns, now) {
+			conversations[user] = turns
+		}
+	}
+	return json.Marshal(conversations)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing the conversations,
+// e.g. to restore a History exported on shutdown.
+func (h *History) UnmarshalJSON(data []byte) error {
+	var conversations map[string][]ConversationTurn
+	if err := json.Unmarshal(data, &conversations); err != nil {
+		return err
+	}
+	for user, turns := range conversations {
+		if len(turns) == 0 {
+			delete(conversations, user)
+			continue
+		}
+		conversations[user] = historyWindow(turns, h.MaxTurns, h.MaxTokens)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conversations = conversations
+	h.sweepAt = 0
+	return nil
+}
+
+// idle reports whether the conversation with turns is forgotten at now. It
+// must be called with h.mu held.
+func (h *History) idle(turns []ConversationTurn, now time.Time) bool {
+	return h.IdleTimeout > 0 && len(turns) > 0 && now.Sub(turns[len(turns)-1].Time) >= h.IdleTimeout
+}
+
+// historyWindow returns the most recent turns that fit maxTurns and
+// maxTokens, if positive, and at least the last turn.
+func historyWindow(turns []ConversationTurn, maxTurns, maxTokens int) []ConversationTurn {
+	if maxTurns > 0 && len(turns) > maxTurns {
+		turns = turns[len(turns)-maxTurns:]
+	}
+	if maxTokens > 0 {
+		tokens := 0
+		for i := len(turns) - 1; i >= 0; i-- {
+			if tokens += EstimateTokens(turns[i].Text); tokens > maxTokens && i < len(turns)-1 {
+				return turns[i+1:]
+			}
+		}
+	}
+	return turns
+}
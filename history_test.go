@@ -0,0 +1,25 @@
+package whatsapp
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHistoryPurgeUserMatchesPhoneNumbers(t *testing.T) {
+	history := NewHistory(10)
+	history.Add("15551234567", ConversationTurn{Role: ConversationRoleUser, Text: "hello"})
+	history.Add("+1 555-123-4567", ConversationTurn{Role: ConversationRoleAssistant, Text: "hi"})
+	history.Add("15557654321", ConversationTurn{Role: ConversationRoleUser, Text: "other"})
+
+	if err := history.PurgeUser(context.Background(), "+1 (555) 123 4567"); err != nil {
+		t.Fatal(err)
+	}
+	for _, waID := range []string{"15551234567", "+1 555-123-4567"} {
+		if turns := history.Conversation(waID); turns != nil {
+			t.Errorf("Conversation(%q) = %+v after PurgeUser, want nil", waID, turns)
+		}
+	}
+	if turns := history.Conversation("15557654321"); len(turns) != 1 {
+		t.Errorf("Conversation of another user = %+v, want it kept", turns)
+	}
+}
@@ -12,10 +12,28 @@ type Link struct {
 
 type Options struct {
 	CollectLinks func([]Link)
+	// InlineLinks writes the href of every anchor after its text, as in
+	// "text (url)", see WithInlineLinks.
+	InlineLinks bool
 }
 
 type OptionFn func(*Options)
 
+// WithInlineLinks makes FromHTML write the href of every anchor in parentheses
+// after its text, so that links keep their context in long messages. Anchors
+// whose text is the URL itself are written once. FromHTMLWithLinks doesn't
+// append the list of links in this mode.
+//
+// Example usage:
+//
+//	text := FromHTML(`Read <a href="https://example.com/terms">the terms</a>.`, WithInlineLinks())
+//	// text is "Read the terms (https://example.com/terms)."
+func WithInlineLinks() OptionFn {
+	return func(opt *Options) {
+		opt.InlineLinks = true
+	}
+}
+
 func FormatLinks(links []Link) string {
 	if len(links) == 0 {
 		return ""
@@ -33,69 +51,329 @@ func FormatLinks(links []Link) string {
 	return out.String()
 }
 
-func FromHTML(text string, opts ...OptionFn) string {
-	simpleMappings := map[string]string{
-		"b": "*",
-		"i": "_",
-		"s": "~",
-	}
+// htmlMarkers are the WhatsApp formatting markers of HTML elements.
+var htmlMarkers = map[string]string{
+	"b":      "*",
+	"strong": "*",
+	"i":      "_",
+	"em":     "_",
+	"s":      "~",
+	"strike": "~",
+	"del":    "~",
+}
+
+// htmlBreaks are the line breaks around block elements: two for elements
+// rendered with vertical margins, like paragraphs, and one for the others.
+var htmlBreaks = map[string]int{
+	"p": 2, "h1": 2, "h2": 2, "h3": 2, "h4": 2, "h5": 2, "h6": 2,
+	"blockquote": 2, "pre": 2, "ul": 2, "ol": 2, "dl": 2, "table": 2, "hr": 2,
+	"div": 1, "li": 1, "dt": 1, "dd": 1, "tr": 1, "section": 1, "article": 1,
+	"header": 1, "footer": 1, "nav": 1, "aside": 1, "main": 1, "address": 1,
+	"figure": 1, "figcaption": 1, "form": 1, "fieldset": 1,
+}
+
+// htmlHidden are the elements whose content isn't rendered.
+var htmlHidden = map[string]bool{
+	"head": true, "title": true, "script": true, "style": true, "template": true,
+}
+
+// htmlSpace are the characters HTML treats as whitespace. Unlike
+// unicode.IsSpace, it excludes the non-breaking space.
+const htmlSpace = " \t\n\f\r"
 
+// FromHTML converts text from HTML to WhatsApp formatting. Bold, italic and
+// strikethrough elements become *, _ and ~ markers; other tags are dropped.
+// Markers are always balanced on every line: elements closed out of order, as
+// in <b><i>text</b></i>, are closed and reopened like a browser would,
+// elements spanning line breaks, also the ones of <pre>, are closed and
+// reopened on every line, unclosed elements are closed at the end, empty
+// elements produce no markers, and an element nested in one with the same
+// marker is merged into it.
+//
+// The text is laid out the way browsers render it: entities and numeric
+// character references are decoded, runs of whitespace collapse into a single
+// space except in <pre>, <br> and block elements such as paragraphs, list items
+// and headings start new lines, and leading and trailing whitespace is removed.
+// The content of <head>, <script> and <style> is dropped.
+func FromHTML(text string, opts ...OptionFn) string {
 	var options Options
 	for _, opt := range opts {
 		opt(&options)
 	}
 
+	c := htmlConverter{options: &options}
 	tokenizer := html.NewTokenizer(strings.NewReader(text))
-	var (
-		out   strings.Builder
-		links []Link
-	)
-
-	func() {
-		var currentLink *Link
-		for {
-			tokenType := tokenizer.Next()
-			switch tokenType {
-			case html.ErrorToken:
-				return
-			case html.TextToken:
-				text := tokenizer.Token().Data
-				if currentLink != nil {
-					currentLink.Text += text
-				}
-				out.WriteString(text)
-			case html.StartTagToken, html.EndTagToken:
-				token := tokenizer.Token()
-				switch token.Data {
-				case "a":
-					if tokenType == html.StartTagToken && options.CollectLinks != nil {
-						for _, attr := range token.Attr {
-							if attr.Key == "href" {
-								currentLink = &Link{Link: attr.Val}
-								break
-							}
-						}
-					} else if tokenType == html.EndTagToken && currentLink != nil {
-						links = append(links, *currentLink)
-						currentLink = nil
-					}
-				default:
-					if mapping, exists := simpleMappings[token.Data]; exists {
-						out.WriteString(mapping)
-					}
-				}
-			}
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		// Token decodes the entities of texts and attribute values
+		switch token := tokenizer.Token(); tokenType {
+		case html.TextToken:
+			c.text(token.Data)
+		case html.StartTagToken:
+			c.start(token)
+		case html.SelfClosingTagToken:
+			c.start(token)
+			c.end(token.Data)
+		case html.EndTagToken:
+			c.end(token.Data)
 		}
-	}()
+	}
+	c.closeAll()
+
+	if options.CollectLinks != nil && len(c.links) > 0 {
+		options.CollectLinks(c.links)
+	}
+	return c.out.String()
+}
+
+// htmlElement is an element open during the conversion.
+type htmlElement struct {
+	tag    string
+	marker string // marker is the formatting marker, empty if the element has none or an ancestor has the same.
+	opened bool   // opened reports whether the opening marker was written.
+}
+
+// htmlConverter converts a stream of HTML tokens to WhatsApp formatting.
+type htmlConverter struct {
+	options *Options
+	out     strings.Builder
+	stack   []htmlElement
+	// space and breaks are the whitespace and line breaks after the last text,
+	// written before the next text so that closing markers directly follow
+	// the text, as WhatsApp requires, and trailing whitespace is dropped.
+	space  string
+	breaks int
+	pre    int // pre is the number of open <pre> elements.
+	hidden int // hidden is the number of open elements whose content is dropped.
+	link   *Link
+	links  []Link
+}
+
+// text writes the text, opening the markers of the elements around it first.
+func (c *htmlConverter) text(text string) {
+	if c.hidden > 0 {
+		return
+	}
+	if c.pre == 0 {
+		text = collapseSpace(text)
+	}
+	if c.link != nil {
+		c.link.Text += text
+	}
+	trimmed := strings.TrimLeft(text, htmlSpace)
+	c.addSpace(text[:len(text)-len(trimmed)])
+	if trimmed == "" {
+		return
+	}
+
+	var before string
+	if c.out.Len() > 0 {
+		if c.breaks > 0 {
+			before = strings.Repeat("\n", c.breaks)
+		} else {
+			before = c.space
+		}
+	}
+	c.space, c.breaks = "", 0
+	core := strings.TrimRight(trimmed, htmlSpace)
+	// Markers can't span lines, so they are closed at the line breaks of
+	// preformatted text, and reopened for the next line with text
+	var trail string
+	for i, line := range strings.Split(before+core, "\n") {
+		if i > 0 {
+			c.closeMarkers()
+			c.out.WriteString(trail + "\n")
+		}
+		content := strings.TrimLeft(line, htmlSpace)
+		if content == "" {
+			trail = line
+			continue
+		}
+		c.out.WriteString(line[:len(line)-len(content)])
+		trimmedContent := strings.TrimRight(content, htmlSpace)
+		trail = content[len(trimmedContent):]
+		c.openMarkers()
+		c.out.WriteString(trimmedContent)
+	}
+	c.addSpace(trimmed[len(core):])
+}
+
+// openMarkers writes the markers of the open elements that weren't written yet.
+func (c *htmlConverter) openMarkers() {
+	for i := range c.stack {
+		if e := &c.stack[i]; !e.opened {
+			c.out.WriteString(e.marker)
+			e.opened = true
+		}
+	}
+}
+
+// closeMarkers writes the closing markers of the open elements, which are
+// reopened for the next text.
+func (c *htmlConverter) closeMarkers() {
+	for i := len(c.stack) - 1; i >= 0; i-- {
+		if e := &c.stack[i]; e.opened {
+			c.out.WriteString(e.marker)
+			e.opened = false
+		}
+	}
+}
 
-	if options.CollectLinks != nil && len(links) > 0 {
-		options.CollectLinks(links)
+// addSpace adds whitespace after the last text. Outside <pre> it collapses
+// into a single space.
+func (c *htmlConverter) addSpace(space string) {
+	switch {
+	case space == "":
+	case c.pre > 0:
+		c.space += space
+	default:
+		c.space = " "
 	}
+}
+
+// lineBreak makes the next text start on a new line, after at least n line
+// breaks. Markers can't span lines, so open ones are closed and reopened
+// for the next text.
+func (c *htmlConverter) lineBreak(n int) {
+	c.closeMarkers()
+	c.breaks = max(c.breaks, n)
+}
 
+// collapseSpace replaces every run of whitespace in text with a single space.
+func collapseSpace(text string) string {
+	var out strings.Builder
+	space := false
+	for _, r := range text {
+		if strings.ContainsRune(htmlSpace, r) {
+			space = true
+			continue
+		}
+		if space {
+			out.WriteByte(' ')
+			space = false
+		}
+		out.WriteRune(r)
+	}
+	if space {
+		out.WriteByte(' ')
+	}
 	return out.String()
 }
 
+// start opens the element of token.
+func (c *htmlConverter) start(token html.Token) {
+	if breaks, ok := htmlBreaks[token.Data]; ok {
+		c.lineBreak(breaks)
+	}
+	switch token.Data {
+	case "br":
+		c.lineBreak(c.breaks + 1)
+	case "pre":
+		c.pre++
+	case "a":
+		if c.link != nil {
+			// Anchors can't be nested, a new one ends the previous
+			c.end("a")
+		}
+		if c.options.CollectLinks == nil && !c.options.InlineLinks {
+			return
+		}
+		for _, attr := range token.Attr {
+			if attr.Key == "href" {
+				c.link = &Link{Link: attr.Val}
+				c.stack = append(c.stack, htmlElement{tag: "a"})
+				break
+			}
+		}
+	default:
+		if htmlHidden[token.Data] {
+			c.hidden++
+			return
+		}
+		marker, ok := htmlMarkers[token.Data]
+		if !ok {
+			return
+		}
+		for _, e := range c.stack {
+			if e.marker == marker {
+				marker = ""
+				break
+			}
+		}
+		c.stack = append(c.stack, htmlElement{tag: token.Data, marker: marker})
+	}
+}
+
+// end closes the innermost open element with the tag. The elements opened
+// inside it are closed as well, and reopened for the text following it.
+// Closing tags without an open element are ignored.
+func (c *htmlConverter) end(tag string) {
+	if breaks, ok := htmlBreaks[tag]; ok {
+		c.lineBreak(breaks)
+	}
+	switch {
+	case tag == "pre" && c.pre > 0:
+		c.pre--
+	case htmlHidden[tag] && c.hidden > 0:
+		c.hidden--
+	}
+
+	i := len(c.stack) - 1
+	for i >= 0 && c.stack[i].tag != tag {
+		i--
+	}
+	if i < 0 {
+		return
+	}
+	inner := c.stack[i+1:]
+	for j := len(c.stack) - 1; j >= i; j-- {
+		c.close(c.stack[j])
+	}
+	reopened := make([]htmlElement, 0, len(inner))
+	for _, e := range inner {
+		e.opened = false
+		reopened = append(reopened, e)
+	}
+	c.stack = append(c.stack[:i], reopened...)
+}
+
+// close writes the closing marker of e, or the link of an anchor.
+func (c *htmlConverter) close(e htmlElement) {
+	if e.opened {
+		c.out.WriteString(e.marker)
+	}
+	if e.tag != "a" || c.link == nil {
+		return
+	}
+	if c.options.InlineLinks && strings.TrimSpace(c.link.Text) != c.link.Link {
+		c.out.WriteString(" (" + c.link.Link + ")")
+	}
+	c.links = append(c.links, Link{Text: strings.TrimSpace(c.link.Text), Link: c.link.Link})
+	c.link = nil
+}
+
+// closeAll closes the elements left open at the end of the document.
+// Trailing whitespace is dropped.
+func (c *htmlConverter) closeAll() {
+	for i := len(c.stack) - 1; i >= 0; i-- {
+		c.close(c.stack[i])
+	}
+	c.stack = nil
+	c.space, c.breaks = "", 0
+}
+
 func FromHTMLWithLinks(text string, opts ...OptionFn) string {
+	var options Options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.InlineLinks {
+		return strings.TrimSpace(FromHTML(text, opts...))
+	}
+
 	var links []Link
 	opts = append(opts, func(opt *Options) {
 		prev := opt.CollectLinks
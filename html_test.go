@@ -0,0 +1,64 @@
+package whatsapp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromHTMLMarkers(t *testing.T) {
+	for _, tc := range []struct {
+		name, html, want string
+	}{
+		{"bold", "<b>bold</b> text", "*bold* text"},
+		{"nested", "<b>bold <i>both</i></b>", "*bold _both_*"},
+		{"closed out of order", "<b><i>x</b></i>", "*_x_*"},
+		{"closed out of order with text", "<b>a<i>b</b>c</i>", "*a_b_*_c_"},
+		{"unclosed", "<b>unclosed", "*unclosed*"},
+		{"unclosed nested", "<b>a <i>b", "*a _b_*"},
+		{"empty element", "a<b></b> <i> </i>b", "a b"},
+		{"same marker merged", "<b>a <strong>b</strong> c</b>", "*a b c*"},
+		{"same marker merged italic", "<em>a <i>b</i></em>", "_a b_"},
+		{"same marker after merge", "<s>a <del>b</del></s> <strike>c</strike>", "~a b~ ~c~"},
+		{"stray closing tag", "a</b> b", "a b"},
+		{"paragraphs", "<p><b>a</p><p>b</b></p>", "*a*\n\n*b*"},
+		{"line break", "<i>a<br>b</i>", "_a_\n_b_"},
+		{"preformatted lines", "<pre><b>a\n\nb</b></pre>", "*a*\n\n*b*"},
+		{"preformatted indentation", "<pre><b>a \n  b</b></pre>", "*a* \n  *b*"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FromHTML(tc.html); got != tc.want {
+				t.Errorf("FromHTML(%q) = %q, want %q", tc.html, got, tc.want)
+			}
+		})
+	}
+}
+
+func FuzzFromHTML(f *testing.F) {
+	for _, seed := range []string{
+		"<b><i>x</b></i>",
+		"<b>unclosed",
+		"<b>a <strong>b</strong> c</b>",
+		"<em>a <i>b</i></em>",
+		"<s>a <del>b</del></s> <strike>c</strike>",
+		"<b>a<i>b</b>c</i>",
+		"<p><b>a</p><p>b</b></p>",
+		"<pre><b>a\nb</b></pre>",
+		"<i>a<br>b</i>",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, html string) {
+		got := FromHTML(html)
+		// Markers in the text itself, possibly as character references, needn't be balanced
+		if strings.ContainsAny(html, "*_~&") {
+			return
+		}
+		for i, line := range strings.Split(got, "\n") {
+			for _, marker := range []string{"*", "_", "~"} {
+				if strings.Count(line, marker)%2 != 0 {
+					t.Fatalf("FromHTML(%q) = %q: unbalanced %s on line %d", html, got, marker, i+1)
+				}
+			}
+		}
+	})
+}
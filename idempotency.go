@@ -0,0 +1,124 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyTTL is the default time an idempotency key suppresses duplicate sends.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// ErrDuplicateMessage is returned without sending the message if a message
+// with the same idempotency key was already sent within the TTL window.
+var ErrDuplicateMessage = errors.New("duplicate message suppressed by idempotency key")
+
+// IdempotencyStore keeps the idempotency keys of sent messages.
+// Share a store between processes, e.g. backed by Redis, to suppress duplicates across them.
+type IdempotencyStore interface {
+	// Claim atomically claims key for ttl. It reports false if the key is
+	// already claimed and has not expired.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release frees a claimed key, so that a failed send can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencyKey is the context key of the idempotency key.
+type idempotencyKey struct{}
+
+// ContextWithIdempotencyKey returns a context that makes the message sent
+// with it idempotent: a client configured with WithIdempotency suppresses
+// further sends with the same key within the TTL window.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithIdempotency(NewMemoryIdempotencyStore(), time.Hour))
+//
+//	ctx := ContextWithIdempotencyKey(ctx, "order-1234-shipped")
+//	_, err := client.SendText(ctx, "1234567890", &SendTextParams{Body: "Your order has shipped!"})
+//	if errors.Is(err, ErrDuplicateMessage) {
+//	    // Already sent by a previous attempt
+//	}
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key of ctx, if any.
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKey{}).(string)
+	return key, ok && key != ""
+}
+
+// WithIdempotency suppresses duplicate sends of messages with the same
+// idempotency key within ttl, see ContextWithIdempotencyKey. Zero ttl means
+// DefaultIdempotencyTTL. Keys of failed sends are released, so they can be retried.
+func WithIdempotency(store IdempotencyStore, ttl time.Duration) ClientOption {
+	return func(wa *Client) {
+		wa.IdempotencyStore = store
+		wa.IdempotencyTTL = ttl
+	}
+}
+
+// claimIdempotencyKey claims the idempotency key of ctx. The returned release
+// function must be called if the send failed; it is nil if there is no key to release.
+func (wa *Client) claimIdempotencyKey(ctx context.Context) (release func(), err error) {
+	key, ok := IdempotencyKeyFromContext(ctx)
+	if wa.IdempotencyStore == nil || !ok {
+		return nil, nil
+	}
+	claimed, err := wa.IdempotencyStore.Claim(ctx, key, orDefault(wa.IdempotencyTTL, DefaultIdempotencyTTL))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrDuplicateMessage
+	}
+	return func() {
+		// The caller's context may be done by now, but the key must still be released
+		wa.IdempotencyStore.Release(context.WithoutCancel(ctx), key)
+	}, nil
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore keeping keys in memory.
+// It is safe for concurrent use. Expired keys are removed lazily.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	expiresAt map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{expiresAt: make(map[string]time.Time)}
+}
+
+// Claim implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= time.Minute {
+		for k, expiresAt := range s.expiresAt {
+			if !now.Before(expiresAt) {
+				delete(s.expiresAt, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if expiresAt, ok := s.expiresAt[key]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	s.expiresAt[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.expiresAt, key)
+	return nil
+}
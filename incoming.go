@@ -0,0 +1,208 @@
+package whatsapp
+
+import (
+	"cmp"
+	"errors"
+)
+
+// IncomingMessage is a webhook message together with the metadata of the
+// notification it arrived in.
+type IncomingMessage struct {
+	*WebhookMessage
+	// BusinessAccountID is the ID of the WhatsApp Business Account the message was sent to.
+	BusinessAccountID string `json:"business_account_id"`
+	// Metadata identifies the business phone number the message was sent to.
+	Metadata WebhookMetadata `json:"metadata"`
+	// Contact is the profile of the sender, or nil if the notification didn't include it.
+	Contact *WebhookContact `json:"contact,omitempty"`
+	// SenderName is the profile name of the sender, or empty if it's unknown.
+	SenderName string `json:"sender_name,omitempty"`
+}
+
+// StatusUpdate is a webhook status together with the metadata of the
+// notification it arrived in.
+type StatusUpdate struct {
+	*WebhookStatus
+	// BusinessAccountID is the ID of the WhatsApp Business Account that sent the message.
+	BusinessAccountID string `json:"business_account_id"`
+	// Metadata identifies the business phone number that sent the message.
+	Metadata WebhookMetadata `json:"metadata"`
+}
+
+// Messages returns the messages of all entries and changes of the request in
+// order, each enriched with its metadata and sender profile. The messages
+// point into the request.
+//
+// Example usage:
+//
+//	for _, message := range req.Messages() {
+//	    log.Printf("Message %s from %s to %s", message.ID, message.SenderName, message.Metadata.DisplayPhoneNumber)
+//	}
+func (r *WebhookRequest) Messages() []IncomingMessage {
+	var messages []IncomingMessage
+	for i := range r.Entry {
+		entry := &r.Entry[i]
+		for j := range entry.Changes {
+			value := &entry.Changes[j].Value
+			for k := range value.Messages {
+				messages = append(messages, newIncomingMessage(entry, value, &value.Messages[k]))
+			}
+		}
+	}
+	return messages
+}
+
+// newIncomingMessage returns message enriched with the metadata of the entry
+// and value it was received in.
+func newIncomingMessage(entry *WebhookEntry, value *WebhookValue, message *WebhookMessage) IncomingMessage {
+	incoming := IncomingMessage{
+		WebhookMessage:    message,
+		BusinessAccountID: entry.ID,
+		Metadata:          value.Metadata,
+		Contact:           value.contact(message.From),
+	}
+	if incoming.Contact != nil {
+		incoming.SenderName = incoming.Contact.Profile.Name
+	}
+	return incoming
+}
+
+// Statuses returns the statuses of all entries and changes of the request in
+// order, each enriched with its metadata. The statuses point into the request.
+func (r *WebhookRequest) Statuses() []StatusUpdate {
+	var statuses []StatusUpdate
+	for i := range r.Entry {
+		entry := &r.Entry[i]
+		for j := range entry.Changes {
+			value := &entry.Changes[j].Value
+			for k := range value.Statuses {
+				statuses = append(statuses, StatusUpdate{
+					WebhookStatus:     &value.Statuses[k],
+					BusinessAccountID: entry.ID,
+					Metadata:          value.Metadata,
+				})
+			}
+		}
+	}
+	return statuses
+}
+
+// contact returns the contact of the sender with the given WhatsApp ID, or nil if there is none.
+func (v *WebhookValue) contact(waID string) *WebhookContact {
+	for i := range v.Contacts {
+		if v.Contacts[i].WaID == waID {
+			return &v.Contacts[i]
+		}
+	}
+	// The wa_id may be normalized differently than the from field, e.g. for
+	// Brazilian numbers, but a notification with a single contact is about its sender
+	if len(v.Contacts) == 1 {
+		return &v.Contacts[0]
+	}
+	return nil
+}
+
+// SenderName returns the profile name of the sender of the message with the
+// given ID, or empty if the request doesn't contain it.
+func (r *WebhookRequest) SenderName(messageID string) string {
+	for _, message := range r.Messages() {
+		if message.ID == messageID {
+			return message.SenderName
+		}
+	}
+	return ""
+}
+
+// IsEphemeral reports whether the message is a disappearing or view-once
+// message wrapping another message.
+func (m *WebhookMessage) IsEphemeral() bool {
+	return m.Type == MessageTypeEphemeral && m.Ephemeral != nil
+}
+
+// IsViewOnce reports whether the message, or the message it wraps, contains
+// media that can be viewed only once.
+func (m *WebhookMessage) IsViewOnce() bool {
+	inner := m.Unwrap()
+	for _, media := range []*WebhookMessageMedia{inner.Image, inner.Video, inner.Audio, inner.Document} {
+		if media != nil && media.ViewOnce {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns the message wrapped by an ephemeral message, with the ID,
+// sender, timestamp and context of the wrapper filled in where the inner
+// message lacks them. Other messages are returned as is.
+//
+// Example usage:
+//
+//	message = message.Unwrap()
+//	switch message.Type {
+//	case MessageTypeText:
+//	    ...
+//	}
+func (m *WebhookMessage) Unwrap() *WebhookMessage {
+	if !m.IsEphemeral() {
+		return m
+	}
+	inner := *m.Ephemeral
+	if inner.ID == "" {
+		inner.ID = m.ID
+	}
+	if inner.From == "" {
+		inner.From = m.From
+	}
+	if inner.Timestamp == "" {
+		inner.Timestamp = m.Timestamp
+	}
+	if inner.Context == nil {
+		inner.Context = m.Context
+	}
+	return inner.Unwrap()
+}
+
+// UnsupportedMessage explains why a message of type unknown or unsupported
+// couldn't be delivered in a supported format.
+type UnsupportedMessage struct {
+	// Type is the original type of the message, e.g. "poll", or empty if the
+	// notification doesn't tell.
+	Type string
+	// Reason is the most specific description of the errors, e.g. "Message
+	// type is currently not supported.", or empty without errors.
+	Reason string
+	// Err wraps a *GraphError for every error of the message, or is nil
+	// without errors, like FailedReason.
+	Err error
+}
+
+// UnsupportedInfo returns why the message, or the message it wraps, is of
+// type unknown or unsupported, or nil for messages of other types, so bots
+// can tell users which messages they can't handle.
+//
+// Example usage:
+//
+//	if info := message.UnsupportedInfo(); info != nil {
+//	    log.Printf("unsupported %s message: %s", info.Type, info.Reason)
+//	    client.SendText(ctx, message.From, &SendTextParams{Body: "Sorry, I can't read this kind of message yet."})
+//	}
+func (m *WebhookMessage) UnsupportedInfo() *UnsupportedMessage {
+	inner := m.Unwrap()
+	if inner.Type != MessageTypeUnknown && inner.Type != MessageTypeUnsupported {
+		return nil
+	}
+	info := &UnsupportedMessage{}
+	if inner.Unsupported != nil {
+		info.Type = inner.Unsupported.Type
+	}
+	errs := make([]error, len(inner.Errors))
+	for i := range inner.Errors {
+		errs[i] = inner.Errors[i].Err()
+	}
+	info.Err = errors.Join(errs...)
+	if len(inner.Errors) > 0 {
+		err := inner.Errors[0].Err()
+		info.Reason = cmp.Or(err.Details, err.Message)
+	}
+	return info
+}
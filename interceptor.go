@@ -0,0 +1,74 @@
+package whatsapp
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// RequestInfo describes an outgoing API request to interceptors.
+type RequestInfo struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// URL is the request URL with secrets, such as appsecret_proof, removed.
+	URL string
+	// Attempt is the 1-based number of the attempt to send the request.
+	Attempt int
+	// Payload is the JSON body of the request, or nil for requests without
+	// a JSON body such as media uploads and downloads.
+	// The access token is never part of the payload. The payload is reused
+	// after the request completed, copy it to keep it.
+	Payload []byte
+	// RequestID is the ID sent in the RequestIDHeader of the client, if set.
+	RequestID string
+}
+
+// RequestInterceptor is called before every API request is sent. It may modify
+// the request, e.g. to add headers for custom authentication. Returning an error
+// aborts the request with that error.
+type RequestInterceptor func(req *http.Request, info *RequestInfo) error
+
+// ResponseInterceptor is called after every API request with the response,
+// or with a nil response and the error if the request failed. The response body
+// must not be consumed.
+type ResponseInterceptor func(resp *http.Response, info *RequestInfo, err error)
+
+// WithBeforeRequest appends interceptors called before every API request, in order.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithBeforeRequest(func(req *http.Request, info *RequestInfo) error {
+//	    log.Printf("%s %s (attempt %d): %s", info.Method, info.URL, info.Attempt, info.Payload)
+//	    return nil
+//	}))
+func WithBeforeRequest(interceptors ...RequestInterceptor) ClientOption {
+	return func(wa *Client) {
+		wa.BeforeRequest = append(wa.BeforeRequest, interceptors...)
+	}
+}
+
+// WithAfterResponse appends interceptors called after every API request, in order.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithAfterResponse(func(resp *http.Response, info *RequestInfo, err error) {
+//	    if err == nil {
+//	        log.Printf("%s %s: %s", info.Method, info.URL, resp.Status)
+//	    }
+//	}))
+func WithAfterResponse(interceptors ...ResponseInterceptor) ClientOption {
+	return func(wa *Client) {
+		wa.AfterResponse = append(wa.AfterResponse, interceptors...)
+	}
+}
+
+// sanitizeURL removes secrets from the query of u.
+func sanitizeURL(u *url.URL) string {
+	if !u.Query().Has("appsecret_proof") {
+		return u.String()
+	}
+	sanitized := *u
+	query := sanitized.Query()
+	query.Del("appsecret_proof")
+	sanitized.RawQuery = query.Encode()
+	return sanitized.String()
+}
@@ -0,0 +1,300 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDialect is the JSON Schema version of the schemas returned by JSONSchema.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// SchemaTypes are the payload models exported by JSONSchemas, by name: the
+// requests sent to the Cloud API, the webhook notifications, and the responses.
+var SchemaTypes = map[string]any{
+	"Request":                Request{},
+	"WebhookRequest":         WebhookRequest{},
+	"IncomingMessage":        IncomingMessage{},
+	"MessagesResponse":       MessagesResponse{},
+	"MediaResponse":          MediaResponse{},
+	"UploadMediaResponse":    UploadMediaResponse{},
+	"DeleteMediaResponse":    DeleteMediaResponse{},
+	"SuccessResponse":        SuccessResponse{},
+	"CreateFlowResponse":     CreateFlowResponse{},
+	"UpdateFlowJSONResponse": UpdateFlowJSONResponse{},
+	"FlowsResponse":          FlowsResponse{},
+	"APIError":               APIError{},
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	timeType          = reflect.TypeFor[time.Time]()
+)
+
+// JSONSchema returns the JSON Schema of the JSON encoding of the values of
+// the type of v, as produced by this package, e.g. to validate payloads in
+// other services or to generate TypeScript types with the same shape as the
+// Go structs. Named struct types are defined in $defs and referenced by
+// name. Fields without omitempty are required, and fields that may be nil
+// also accept null. Interfaces, such as the parameters of an Action, and
+// types with their own encoding accept any value. The dialect is the one of
+// OpenAPI 3.1, so the definitions can be used as its components too.
+//
+// Example usage:
+//
+//	schema, err := JSONSchema(Request{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.WriteFile("request.schema.json", schema, 0o644)
+func JSONSchema(v any) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("cannot generate the JSON schema of nil")
+	}
+	g := &schemaGenerator{defs: make(map[string]any), names: make(map[string]reflect.Type)}
+	schema := g.schema(t)
+	if t.Name() != "" {
+		schema["title"] = t.Name()
+	}
+	schema["$schema"] = JSONSchemaDialect
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// JSONSchemas returns the JSON Schemas of the SchemaTypes, by name.
+func JSONSchemas() (map[string][]byte, error) {
+	schemas := make(map[string][]byte, len(SchemaTypes))
+	for name, v := range SchemaTypes {
+		schema, err := JSONSchema(v)
+		if err != nil {
+			return nil, fmt.Errorf("generating JSON schema of %s: %w", name, err)
+		}
+		schemas[name] = schema
+	}
+	return schemas, nil
+}
+
+// schemaGenerator builds the schema of a type and the definitions it references.
+type schemaGenerator struct {
+	defs  map[string]any          // defs are the definitions of the named structs, by name.
+	names map[string]reflect.Type // names are the types of the definitions, to detect clashes.
+}
+
+// schema returns the schema of the values of t.
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	case t.Kind() != reflect.Pointer && isOpaque(t):
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.Name()
+		if defined, ok := g.names[name]; ok && defined != t {
+			name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + name
+		}
+		if _, ok := g.names[name]; !ok {
+			// The name is taken before the fields, for recursive types
+			g.names[name] = t
+			g.defs[name] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}
+	}
+	// Interfaces, and the types encoding/json rejects
+	return map[string]any{}
+}
+
+// object returns the schema of the struct type t.
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	g.fields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fields adds the properties of the fields of the struct type t, including
+// the ones of embedded structs without a name, following encoding/json.
+func (g *schemaGenerator) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for _, field := range encodedFields(t) {
+		var schema map[string]any
+		if hasOption(field.options, "string") {
+			schema = map[string]any{"type": "string"}
+		} else {
+			schema = g.schema(field.typ)
+		}
+		omitted := hasOption(field.options, "omitempty") || hasOption(field.options, "omitzero")
+		switch field.typ.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			if !omitted && len(schema) > 0 {
+				schema = map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+			}
+		}
+		properties[field.name] = schema
+		if !omitted {
+			*required = append(*required, field.name)
+		}
+	}
+}
+
+// encodedField is a field of a struct as encoded by encoding/json.
+type encodedField struct {
+	name    string
+	tagged  bool
+	depth   int
+	index   []int
+	typ     reflect.Type
+	options string
+}
+
+// encodedFields returns the fields encoding/json encodes for the struct type t, in
+// order. Fields of embedded structs are promoted breadth first, so a shallower
+// field hides deeper ones with the same name, and fields at the same depth hide
+// each other unless exactly one is tagged. Embedded structs with their own
+// encoding are opaque and contribute no fields.
+func encodedFields(t reflect.Type) []encodedField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []encodedField
+	next := []embedded{{typ: t}}
+	visited := make(map[reflect.Type]bool)
+	for depth := 0; len(next) > 0; depth++ {
+		current := next
+		next = nil
+		// count detects types embedded twice at the same depth, whose fields hide each other
+		count := make(map[reflect.Type]int)
+		for _, e := range current {
+			count[e.typ]++
+		}
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := range e.typ.NumField() {
+				field := e.typ.Field(i)
+				tag := field.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				fieldType := field.Type
+				if field.Anonymous {
+					if fieldType.Kind() == reflect.Pointer {
+						fieldType = fieldType.Elem()
+					}
+					if !field.IsExported() && fieldType.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !field.IsExported() {
+					continue
+				}
+				name, options, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), e.index...), i)
+				if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+					if !isOpaque(fieldType) {
+						next = append(next, embedded{typ: fieldType, index: index})
+					}
+					continue
+				}
+				f := encodedField{name: name, tagged: name != "", depth: depth, index: index, typ: field.Type, options: options}
+				if f.name == "" {
+					f.name = field.Name
+				}
+				fields = append(fields, f)
+				if count[e.typ] > 1 {
+					fields = append(fields, f)
+				}
+			}
+		}
+	}
+
+	byName := make(map[string][]encodedField)
+	for _, f := range fields {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	var dominant []encodedField
+	for _, candidates := range byName {
+		if f, ok := dominantField(candidates); ok {
+			dominant = append(dominant, f)
+		}
+	}
+	slices.SortFunc(dominant, func(a, b encodedField) int { return slices.Compare(a.index, b.index) })
+	return dominant
+}
+
+// dominantField returns the field encoding/json encodes among the fields with
+// the same name, if any.
+func dominantField(fields []encodedField) (encodedField, bool) {
+	depth := fields[0].depth
+	for _, f := range fields {
+		depth = min(depth, f.depth)
+	}
+	var shallowest, tagged []encodedField
+	for _, f := range fields {
+		if f.depth == depth {
+			shallowest = append(shallowest, f)
+			if f.tagged {
+				tagged = append(tagged, f)
+			}
+		}
+	}
+	switch {
+	case len(shallowest) == 1:
+		return shallowest[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	}
+	return encodedField{}, false
+}
+
+// isOpaque reports whether the values of t have their own JSON encoding.
+func isOpaque(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)
+}
+
+// hasOption reports whether the comma-separated options of a struct tag include option.
+func hasOption(options, option string) bool {
+	for o := range strings.SplitSeq(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type schemaInner struct {
+	Name  string `json:"name"`
+	Inner string `json:"inner"`
+	Tie   string
+}
+
+type schemaOther struct {
+	Tie string
+}
+
+type schemaTagged struct {
+	Tie string `json:"Tie"`
+}
+
+type schemaOpaque struct {
+	Secret string `json:"secret"`
+}
+
+func (schemaOpaque) MarshalJSON() ([]byte, error) { return []byte(`"opaque"`), nil }
+
+type schemaOtherOpaque struct {
+	Hidden string `json:"hidden"`
+}
+
+func (schemaOtherOpaque) MarshalJSON() ([]byte, error) { return []byte(`"opaque"`), nil }
+
+func TestJSONSchemaEmbeddedFields(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		v    any
+		want map[string]string // want are the types of the properties, by name
+	}{
+		{"shallowest wins", struct {
+			schemaInner
+			Name int `json:"name"`
+		}{}, map[string]string{"name": "integer", "inner": "string", "Tie": "string"}},
+		{"ambiguous", struct {
+			schemaInner
+			schemaOther
+		}{}, map[string]string{"name": "string", "inner": "string"}},
+		{"tagged wins", struct {
+			schemaInner
+			schemaTagged
+		}{}, map[string]string{"name": "string", "inner": "string", "Tie": "string"}},
+		{"opaque", struct {
+			schemaOpaque
+			schemaOtherOpaque
+			Text string `json:"text"`
+		}{}, map[string]string{"text": "string"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := JSONSchema(tc.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var schema struct {
+				Properties map[string]struct{ Type string } `json:"properties"`
+			}
+			if err := json.Unmarshal(data, &schema); err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for name, property := range schema.Properties {
+				got[name] = property.Type
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("JSONSchema(%T) properties = %v, want %v", tc.v, got, tc.want)
+			}
+		})
+	}
+}
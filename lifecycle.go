@@ -0,0 +1,111 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned by components that stopped accepting work.
+var ErrShuttingDown = errors.New("shutting down")
+
+// Lifecycle is implemented by the components that work in the background or
+// handle requests asynchronously, such as Scheduler and Webhook, so services
+// can stop them without dropping messages.
+type Lifecycle interface {
+	// Start starts the component. Work started by it uses ctx.
+	Start(ctx context.Context) error
+	// Shutdown stops accepting new work and waits for the work in flight to
+	// finish, or for ctx to be done, in which case it returns the context error.
+	Shutdown(ctx context.Context) error
+}
+
+// ShutdownAll shuts the components down in order, so components feeding
+// others should come first, and returns the errors of all of them.
+//
+// Example usage:
+//
+//	scheduler.Start(ctx)
+//	...
+//	<-signals
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	server.Shutdown(ctx)
+//	if err := ShutdownAll(ctx, webhook, scheduler); err != nil {
+//	    log.Printf("Shutdown: %v", err)
+//	}
+func ShutdownAll(ctx context.Context, components ...Lifecycle) error {
+	var errs []error
+	for _, component := range components {
+		if err := component.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// inflight counts work in flight and lets shutdowns wait for it. The zero value is ready to use.
+type inflight struct {
+	mu      sync.Mutex
+	n       int
+	closing bool
+	drained chan struct{}
+}
+
+// acquire counts new work, unless the shutdown started.
+func (f *inflight) acquire() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closing {
+		return false
+	}
+	f.n++
+	return true
+}
+
+// add counts work that continues work already counted, even during shutdown.
+func (f *inflight) add() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n++
+}
+
+// release marks counted work as done.
+func (f *inflight) release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n--
+	if f.n == 0 && f.drained != nil {
+		close(f.drained)
+		f.drained = nil
+	}
+}
+
+// isClosing reports whether the shutdown started.
+func (f *inflight) isClosing() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closing
+}
+
+// close stops new work from being acquired and waits for the work in flight until ctx is done.
+func (f *inflight) close(ctx context.Context) error {
+	f.mu.Lock()
+	f.closing = true
+	if f.n == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	if f.drained == nil {
+		f.drained = make(chan struct{})
+	}
+	drained := f.drained
+	f.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
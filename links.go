@@ -0,0 +1,151 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// urlRegexp matches the URLs WhatsApp clients hyperlink: absolute http(s)
+// URLs and host names starting with www.
+var urlRegexp = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// urlTrailingPunctuation are characters that end a sentence rather than a URL.
+const urlTrailingPunctuation = `.,;:!?'")]}*_~`
+
+// FindURLs returns the URLs in text, in order of appearance. Trailing
+// punctuation, such as the period ending a sentence, is not part of a URL.
+//
+// Example usage:
+//
+//	urls := FindURLs("Track your order at https://example.com/orders/42.")
+//	// urls is []string{"https://example.com/orders/42"}
+func FindURLs(text string) []string {
+	var urls []string
+	for _, loc := range findURLs(text) {
+		urls = append(urls, text[loc[0]:loc[1]])
+	}
+	return urls
+}
+
+// ContainsURL reports whether text contains a URL, see FindURLs.
+func ContainsURL(text string) bool {
+	return len(findURLs(text)) > 0
+}
+
+// findURLs returns the start and end offsets of the URLs in text.
+func findURLs(text string) [][2]int {
+	var locs [][2]int
+	for _, loc := range urlRegexp.FindAllStringIndex(text, -1) {
+		url := strings.TrimRight(text[loc[0]:loc[1]], urlTrailingPunctuation)
+		// A closing parenthesis is kept if the URL contains the opening one,
+		// as in Wikipedia links
+		if strings.Count(url, "(") > strings.Count(url, ")") && strings.HasPrefix(text[loc[0]+len(url):], ")") {
+			url += ")"
+		}
+		if strings.HasSuffix(url, "://") || strings.EqualFold(url, "www.") {
+			continue
+		}
+		locs = append(locs, [2]int{loc[0], loc[0] + len(url)})
+	}
+	return locs
+}
+
+// URLShortener replaces a URL with a shorter or tracked one, e.g. by calling
+// a link shortening service or adding campaign parameters.
+type URLShortener interface {
+	// ShortenURL returns the URL to send instead of url. Returning url unchanged
+	// keeps it. URLs without a scheme, like www.example.com, are passed as is.
+	ShortenURL(ctx context.Context, url string) (string, error)
+}
+
+// URLShortenerFunc is a function implementing URLShortener.
+type URLShortenerFunc func(ctx context.Context, url string) (string, error)
+
+// ShortenURL calls f(ctx, url).
+func (f URLShortenerFunc) ShortenURL(ctx context.Context, url string) (string, error) {
+	return f(ctx, url)
+}
+
+// ShortenURLs returns text with every URL replaced by the one returned by
+// shortener. A URL appearing several times is only shortened once.
+//
+// Example usage:
+//
+//	tracker := URLShortenerFunc(func(ctx context.Context, url string) (string, error) {
+//	    return shortLinks.Create(ctx, url, "utm_campaign=spring")
+//	})
+//	body, err := ShortenURLs(ctx, body, tracker)
+func ShortenURLs(ctx context.Context, text string, shortener URLShortener) (string, error) {
+	locs := findURLs(text)
+	if len(locs) == 0 {
+		return text, nil
+	}
+
+	var (
+		out       strings.Builder
+		shortened = make(map[string]string, len(locs))
+		last      int
+	)
+	for _, loc := range locs {
+		url := text[loc[0]:loc[1]]
+		short, ok := shortened[url]
+		if !ok {
+			var err error
+			if short, err = shortener.ShortenURL(ctx, url); err != nil {
+				return "", fmt.Errorf("shortening %s: %w", url, err)
+			}
+			shortened[url] = short
+		}
+		out.WriteString(text[last:loc[0]])
+		out.WriteString(short)
+		last = loc[1]
+	}
+	out.WriteString(text[last:])
+	return out.String(), nil
+}
+
+// WithURLShortener makes SendText replace the URLs of text bodies with the
+// ones returned by shortener before sending. If shortening fails, the message
+// is not sent.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithURLShortener(tracker), WithAutoPreviewURL())
+func WithURLShortener(shortener URLShortener) ClientOption {
+	return func(wa *Client) {
+		wa.URLShortener = shortener
+	}
+}
+
+// WithAutoPreviewURL makes SendText request a link preview for every text
+// body containing a URL, so messages with links render consistently whether
+// or not the caller set SendTextParams.PreviewURL. Bodies without URLs are
+// sent unchanged.
+func WithAutoPreviewURL() ClientOption {
+	return func(wa *Client) {
+		wa.AutoPreviewURL = true
+	}
+}
+
+// prepareLinks returns params with its URLs shortened and PreviewURL set
+// according to the client configuration. params is not modified.
+func (wa *Client) prepareLinks(ctx context.Context, params *SendTextParams) (*SendTextParams, error) {
+	if wa.URLShortener == nil && !wa.AutoPreviewURL {
+		return params, nil
+	}
+
+	prepared := *params
+	if wa.URLShortener != nil {
+		body, err := ShortenURLs(ctx, params.Body, wa.URLShortener)
+		if err != nil {
+			return nil, err
+		}
+		prepared.Body = body
+	}
+	if wa.AutoPreviewURL && !prepared.PreviewURL {
+		prepared.PreviewURL = ContainsURL(prepared.Body)
+	}
+	return &prepared, nil
+}
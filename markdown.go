@@ -0,0 +1,61 @@
+package whatsapp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownRules rewrite Markdown to WhatsApp formatting, in order. Bullets
+// are rewritten before emphasis, so a "* " bullet isn't taken for a marker,
+// and single-asterisk italics before bold, whose result uses single asterisks.
+var markdownRules = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?m)^(\s*)[-*+]\s+`), "$1• "},
+	{regexp.MustCompile(`(?m)^#{1,6}\s+(.+?)\s*#*$`), "**$1**"},
+	{regexp.MustCompile(`(^|[^*\w])\*([^*\s](?:[^*]*[^*\s])?)\*([^*\w]|$)`), "${1}_${2}_$3"},
+	{regexp.MustCompile(`\*\*([^*\n]+)\*\*`), "*$1*"},
+	{regexp.MustCompile(`(^|\W)__([^_\n]+)__(\W|$)`), "$1*$2*$3"},
+	{regexp.MustCompile(`~~([^~\n]+)~~`), "~$1~"},
+	{regexp.MustCompile(`!?\[([^\]\n]+)\]\((\S+?)\)`), "$1 ($2)"},
+}
+
+// FromMarkdown converts text from Markdown, as produced by language models,
+// to WhatsApp formatting: **bold** and __bold__ become *bold*, *italic*
+// becomes _italic_, ~~strikethrough~~ becomes ~strikethrough~, headings
+// become bold lines, list bullets become "•", and links are written as
+// "text (url)". Code blocks and inline code are kept, as WhatsApp renders
+// them too, and their content isn't converted.
+//
+// Example usage:
+//
+//	text := FromMarkdown("## Your order\n- **2x** pizza\n- [track it](https://example.com/o/1)")
+//	// text is "*Your order*\n• *2x* pizza\n• track it (https://example.com/o/1)"
+func FromMarkdown(text string) string {
+	var out strings.Builder
+	// Odd parts are code blocks
+	for i, part := range strings.Split(text, "```") {
+		if i > 0 {
+			out.WriteString("```")
+		}
+		if i%2 == 1 {
+			out.WriteString(part)
+			continue
+		}
+		for j, span := range strings.Split(part, "`") {
+			if j > 0 {
+				out.WriteString("`")
+			}
+			if j%2 == 1 {
+				out.WriteString(span)
+				continue
+			}
+			for _, rule := range markdownRules {
+				span = rule.re.ReplaceAllString(span, rule.replacement)
+			}
+			out.WriteString(span)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,296 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrMediaURLExpired is returned by the download methods when the server answers
+// the media URL with 403 Forbidden or 404 Not Found, which usually means it is
+// older than 5 minutes. A fresh URL can be obtained with GetMedia. A rejected
+// access token, 401 Unauthorized, isn't reported as an expired URL.
+var ErrMediaURLExpired = errors.New("media URL expired")
+
+// WithMediaURLRefresh enables or disables the automatic refresh of expired media
+// URLs in GetAndDownloadMedia and GetAndDownloadMediaBytes. It is enabled by default.
+func WithMediaURLRefresh(enabled bool) ClientOption {
+	return func(wa *Client) {
+		wa.DisableMediaURLRefresh = !enabled
+	}
+}
+
+// MediaMismatchError is returned when downloaded media doesn't match the metadata
+// obtained from GetMedia, e.g. because the download was truncated or corrupted.
+type MediaMismatchError struct {
+	// Field is the mismatching metadata field: "file_size" or "sha256".
+	Field string
+	// Expected is the value from the media metadata.
+	Expected string
+	// Actual is the value observed while downloading.
+	Actual string
+}
+
+// Error implements the error interface.
+func (e *MediaMismatchError) Error() string {
+	return fmt.Sprintf("media %s mismatch: want %s, got %s", e.Field, e.Expected, e.Actual)
+}
+
+// DownloadMediaVerified downloads the media described by mediaInfo and verifies it
+// against the metadata while streaming. The Content-Length header is checked against
+// mediaInfo.FileSize before the body is returned, and the size and SHA256 of the
+// content are checked once the returned reader reaches EOF. On mismatch the reader
+// returns a *MediaMismatchError instead of io.EOF, so callers must not trust the
+// content until they have read it to the end without error.
+//
+// Note: The caller is responsible for closing the returned ReadCloser.
+//
+// Example usage:
+//
+//	mediaInfo, err := client.GetMedia(ctx, mediaID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	reader, err := client.DownloadMediaVerified(ctx, mediaInfo)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer reader.Close()
+//
+//	if _, err := io.Copy(destination, reader); err != nil {
+//	    var mismatch *MediaMismatchError
+//	    if errors.As(err, &mismatch) {
+//	        log.Printf("Corrupted download: %v", mismatch)
+//	    }
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#download-media
+func (wa *Client) DownloadMediaVerified(ctx context.Context, mediaInfo *MediaResponse) (io.ReadCloser, error) {
+	if mediaInfo == nil {
+		return nil, fmt.Errorf("media info cannot be nil")
+	}
+
+	resp, err := wa.downloadMedia(ctx, mediaInfo.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if mediaInfo.FileSize > 0 && resp.ContentLength >= 0 && resp.ContentLength != mediaInfo.FileSize {
+		resp.Body.Close()
+		return nil, &MediaMismatchError{
+			Field:    "file_size",
+			Expected: strconv.FormatInt(mediaInfo.FileSize, 10),
+			Actual:   strconv.FormatInt(resp.ContentLength, 10),
+		}
+	}
+
+	return &verifyingReader{
+		ReadCloser: resp.Body,
+		size:       mediaInfo.FileSize,
+		sha256:     mediaInfo.SHA256,
+		hash:       sha256.New(),
+	}, nil
+}
+
+// verifyingReader hashes and counts the content passing through it
+// and validates both against the expected values at EOF.
+type verifyingReader struct {
+	io.ReadCloser
+	size   int64
+	sha256 string
+	hash   hash.Hash
+	read   int64
+}
+
+func (vr *verifyingReader) Read(p []byte) (int, error) {
+	n, err := vr.ReadCloser.Read(p)
+	vr.hash.Write(p[:n])
+	vr.read += int64(n)
+
+	if vr.size > 0 && vr.read > vr.size {
+		return n, vr.sizeMismatch()
+	}
+	if err != io.EOF {
+		return n, err
+	}
+
+	if vr.size > 0 && vr.read != vr.size {
+		return n, vr.sizeMismatch()
+	}
+	if actual := hex.EncodeToString(vr.hash.Sum(nil)); vr.sha256 != "" && actual != vr.sha256 {
+		return n, &MediaMismatchError{Field: "sha256", Expected: vr.sha256, Actual: actual}
+	}
+	return n, io.EOF
+}
+
+func (vr *verifyingReader) sizeMismatch() error {
+	return &MediaMismatchError{
+		Field:    "file_size",
+		Expected: strconv.FormatInt(vr.size, 10),
+		Actual:   strconv.FormatInt(vr.read, 10),
+	}
+}
+
+// ProgressFunc is called with the total number of bytes written so far
+// while media is being streamed to its destination.
+type ProgressFunc func(written int64)
+
+// DownloadMediaToWriter streams the media content from mediaURL into w without
+// buffering it in memory. If progress is not nil, it is called after every write
+// with the total number of bytes written so far. It returns the number of bytes written.
+//
+// Example usage:
+//
+//	written, err := client.DownloadMediaToWriter(ctx, mediaInfo.URL, w, func(n int64) {
+//	    log.Printf("Downloaded %d of %d bytes", n, mediaInfo.FileSize)
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#download-media
+func (wa *Client) DownloadMediaToWriter(ctx context.Context, mediaURL string, w io.Writer, progress ProgressFunc) (int64, error) {
+	reader, err := wa.DownloadMedia(ctx, mediaURL)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	if progress != nil {
+		w = &progressWriter{Writer: w, progress: progress}
+	}
+
+	written, err := io.Copy(w, reader)
+	if err != nil {
+		return written, fmt.Errorf("failed to write media content: %w", err)
+	}
+	return written, nil
+}
+
+// DownloadMediaToFile streams the media content from mediaURL into the file at path,
+// creating or replacing it. The content is written to a temporary file in the same
+// directory, which is renamed to path once the download succeeded, so a failed
+// download leaves an existing file untouched. A replaced file keeps its permissions.
+// If progress is not nil, it is called with the total number of bytes written so far.
+//
+// Example usage:
+//
+//	_, err := client.DownloadMediaToFile(ctx, mediaInfo.URL, "/tmp/report.pdf", nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#download-media
+func (wa *Client) DownloadMediaToFile(ctx context.Context, mediaURL, path string, progress ProgressFunc) (int64, error) {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	written, err := wa.DownloadMediaToWriter(ctx, mediaURL, file, progress)
+	if err == nil {
+		if chmodErr := file.Chmod(mode); chmodErr != nil {
+			err = fmt.Errorf("failed to set file permissions: %w", chmodErr)
+		}
+	}
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close file: %w", closeErr)
+	}
+	if err == nil {
+		if renameErr := os.Rename(file.Name(), path); renameErr != nil {
+			err = fmt.Errorf("failed to replace file: %w", renameErr)
+		}
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return written, err
+	}
+	return written, nil
+}
+
+// progressWriter reports the running total of written bytes after every write.
+type progressWriter struct {
+	io.Writer
+	progress ProgressFunc
+	written  int64
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.Writer.Write(p)
+	pw.written += int64(n)
+	pw.progress(pw.written)
+	return n, err
+}
+
+// mimeTypesByExtension maps file extensions to the supported MIME types. It takes
+// precedence over content sniffing, which can't tell e.g. DOCX from any other ZIP file.
+var mimeTypesByExtension = map[string]SupportedMimeType{
+	".jpg":  MimeTypeImageJPEG,
+	".jpeg": MimeTypeImageJPEG,
+	".png":  MimeTypeImagePNG,
+	".webp": MimeTypeImageWebP,
+	".aac":  MimeTypeAudioAAC,
+	".m4a":  MimeTypeAudioMP4,
+	".mp3":  MimeTypeAudioMPEG,
+	".amr":  MimeTypeAudioAMR,
+	".ogg":  MimeTypeAudioOGG,
+	".opus": MimeTypeAudioOGG,
+	".mp4":  MimeTypeVideoMP4,
+	".3gp":  MimeTypeVideo3GPP,
+	".pdf":  MimeTypeDocumentPDF,
+	".docx": MimeTypeDocumentDOCX,
+	".pptx": MimeTypeDocumentPPTX,
+	".xlsx": MimeTypeDocumentXLSX,
+	".txt":  MimeTypeDocumentText,
+}
+
+// DetectMimeType detects the MIME type of the media in r, first by the extension
+// of filename and then by sniffing the content with http.DetectContentType.
+// The detected type is validated with ValidateMimeType. Since sniffing consumes
+// the beginning of r, the returned reader must be used in place of r afterwards.
+//
+// Example usage:
+//
+//	mimeType, file, err := DetectMimeType(file, "photo.jpg")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func DetectMimeType(r io.Reader, filename string) (string, io.Reader, error) {
+	if mimeType, ok := mimeTypesByExtension[strings.ToLower(filepath.Ext(filename))]; ok {
+		return string(mimeType), r, nil
+	}
+
+	// http.DetectContentType considers at most 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", r, fmt.Errorf("failed to read media content: %w", err)
+	}
+	head = head[:n]
+	r = io.MultiReader(bytes.NewReader(head), r)
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "", r, fmt.Errorf("failed to detect MIME type: %w", err)
+	}
+	if err := ValidateMimeType(mimeType); err != nil {
+		return "", r, err
+	}
+	return mimeType, r, nil
+}
@@ -0,0 +1,163 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloadMediaToFile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+		wantErr bool
+	}{
+		{"success", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "new content")
+		}, "new content", false},
+		{"error status", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}, "old content", true},
+		{"truncated body", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "100")
+			io.WriteString(w, "new")
+		}, "old content", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, tc.handler)
+			dir := t.TempDir()
+			path := filepath.Join(dir, "media.bin")
+			if err := os.WriteFile(path, []byte("old content"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := client.DownloadMediaToFile(context.Background(), client.BaseURL+"/media", path, nil)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("DownloadMediaToFile() = %v, want error %v", err, tc.wantErr)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tc.want {
+				t.Errorf("file content = %q, want %q", content, tc.want)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o600 {
+				t.Errorf("file mode = %v, want the one of the replaced file", info.Mode().Perm())
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("directory has %d entries, want the temporary file removed", len(entries))
+			}
+		})
+	}
+}
+
+func TestDownloadMediaExpiredURL(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusForbidden, true},
+		{http.StatusNotFound, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusInternalServerError, false},
+	} {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			})
+			_, err := client.DownloadMediaBytes(context.Background(), client.BaseURL+"/media")
+			if err == nil {
+				t.Fatal("DownloadMediaBytes() succeeded, want an error")
+			}
+			if got := errors.Is(err, ErrMediaURLExpired); got != tc.want {
+				t.Errorf("errors.Is(%v, ErrMediaURLExpired) = %v, want %v", err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetAndDownloadMediaRefreshFailure(t *testing.T) {
+	var infoRequests atomic.Int32
+	var client *Client
+	client = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/download") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if infoRequests.Add(1) > 1 {
+			http.Error(w, `{"error":{"message":"unavailable","code":2}}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"media-1","url":%q,"mime_type":"image/jpeg"}`, client.BaseURL+"/download")
+	})
+
+	info, _, err := client.GetAndDownloadMedia(context.Background(), "media-1")
+	if err == nil || !strings.Contains(err.Error(), "refresh") {
+		t.Fatalf("GetAndDownloadMedia() error = %v, want the error of the refresh", err)
+	}
+	if info == nil || info.ID != "media-1" {
+		t.Errorf("GetAndDownloadMedia() info = %+v, want the media information fetched first", info)
+	}
+}
+
+func TestGetAndDownloadMediaRetrySharesRequestID(t *testing.T) {
+	var downloads atomic.Int32
+	var (
+		mu       sync.Mutex
+		attempts []int
+		ids      []string
+	)
+	var client *Client
+	client = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ids = append(ids, r.Header.Get(DefaultRequestIDHeader))
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/download") {
+			if downloads.Add(1) == 1 {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			io.WriteString(w, "content")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"media-1","url":%q,"mime_type":"image/jpeg"}`, client.BaseURL+"/download")
+	}, WithRequestIDHeader(""), WithBeforeRequest(func(req *http.Request, info *RequestInfo) error {
+		if strings.HasSuffix(req.URL.Path, "/download") {
+			mu.Lock()
+			attempts = append(attempts, info.Attempt)
+			mu.Unlock()
+		}
+		return nil
+	}))
+
+	_, content, err := client.GetAndDownloadMediaBytes(context.Background(), "media-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "content" {
+		t.Errorf("content = %q, want the content of the retried download", content)
+	}
+	if !slices.Equal(attempts, []int{1, 2}) {
+		t.Errorf("download attempts = %v, want [1 2]", attempts)
+	}
+	if len(ids) != 4 || ids[0] == "" || slices.ContainsFunc(ids, func(id string) bool { return id != ids[0] }) {
+		t.Errorf("request IDs = %q, want the same ID for the 4 requests", ids)
+	}
+}
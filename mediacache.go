@@ -0,0 +1,191 @@
+package whatsapp
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMediaCacheSize is the default number of entries of a MemoryMediaCache.
+	DefaultMediaCacheSize = 1024
+	// MediaIDLifetime is how long the media ID of uploaded media stays valid.
+	// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#upload-media
+	MediaIDLifetime = 30 * 24 * time.Hour
+)
+
+// mediaErrorCodes are the error codes of messages that referred to an invalid
+// or expired media ID. The parameter error codes are reported for any invalid
+// parameter, so they only count if the error mentions the media ID.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+var (
+	mediaErrorCodes     = []int{131053}
+	parameterErrorCodes = []int{100, 131009}
+)
+
+// CachedMedia is an uploaded media object in a MediaCache.
+type CachedMedia struct {
+	// ID is the media ID returned by the upload.
+	ID string
+	// UploadedAt is the time of the upload.
+	UploadedAt time.Time
+}
+
+// Expired reports whether the media ID is older than MediaIDLifetime.
+func (m CachedMedia) Expired() bool {
+	return time.Since(m.UploadedAt) >= MediaIDLifetime
+}
+
+// MediaCache maps the content of uploaded media to its media ID, so that
+// repeated uploads of the same content reuse the media ID instead.
+// Keys combine the SHA-256 of the content with its MIME type.
+type MediaCache interface {
+	// Get returns the cached media for key, if any.
+	Get(ctx context.Context, key string) (CachedMedia, bool, error)
+	// Put stores the media for key.
+	Put(ctx context.Context, key string, media CachedMedia) error
+}
+
+// WithMediaCache makes UploadMedia, and the methods built on it, reuse the
+// media IDs of content that was uploaded before.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithMediaCache(NewMemoryMediaCache(0)))
+//
+//	// Only the first send uploads the brochure
+//	for _, recipient := range recipients {
+//	    f, err := os.Open("brochure.pdf")
+//	    ...
+//	    _, err = client.SendMediaContent(ctx, recipient, f, "brochure.pdf", "Our new offers")
+//	    f.Close()
+//	}
+func WithMediaCache(cache MediaCache) ClientOption {
+	return func(wa *Client) {
+		wa.MediaCache = cache
+	}
+}
+
+// mediaCacheKey returns the cache key of content with the given SHA-256 sum and MIME type.
+func mediaCacheKey(sum []byte, mimeType string) string {
+	return fmt.Sprintf("%x|%s", sum, mimeType)
+}
+
+// SendMediaContent uploads the content, or reuses its media ID if it is in the
+// media cache, and sends it as an image, audio or document message depending on
+// its MIME type. The caption is ignored for audio. If a cached media ID is
+// rejected, e.g. because it expired earlier than expected, the content is
+// uploaded again and the message is resent once.
+func (wa *Client) SendMediaContent(ctx context.Context, recipient string, content io.Reader, filename, caption string) (*MessagesResponse, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media content: %w", err)
+	}
+	params, err := NewUploadMediaParams(bytes.NewReader(data), filename, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload params: %w", err)
+	}
+	if strings.HasPrefix(params.MimeType, "video/") {
+		return nil, fmt.Errorf("sending %s content is not supported", params.MimeType)
+	}
+
+	uploaded, cached, err := wa.uploadMedia(ctx, params, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload media: %w", err)
+	}
+	response, err := wa.sendMedia(ctx, recipient, params.MimeType, uploaded.ID, filename, caption)
+	if err == nil || !cached || !isMediaError(err, uploaded.ID) {
+		return response, err
+	}
+
+	params.File = bytes.NewReader(data)
+	if uploaded, _, err = wa.uploadMedia(ctx, params, false); err != nil {
+		return nil, fmt.Errorf("failed to upload media again: %w", err)
+	}
+	return wa.sendMedia(ctx, recipient, params.MimeType, uploaded.ID, filename, caption)
+}
+
+// sendMedia sends uploaded media as an image, audio or document message depending on mimeType.
+func (wa *Client) sendMedia(ctx context.Context, recipient, mimeType, mediaID, filename, caption string) (*MessagesResponse, error) {
+	switch {
+	case strings.HasPrefix(mimeType, "image/") && mimeType != string(MimeTypeImageWebP):
+		return wa.SendImage(ctx, recipient, &SendImageParams{ID: mediaID, Caption: caption})
+	case strings.HasPrefix(mimeType, "audio/"):
+		return wa.SendAudio(ctx, recipient, &SendAudioParams{ID: mediaID})
+	default:
+		return wa.SendDocument(ctx, recipient, &SendDocumentParams{ID: mediaID, Caption: caption, Filename: filename})
+	}
+}
+
+// isMediaError reports whether err may have been caused by the media ID being invalid.
+func isMediaError(err error, mediaID string) bool {
+	var graphErr *GraphError
+	if !errors.As(err, &graphErr) {
+		return false
+	}
+	if slices.Contains(mediaErrorCodes, graphErr.Code) {
+		return true
+	}
+	return slices.Contains(parameterErrorCodes, graphErr.Code) &&
+		(strings.Contains(graphErr.Message, mediaID) || strings.Contains(graphErr.Details, mediaID))
+}
+
+// MemoryMediaCache is a MediaCache keeping the most recently used entries in memory.
+// It is safe for concurrent use.
+type MemoryMediaCache struct {
+	size    int
+	mu      sync.Mutex
+	order   *list.List // order lists the keys, most recently used first.
+	entries map[string]*list.Element
+}
+
+type mediaCacheEntry struct {
+	key   string
+	media CachedMedia
+}
+
+// NewMemoryMediaCache creates a cache keeping up to size entries. Zero size means DefaultMediaCacheSize.
+func NewMemoryMediaCache(size int) *MemoryMediaCache {
+	return &MemoryMediaCache{
+		size:    orDefault(size, DefaultMediaCacheSize),
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get implements MediaCache.
+func (c *MemoryMediaCache) Get(ctx context.Context, key string) (CachedMedia, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return CachedMedia{}, false, nil
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*mediaCacheEntry).media, true, nil
+}
+
+// Put implements MediaCache.
+func (c *MemoryMediaCache) Put(ctx context.Context, key string, media CachedMedia) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*mediaCacheEntry).media = media
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(&mediaCacheEntry{key: key, media: media})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*mediaCacheEntry).key)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsMediaError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"media upload error", &GraphError{Code: 131053, Message: "Media upload error"}, true},
+		{"wrapped", fmt.Errorf("sending: %w", &GraphError{Code: 131053}), true},
+		{"parameter naming the media", &GraphError{Code: 100, Message: "Invalid parameter", Details: "Param image['id'] is not a valid media ID: media-1"}, true},
+		{"invalid value naming the media", &GraphError{Code: 131009, Message: "Parameter value is not valid: media-1"}, true},
+		{"other parameter", &GraphError{Code: 100, Message: "Invalid parameter", Details: "Param to must be a phone number"}, false},
+		{"other invalid value", &GraphError{Code: 131009, Message: "Parameter value is not valid"}, false},
+		{"other code", &GraphError{Code: 131047, Message: "Re-engagement message: media-1"}, false},
+		{"not a graph error", errors.New("media-1"), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isMediaError(tc.err, "media-1"); got != tc.want {
+				t.Errorf("isMediaError(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
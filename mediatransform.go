@@ -0,0 +1,233 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // PNG images are decoded by image.Decode.
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultImageQuality is the JPEG quality an ImageResizer starts encoding
+	// with, if its Quality is not set.
+	DefaultImageQuality = 85
+	// minImageQuality is the lowest JPEG quality an ImageResizer encodes with
+	// before scaling the image down further.
+	minImageQuality = 45
+	// minImageDimension is the size of the longer side below which an
+	// ImageResizer gives up scaling an image down.
+	minImageDimension = 64
+	// DefaultMaxImagePixels is the number of pixels above which an
+	// ImageResizer or Thumbnailer without MaxPixels rejects images. Decoding
+	// takes at least 4 bytes per pixel, so small files declaring huge sizes
+	// would otherwise exhaust the memory.
+	DefaultMaxImagePixels = 50_000_000
+)
+
+// ErrImageTooLarge is returned for images with more pixels than allowed, see
+// DefaultMaxImagePixels.
+var ErrImageTooLarge = errors.New("image has too many pixels")
+
+// MediaTransformer transforms media before it is uploaded, e.g. to compress
+// images or transcode videos that exceed the limits of WhatsApp. It returns
+// params unchanged for media it doesn't handle. The returned parameters may
+// have another file, file name and MIME type.
+type MediaTransformer interface {
+	TransformMedia(ctx context.Context, params *UploadMediaParams) (*UploadMediaParams, error)
+}
+
+// MediaTransformerFunc is a function type that implements the MediaTransformer interface.
+type MediaTransformerFunc func(ctx context.Context, params *UploadMediaParams) (*UploadMediaParams, error)
+
+// TransformMedia calls the function with the given parameters.
+func (f MediaTransformerFunc) TransformMedia(ctx context.Context, params *UploadMediaParams) (*UploadMediaParams, error) {
+	return f(ctx, params)
+}
+
+// WithMediaTransformers makes the client pass every upload through the
+// transformers, in order, after its MIME type is detected. Media cached by a
+// MediaCache is looked up by the transformed content.
+//
+// Example usage, with videos transcoded by ffmpeg:
+//
+//	transcode := MediaTransformerFunc(func(ctx context.Context, params *UploadMediaParams) (*UploadMediaParams, error) {
+//	    if !strings.HasPrefix(params.MimeType, "video/") {
+//	        return params, nil
+//	    }
+//	    var out bytes.Buffer
+//	    cmd := exec.CommandContext(ctx, "ffmpeg", "-i", "pipe:0", "-c:v", "libx264", "-fs", "15M",
+//	        "-movflags", "frag_keyframe+empty_moov", "-f", "mp4", "pipe:1")
+//	    cmd.Stdin, cmd.Stdout = params.File, &out
+//	    if err := cmd.Run(); err != nil {
+//	        return nil, err
+//	    }
+//	    return NewUploadMediaParams(&out, "video.mp4", string(MimeTypeVideoMP4))
+//	})
+//	client := NewClient(token, phoneNumberID, WithMediaTransformers(&ImageResizer{MaxDimension: 2048}, transcode))
+func WithMediaTransformers(transformers ...MediaTransformer) ClientOption {
+	return func(wa *Client) {
+		wa.MediaTransformers = append(wa.MediaTransformers, transformers...)
+	}
+}
+
+// transformMedia passes params through the media transformers of the client.
+func (wa *Client) transformMedia(ctx context.Context, params *UploadMediaParams) (*UploadMediaParams, error) {
+	for _, transformer := range wa.MediaTransformers {
+		transformed, err := transformer.TransformMedia(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("transforming %s: %w", params.Filename, err)
+		}
+		params = transformed
+	}
+	return params, nil
+}
+
+// ImageResizer is a MediaTransformer that makes JPEG and PNG images fit the
+// limits of WhatsApp, using the standard library only. Images within the
+// limits are uploaded unchanged. Other images are scaled down to
+// MaxDimension, and re-encoded as JPEG with decreasing quality, and further
+// scaled down, until they fit MaxBytes. Transparent areas become white.
+type ImageResizer struct {
+	// MaxBytes is the size images must fit, MaxImageSize if zero.
+	MaxBytes int
+	// MaxDimension, if positive, is the maximum width and height of images.
+	MaxDimension int
+	// Quality is the initial JPEG quality of re-encoded images,
+	// DefaultImageQuality if zero.
+	Quality int
+	// MaxPixels is the number of pixels above which images are rejected with
+	// ErrImageTooLarge before they are decoded, DefaultMaxImagePixels if zero.
+	MaxPixels int
+}
+
+// TransformMedia implements MediaTransformer.
+func (ir *ImageResizer) TransformMedia(ctx context.Context, params *UploadMediaParams) (*UploadMediaParams, error) {
+	if params.MimeType != string(MimeTypeImageJPEG) && params.MimeType != string(MimeTypeImagePNG) {
+		return params, nil
+	}
+	content, err := io.ReadAll(params.File)
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+	maxBytes := orDefault(ir.MaxBytes, MaxImageSize)
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	if err := checkImagePixels(config.Width, config.Height, ir.MaxPixels); err != nil {
+		return nil, err
+	}
+	if len(content) <= maxBytes && ir.fits(config.Width, config.Height) {
+		unchanged := *params
+		unchanged.File = bytes.NewReader(content)
+		return &unchanged, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	width, height := config.Width, config.Height
+	if !ir.fits(width, height) {
+		width, height = scaleToFit(width, height, ir.MaxDimension)
+	}
+	for max(width, height) >= minImageDimension {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		scaled := resizeImage(img, width, height)
+		for quality := orDefault(ir.Quality, DefaultImageQuality); quality >= minImageQuality; quality -= 10 {
+			var encoded bytes.Buffer
+			if err := jpeg.Encode(&encoded, scaled, &jpeg.Options{Quality: quality}); err != nil {
+				return nil, fmt.Errorf("encoding image: %w", err)
+			}
+			if encoded.Len() <= maxBytes {
+				resized := *params
+				resized.File = &encoded
+				resized.Filename = strings.TrimSuffix(params.Filename, filepath.Ext(params.Filename)) + ".jpg"
+				resized.MimeType = string(MimeTypeImageJPEG)
+				return &resized, nil
+			}
+		}
+		width, height = width*3/4, height*3/4
+	}
+	return nil, fmt.Errorf("image can't be compressed to %d bytes", maxBytes)
+}
+
+// decodeLimitedImage decodes the image read from r, once its header shows it
+// has at most maxPixels pixels, DefaultMaxImagePixels if zero.
+func decodeLimitedImage(r io.Reader, maxPixels int) (image.Image, error) {
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImagePixels(config.Width, config.Height, maxPixels); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(io.MultiReader(&header, r))
+	return img, err
+}
+
+// checkImagePixels returns ErrImageTooLarge if an image of the size has more
+// than maxPixels pixels, DefaultMaxImagePixels if zero.
+func checkImagePixels(width, height, maxPixels int) error {
+	maxPixels = orDefault(maxPixels, DefaultMaxImagePixels)
+	if int64(width)*int64(height) > int64(maxPixels) {
+		return fmt.Errorf("%w: %dx%d is more than %d", ErrImageTooLarge, width, height, maxPixels)
+	}
+	return nil
+}
+
+// fits reports whether an image of the size is within MaxDimension.
+func (ir *ImageResizer) fits(width, height int) bool {
+	return ir.MaxDimension <= 0 || max(width, height) <= ir.MaxDimension
+}
+
+// scaleToFit returns the size of an image scaled down so that its longer side
+// is limit, keeping the aspect ratio.
+func scaleToFit(width, height, limit int) (int, int) {
+	if width >= height {
+		return limit, max(height*limit/width, 1)
+	}
+	return max(width*limit/height, 1), limit
+}
+
+// resizeImage scales img down to width x height on a white background,
+// averaging the source pixels of every target pixel.
+func resizeImage(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	for y := range height {
+		y0, y1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		for x := range width {
+			x0, x1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+			var r, g, b, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += int(row[4*sx])
+					g += int(row[4*sx+1])
+					b += int(row[4*sx+2])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), 0xff
+		}
+	}
+	return dst
+}
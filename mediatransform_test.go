@@ -0,0 +1,29 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestImageResizerRejectsHugeImages(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		resizer *ImageResizer
+		image   []byte
+	}{
+		{"declared size", &ImageResizer{}, declaredSizePNG(t, 50000, 50000)},
+		{"max pixels", &ImageResizer{MaxPixels: 100}, declaredSizePNG(t, 20, 10)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := NewUploadMediaParams(bytes.NewReader(tc.image), "huge.png", string(MimeTypeImagePNG))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tc.resizer.TransformMedia(context.Background(), params); !errors.Is(err, ErrImageTooLarge) {
+				t.Errorf("TransformMedia = %v, want %v", err, ErrImageTooLarge)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrMessageNotFound is returned by a MessageStore for unknown message IDs.
+var ErrMessageNotFound = errors.New("message not found")
+
+// StoredMessage is an outbound message tracked by a MessageStore.
+type StoredMessage struct {
+	// ID is the WhatsApp message ID (WAMID) returned by the API.
+	ID string
+	// Request is the request the message was sent with.
+	Request *Request
+	// Sealed is the encrypted request of messages stored by an
+	// EncryptedMessageStore, whose Request is nil.
+	Sealed []byte
+	// SentAt is the time the API accepted the message.
+	SentAt time.Time
+	// Status is the latest delivery status reported by the status webhook,
+	// or empty until the first status arrives.
+	Status MessageStatus
+	// UpdatedAt is the time of the latest status.
+	UpdatedAt time.Time
+	// Errors are the errors reported with a failed status.
+	Errors []WebhookError
+}
+
+// MessageStore correlates outbound messages with their delivery statuses.
+// The client saves every accepted message, and the webhook updates it
+// whenever a status notification arrives.
+//
+// Example usage:
+//
+//	store := NewMemoryMessageStore()
+//	client := NewClient(token, phoneNumberID, WithMessageStore(store))
+//	webhook := NewWebhook(verifyToken, appSecret, handler)
+//	webhook.MessageStore = store
+//
+//	response, err := client.SendText(ctx, "1234567890", &SendTextParams{Body: "Hello!"})
+//	...
+//	message, err := store.Get(ctx, response.Messages[0].ID)
+//	if err == nil {
+//	    log.Printf("Message is %s", message.Status)
+//	}
+type MessageStore interface {
+	// Save records a message accepted by the API.
+	Save(ctx context.Context, message *StoredMessage) error
+	// UpdateStatus applies a status notification to the message it refers to.
+	// It may return ErrMessageNotFound if the message is unknown, which the
+	// webhook ignores, or keep the status for a while in case the message is
+	// saved later, as MemoryMessageStore does.
+	UpdateStatus(ctx context.Context, status *WebhookStatus) error
+	// Get returns the message with the given ID, or ErrMessageNotFound.
+	Get(ctx context.Context, id string) (*StoredMessage, error)
+}
+
+// WithMessageStore records every message accepted by the API in store.
+func WithMessageStore(store MessageStore) ClientOption {
+	return func(wa *Client) {
+		wa.MessageStore = store
+	}
+}
+
+// storeMessages saves the messages of a successful send in the message store.
+func (wa *Client) storeMessages(ctx context.Context, request *Request, response *MessagesResponse) error {
+	now := time.Now()
+	for _, message := range response.Messages {
+		if err := wa.MessageStore.Save(ctx, &StoredMessage{ID: message.ID, Request: request, SentAt: now}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bounds of the statuses of unknown messages a MemoryMessageStore keeps.
+const (
+	// orphanStatusTTL is how long the status of an unknown message is kept
+	// for the message to be saved, which covers a status webhook overtaking
+	// the send response.
+	orphanStatusTTL = time.Minute
+	// maxOrphanStatuses is the number of statuses of unknown messages above
+	// which further ones are ignored.
+	maxOrphanStatuses = 1024
+)
+
+// MemoryMessageStore is a MessageStore keeping messages in memory.
+// It is safe for concurrent use. Messages are kept until deleted.
+type MemoryMessageStore struct {
+	mu       sync.Mutex
+	messages map[string]*StoredMessage
+	orphans  map[string]*orphanStatus // orphans are the statuses of unknown messages by message ID.
+}
+
+// orphanStatus is the status of a message that wasn't saved yet.
+type orphanStatus struct {
+	message    StoredMessage
+	receivedAt time.Time
+}
+
+// NewMemoryMessageStore creates an empty in-memory message store.
+func NewMemoryMessageStore() *MemoryMessageStore {
+	return &MemoryMessageStore{
+		messages: make(map[string]*StoredMessage),
+		orphans:  make(map[string]*orphanStatus),
+	}
+}
+
+// Save implements MessageStore.
+func (s *MemoryMessageStore) Save(ctx context.Context, message *StoredMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *message
+	existing, ok := s.messages[message.ID]
+	if orphan, isOrphan := s.orphans[message.ID]; isOrphan {
+		// The status webhook overtook the send response
+		delete(s.orphans, message.ID)
+		if !ok && time.Since(orphan.receivedAt) < orphanStatusTTL {
+			existing, ok = &orphan.message, true
+		}
+	}
+	if ok && stored.Status == "" {
+		stored.Status, stored.UpdatedAt, stored.Errors = existing.Status, existing.UpdatedAt, existing.Errors
+	}
+	s.messages[message.ID] = &stored
+	return nil
+}
+
+// UpdateStatus implements MessageStore. Statuses arriving out of order never
+// move a message back, e.g. from read to delivered. The status webhook may
+// overtake the send response, so the status of an unknown message is kept
+// for a minute, for Save to apply it, and is otherwise dropped. Statuses of
+// messages sent by other clients are thus only kept briefly, and if there
+// are too many at once, further ones are ignored.
+func (s *MemoryMessageStore) UpdateStatus(ctx context.Context, status *WebhookStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message, ok := s.messages[status.ID]
+	if !ok {
+		message = s.orphanMessage(status.ID)
+		if message == nil {
+			return nil
+		}
+	}
+	if statusRank(status.Status) < statusRank(message.Status) {
+		return nil
+	}
+	message.Status = status.Status
+	message.UpdatedAt = statusTime(status)
+	message.Errors = status.Errors
+	return nil
+}
+
+// orphanMessage returns the message keeping the statuses of the unknown
+// message with the ID, or nil if there are too many of them.
+func (s *MemoryMessageStore) orphanMessage(id string) *StoredMessage {
+	now := time.Now()
+	if orphan, ok := s.orphans[id]; ok && now.Sub(orphan.receivedAt) < orphanStatusTTL {
+		return &orphan.message
+	}
+	if len(s.orphans) >= maxOrphanStatuses {
+		for id, orphan := range s.orphans {
+			if now.Sub(orphan.receivedAt) >= orphanStatusTTL {
+				delete(s.orphans, id)
+			}
+		}
+		if len(s.orphans) >= maxOrphanStatuses {
+			return nil
+		}
+	}
+	orphan := &orphanStatus{message: StoredMessage{ID: id}, receivedAt: now}
+	s.orphans[id] = orphan
+	return &orphan.message
+}
+
+// Get implements MessageStore.
+func (s *MemoryMessageStore) Get(ctx context.Context, id string) (*StoredMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message, ok := s.messages[id]
+	if !ok {
+		return nil, ErrMessageNotFound
+	}
+	stored := *message
+	return &stored, nil
+}
+
+// Delete removes the message with the given ID from the store.
+func (s *MemoryMessageStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, id)
+}
+
+// statusRank orders message statuses by delivery progress.
+func statusRank(status MessageStatus) int {
+	switch status {
+	case MessageStatusSent:
+		return 1
+	case MessageStatusDelivered:
+		return 2
+	case MessageStatusRead:
+		return 3
+	case MessageStatusFailed:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// statusTime returns the time of a status notification, or the current time
+// if its timestamp can't be parsed.
+func statusTime(status *WebhookStatus) time.Time {
+	if t, ok := parseUnixTime(status.Timestamp); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// updateMessageStore applies the statuses of the request to the message store.
+// Statuses of unknown messages, e.g. sent by other clients, are left to the
+// store, which may ignore them with ErrMessageNotFound or keep them for a while.
+func (wh *Webhook) updateMessageStore(ctx context.Context, request *WebhookRequest) error {
+	for _, entry := range request.Entry {
+		for _, change := range entry.Changes {
+			for i := range change.Value.Statuses {
+				err := wh.MessageStore.UpdateStatus(ctx, &change.Value.Statuses[i])
+				if err != nil && !errors.Is(err, ErrMessageNotFound) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMemoryMessageStoreStatusBeforeSave(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryMessageStore()
+	status := &WebhookStatus{ID: "wamid.1", Status: MessageStatusDelivered, Timestamp: "1700000000"}
+	if err := store.UpdateStatus(ctx, status); err != nil {
+		t.Fatalf("UpdateStatus of an unsaved message: %v", err)
+	}
+
+	request := testTextRequest("hello")
+	if err := store.Save(ctx, &StoredMessage{ID: "wamid.1", Request: request}); err != nil {
+		t.Fatal(err)
+	}
+	message, err := store.Get(ctx, "wamid.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != MessageStatusDelivered || message.UpdatedAt.Unix() != 1700000000 {
+		t.Errorf("status = %s at %v, want the one received before Save", message.Status, message.UpdatedAt)
+	}
+	if message.Request != request {
+		t.Errorf("Request = %+v, want the saved one", message.Request)
+	}
+}
+
+func TestMemoryMessageStoreStatusOrder(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryMessageStore()
+	store.Save(ctx, &StoredMessage{ID: "wamid.1", Request: testTextRequest("hello")})
+	store.UpdateStatus(ctx, &WebhookStatus{ID: "wamid.1", Status: MessageStatusRead})
+	store.UpdateStatus(ctx, &WebhookStatus{ID: "wamid.1", Status: MessageStatusDelivered})
+
+	message, err := store.Get(ctx, "wamid.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != MessageStatusRead {
+		t.Errorf("status = %s, want %s", message.Status, MessageStatusRead)
+	}
+}
+
+func TestMemoryMessageStoreOrphanStatuses(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryMessageStore()
+	for i := range maxOrphanStatuses + 10 {
+		if err := store.UpdateStatus(ctx, &WebhookStatus{ID: fmt.Sprint("wamid.other-", i), Status: MessageStatusSent}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(store.orphans) != maxOrphanStatuses || len(store.messages) != 0 {
+		t.Errorf("store keeps %d statuses and %d messages, want at most %d statuses", len(store.orphans), len(store.messages), maxOrphanStatuses)
+	}
+	if _, err := store.Get(ctx, "wamid.other-0"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Get of a message only known by its status = %v, want %v", err, ErrMessageNotFound)
+	}
+
+	// Expired statuses are dropped, making room for new ones
+	for _, orphan := range store.orphans {
+		orphan.receivedAt = orphan.receivedAt.Add(-orphanStatusTTL)
+	}
+	store.UpdateStatus(ctx, &WebhookStatus{ID: "wamid.1", Status: MessageStatusDelivered})
+	if len(store.orphans) != 1 {
+		t.Errorf("store keeps %d statuses, want the expired ones dropped", len(store.orphans))
+	}
+	store.Save(ctx, &StoredMessage{ID: "wamid.1", Request: testTextRequest("hello")})
+	store.Save(ctx, &StoredMessage{ID: "wamid.other-0", Request: testTextRequest("hello")})
+	for id, want := range map[string]MessageStatus{"wamid.1": MessageStatusDelivered, "wamid.other-0": ""} {
+		message, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if message.Status != want {
+			t.Errorf("status of %s = %q, want %q", id, message.Status, want)
+		}
+	}
+	if len(store.orphans) != 0 {
+		t.Errorf("store keeps %d statuses after the messages were saved, want none", len(store.orphans))
+	}
+}
@@ -1,6 +1,8 @@
 package whatsapp
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 )
@@ -23,6 +25,10 @@ const (
 	// This is typically used for sending messages to a single user.
 	// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/messages#recipient_type
 	RecipientTypeIndividual RecipientType = "individual"
+	// RecipientTypeGroup represents a group recipient, with the group ID as the recipient.
+	// Group messaging is being rolled out gradually, see WithGroups.
+	// https://developers.facebook.com/docs/whatsapp/cloud-api/groups
+	RecipientTypeGroup RecipientType = "group"
 )
 
 // MessageType represents the type of message being sent.
@@ -56,6 +62,10 @@ const (
 	MessageTypeSystem MessageType = "system"
 	// MessageTypeReaction represents a reaction message.
 	MessageTypeReaction MessageType = "reaction"
+	// MessageTypeTemplate represents a template message.
+	MessageTypeTemplate MessageType = "template"
+	// MessageTypeEphemeral represents a disappearing or view-once message wrapping another message.
+	MessageTypeEphemeral MessageType = "ephemeral"
 	// MessageTypeUnknown represents an unknown message type.
 	MessageTypeUnknown MessageType = "unknown"
 	// MessageTypeUnsupported represents an unsupported message type.
@@ -133,13 +143,39 @@ const (
 // Request represents a request to send a message via the WhatsApp Business API.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/reference/messages
 type Request struct {
-	MessagingProduct MessagingProduct `json:"messaging_product"`
-	RecipientType    RecipientType    `json:"recipient_type"`
-	To               string           `json:"to"`
-	Type             MessageType      `json:"type"`
-	Text             *SendTextParams  `json:"text,omitempty"`
-	Image            *SendImageParams `json:"image,omitempty"`
-	Interactive      *Interactive     `json:"interactive,omitempty"`
+	MessagingProduct MessagingProduct    `json:"messaging_product"`
+	RecipientType    RecipientType       `json:"recipient_type"`
+	To               string              `json:"to"`
+	Type             MessageType         `json:"type"`
+	Context          *RequestContext     `json:"context,omitempty"`
+	Text             *SendTextParams     `json:"text,omitempty"`
+	Image            *SendImageParams    `json:"image,omitempty"`
+	Audio            *SendAudioParams    `json:"audio,omitempty"`
+	Document         *SendDocumentParams `json:"document,omitempty"`
+	Sticker          *SendStickerParams  `json:"sticker,omitempty"`
+	Interactive      *Interactive        `json:"interactive,omitempty"`
+	Reaction         *SendReactionParams `json:"reaction,omitempty"`
+	Template         *SendTemplateParams `json:"template,omitempty"`
+	// BizOpaqueCallbackData is an arbitrary string, e.g. a campaign tag, that
+	// is returned in the status notifications of the message. Maximum 512
+	// characters. See ContextWithCallbackData.
+	BizOpaqueCallbackData string `json:"biz_opaque_callback_data,omitempty"`
+}
+
+// RequestContext references the message a request replies to. The reply is
+// rendered as a contextual bubble quoting the original message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/guides/send-messages#contextual-replies
+type RequestContext struct {
+	MessageID string `json:"message_id"`
+}
+
+// SendReactionParams contains parameters for sending a reaction message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/reaction-messages
+type SendReactionParams struct {
+	// MessageID is the ID of the message to react to.
+	MessageID string `json:"message_id"`
+	// Emoji is the reaction emoji. An empty emoji removes the reaction.
+	Emoji string `json:"emoji"`
 }
 
 // Interactive represents the interactive object for interactive messages.
@@ -197,6 +233,51 @@ type Action struct {
 	Sections   []ListSection    `json:"sections,omitempty"`
 }
 
+// UnmarshalJSON decodes an action, selecting the concrete type of Parameters
+// by the action name, or by the shape of the parameters if the name is missing.
+func (a *Action) UnmarshalJSON(data []byte) error {
+	type plainAction Action
+	var raw struct {
+		plainAction
+		Parameters json.RawMessage `json:"parameters,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*a = Action(raw.plainAction)
+	if len(raw.Parameters) == 0 || string(raw.Parameters) == "null" {
+		return nil
+	}
+
+	name := a.Name
+	if name == "" {
+		var shape map[string]json.RawMessage
+		if err := json.Unmarshal(raw.Parameters, &shape); err != nil {
+			return fmt.Errorf("decoding action parameters: %w", err)
+		}
+		if _, ok := shape["flow_id"]; ok {
+			name = (*FlowParameters)(nil).ActionType()
+		} else if _, ok := shape["url"]; ok {
+			name = (*CTAURLParameters)(nil).ActionType()
+		}
+	}
+
+	var params ActionParameters
+	switch name {
+	case (*FlowParameters)(nil).ActionType():
+		params = &FlowParameters{}
+	case (*CTAURLParameters)(nil).ActionType():
+		params = &CTAURLParameters{}
+	default:
+		return fmt.Errorf("unknown action parameters for action %q", a.Name)
+	}
+	if err := json.Unmarshal(raw.Parameters, params); err != nil {
+		return fmt.Errorf("decoding %s action parameters: %w", name, err)
+	}
+	a.Parameters = params
+	return nil
+}
+
 // FlowParameters represents the parameters for a flow action.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/messages/interactive-flow-messages
 type FlowParameters struct {
@@ -303,8 +384,11 @@ type SendTextParams struct {
 	// to render a link preview of any URL in the body text string.
 	PreviewURL bool `json:"preview_url,omitempty"`
 	// Body text. Required. URLs are automatically hyperlinked.
-	// Maximum 1024 characters.
+	// Maximum 4096 characters.
 	Body string `json:"body"`
+	// AutoSplit makes SendText split a body longer than MaxTextBodyLength
+	// into several messages instead of sending it as is.
+	AutoSplit bool `json:"-"`
 }
 
 // SendImageParams contains parameters for sending an image message.
@@ -335,7 +419,7 @@ func (sip *SendImageParams) Validate() error {
 	if sip.ID != "" && sip.Link != "" {
 		return fmt.Errorf("only one of ID or Link should be provided")
 	}
-	if len(sip.Caption) > 1024 {
+	if TextLength(sip.Caption) > MaxCaptionLength {
 		return fmt.Errorf("caption exceeds maximum length of 1024 characters")
 	}
 	return nil
@@ -375,6 +459,110 @@ func NewSendImageParamsWithLink(link string, caption ...string) (*SendImageParam
 	return params, nil
 }
 
+// SendDocumentParams contains parameters for sending a document message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/document-messages
+type SendDocumentParams struct {
+	// ID is the media object ID. Required when not using link.
+	// Only one of ID or Link should be provided.
+	ID string `json:"id,omitempty"`
+	// Link is the URL of the document. Required when not using ID.
+	// Only one of ID or Link should be provided.
+	// The document must be 100MB or smaller.
+	Link string `json:"link,omitempty"`
+	// Caption is optional text that appears below the document.
+	// Maximum 1024 characters.
+	Caption string `json:"caption,omitempty"`
+	// Filename is the document filename, with extension. The WhatsApp client
+	// uses the extension to pick the file icon.
+	Filename string `json:"filename,omitempty"`
+}
+
+// Validate validates the document parameters
+func (sdp *SendDocumentParams) Validate() error {
+	if sdp == nil {
+		return fmt.Errorf("document parameters cannot be nil")
+	}
+	if sdp.ID == "" && sdp.Link == "" {
+		return fmt.Errorf("either ID or Link must be provided")
+	}
+	if sdp.ID != "" && sdp.Link != "" {
+		return fmt.Errorf("only one of ID or Link should be provided")
+	}
+	if TextLength(sdp.Caption) > MaxCaptionLength {
+		return fmt.Errorf("caption exceeds maximum length of 1024 characters")
+	}
+	return nil
+}
+
+// SendAudioParams contains parameters for sending an audio message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/audio-messages
+type SendAudioParams struct {
+	// ID is the media object ID. Required when not using link.
+	// Only one of ID or Link should be provided.
+	ID string `json:"id,omitempty"`
+	// Link is the URL of the audio file. Required when not using ID.
+	// Only one of ID or Link should be provided.
+	// The audio must be 16MB or smaller.
+	Link string `json:"link,omitempty"`
+	// Voice marks the audio as a voice note, rendered as a push-to-talk bubble.
+	// Voice notes must be OGG files encoded with the Opus codec.
+	Voice bool `json:"voice,omitempty"`
+}
+
+// Validate validates the audio parameters
+func (sap *SendAudioParams) Validate() error {
+	if sap == nil {
+		return fmt.Errorf("audio parameters cannot be nil")
+	}
+	if sap.ID == "" && sap.Link == "" {
+		return fmt.Errorf("either ID or Link must be provided")
+	}
+	if sap.ID != "" && sap.Link != "" {
+		return fmt.Errorf("only one of ID or Link should be provided")
+	}
+	return nil
+}
+
+// ValidateVoiceNote checks that head, the beginning of an audio file, is an OGG
+// container carrying Opus audio, which is the only format rendered as a voice note.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#supported-media-types
+func ValidateVoiceNote(head []byte) error {
+	if !bytes.HasPrefix(head, []byte("OggS")) {
+		return fmt.Errorf("voice note must be an OGG file")
+	}
+	if !bytes.Contains(head, []byte("OpusHead")) {
+		return fmt.Errorf("voice note must be encoded with the Opus codec")
+	}
+	return nil
+}
+
+// SendStickerParams contains parameters for sending a sticker message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/sticker-messages
+type SendStickerParams struct {
+	// ID is the media object ID. Required when not using link.
+	// Only one of ID or Link should be provided.
+	ID string `json:"id,omitempty"`
+	// Link is the URL of the sticker. Required when not using ID.
+	// Only one of ID or Link should be provided.
+	// Stickers must be 512x512 WebP images, 100KB or smaller for static
+	// and 500KB or smaller for animated stickers.
+	Link string `json:"link,omitempty"`
+}
+
+// Validate validates the sticker parameters
+func (ssp *SendStickerParams) Validate() error {
+	if ssp == nil {
+		return fmt.Errorf("sticker parameters cannot be nil")
+	}
+	if ssp.ID == "" && ssp.Link == "" {
+		return fmt.Errorf("either ID or Link must be provided")
+	}
+	if ssp.ID != "" && ssp.Link != "" {
+		return fmt.Errorf("only one of ID or Link should be provided")
+	}
+	return nil
+}
+
 // SendInteractiveFlowParams contains parameters for sending an interactive flow message.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/messages/interactive-flow-messages
 type SendInteractiveFlowParams struct {
@@ -536,7 +724,37 @@ type WebhookValue struct {
 	Contacts         []WebhookContact `json:"contacts,omitempty"`
 	Messages         []WebhookMessage `json:"messages,omitempty"`
 	Statuses         []WebhookStatus  `json:"statuses,omitempty"`
+	Calls            []WebhookCall    `json:"calls,omitempty"`
 	Errors           []WebhookError   `json:"errors,omitempty"`
+
+	// Decision is the decision of account_review_update notifications.
+	Decision AccountReviewDecision `json:"decision,omitempty"`
+
+	// Event, PhoneNumber, BanInfo, ViolationInfo and RestrictionInfo are the
+	// fields of account_update notifications. Which of them are set depends on the event.
+	Event           AccountUpdateEvent    `json:"event,omitempty"`
+	PhoneNumber     string                `json:"phone_number,omitempty"`
+	BanInfo         *WebhookBanInfo       `json:"ban_info,omitempty"`
+	ViolationInfo   *WebhookViolationInfo `json:"violation_info,omitempty"`
+	RestrictionInfo []WebhookRestriction  `json:"restriction_info,omitempty"`
+
+	// EntityType, EntityID, AlertSeverity, AlertStatus, AlertType and
+	// AlertDescription are the fields of account_alerts notifications.
+	EntityType       string        `json:"entity_type,omitempty"`
+	EntityID         string        `json:"entity_id,omitempty"`
+	AlertSeverity    AlertSeverity `json:"alert_severity,omitempty"`
+	AlertStatus      string        `json:"alert_status,omitempty"`
+	AlertType        string        `json:"alert_type,omitempty"`
+	AlertDescription string        `json:"alert_description,omitempty"`
+
+	// DisplayPhoneNumber, CurrentLimit, OldLimit and
+	// MaxDailyConversationPerPhone are the fields of
+	// phone_number_quality_update notifications, whose Event is a
+	// QualityUpdateEvent, see QualityEvent.
+	DisplayPhoneNumber           string         `json:"display_phone_number,omitempty"`
+	CurrentLimit                 MessagingLimit `json:"current_limit,omitempty"`
+	OldLimit                     MessagingLimit `json:"old_limit,omitempty"`
+	MaxDailyConversationPerPhone int            `json:"max_daily_conversation_per_phone,omitempty"`
 }
 
 // WebhookMetadata contains metadata about the webhook notification.
@@ -563,6 +781,7 @@ type WebhookProfile struct {
 // https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/payload-examples
 type WebhookMessage struct {
 	From        string                     `json:"from"`
+	GroupID     string                     `json:"group_id,omitempty"`
 	ID          string                     `json:"id"`
 	Timestamp   string                     `json:"timestamp"`
 	Type        MessageType                `json:"type"`
@@ -581,9 +800,18 @@ type WebhookMessage struct {
 	System      *WebhookMessageSystem      `json:"system,omitempty"`
 	Reaction    *WebhookMessageReaction    `json:"reaction,omitempty"`
 	Referral    *WebhookMessageReferral    `json:"referral,omitempty"`
+	Ephemeral   *WebhookMessage            `json:"ephemeral,omitempty"`
+	Unsupported *WebhookMessageUnsupported `json:"unsupported,omitempty"`
 	Errors      []WebhookError             `json:"errors,omitempty"`
 }
 
+// WebhookMessageUnsupported describes the original type of an unsupported message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/payload-examples
+type WebhookMessageUnsupported struct {
+	// Type is the type of the message in WhatsApp, e.g. "poll" or "edit".
+	Type string `json:"type"`
+}
+
 // WebhookMessageContext represents the context of a message in webhook notifications.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/payload-examples
 type WebhookMessageContext struct {
@@ -614,6 +842,10 @@ type WebhookMessageMedia struct {
 	ID       string `json:"id"`
 	MimeType string `json:"mime_type"`
 	SHA256   string `json:"sha256"`
+	// ViewOnce is set for media that can be viewed only once.
+	ViewOnce bool `json:"view_once,omitempty"`
+	// Voice is set for audio recorded as a voice note.
+	Voice bool `json:"voice,omitempty"`
 }
 
 // WebhookMessageLocation represents a location message in webhook notifications.
@@ -860,13 +1092,16 @@ const (
 // WebhookStatus represents a message status in webhook notifications.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/payload-examples
 type WebhookStatus struct {
-	ID           string                     `json:"id"`
-	Status       MessageStatus              `json:"status"`
-	Timestamp    string                     `json:"timestamp"`
-	RecipientID  string                     `json:"recipient_id"`
-	Conversation *WebhookStatusConversation `json:"conversation,omitempty"`
-	Pricing      *WebhookStatusPricing      `json:"pricing,omitempty"`
-	Errors       []WebhookError             `json:"errors,omitempty"`
+	ID            string                     `json:"id"`
+	Status        MessageStatus              `json:"status"`
+	Timestamp     string                     `json:"timestamp"`
+	RecipientID   string                     `json:"recipient_id"`
+	RecipientType RecipientType              `json:"recipient_type,omitempty"`
+	Conversation  *WebhookStatusConversation `json:"conversation,omitempty"`
+	Pricing       *WebhookStatusPricing      `json:"pricing,omitempty"`
+	Errors        []WebhookError             `json:"errors,omitempty"`
+	// BizOpaqueCallbackData is the callback data the message was sent with.
+	BizOpaqueCallbackData string `json:"biz_opaque_callback_data,omitempty"`
 }
 
 // ConversationOriginType represents the origin type of a conversation.
@@ -896,13 +1131,32 @@ type WebhookConversationOrigin struct {
 	Type ConversationOriginType `json:"type"`
 }
 
-// PricingModel represents the pricing model in webhook notifications.
-// https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/payload-examples
+// PricingModel represents the pricing model in webhook notifications. Meta
+// introduces new models over time, so values other than the constants below
+// must be expected.
+// https://developers.facebook.com/docs/whatsapp/pricing
 type PricingModel string
 
 const (
 	// PricingModelCBP represents the CBP (Conversation-Based Pricing) model.
 	PricingModelCBP PricingModel = "CBP"
+	// PricingModelPMP represents the PMP (Per-Message Pricing) model, where
+	// every delivered template message is charged.
+	PricingModelPMP PricingModel = "PMP"
+)
+
+// PricingType represents whether a message is charged under per-message pricing.
+// https://developers.facebook.com/docs/whatsapp/pricing
+type PricingType string
+
+const (
+	// PricingTypeRegular represents a message charged at the rate of its category.
+	PricingTypeRegular PricingType = "regular"
+	// PricingTypeFreeCustomerService represents a free message sent within the customer service window.
+	PricingTypeFreeCustomerService PricingType = "free_customer_service"
+	// PricingTypeFreeEntryPoint represents a free message sent within the free entry point window,
+	// opened by ads that click to WhatsApp or the call to action of a Facebook Page.
+	PricingTypeFreeEntryPoint PricingType = "free_entry_point"
 )
 
 // PricingCategory represents the pricing category in webhook notifications.
@@ -916,6 +1170,17 @@ const (
 	PricingCategoryUserInitiated PricingCategory = "user_initiated"
 	// PricingCategoryBusinessInitiated represents business initiated pricing.
 	PricingCategoryBusinessInitiated PricingCategory = "business_initiated"
+	// PricingCategoryMarketing represents marketing template pricing.
+	PricingCategoryMarketing PricingCategory = "marketing"
+	// PricingCategoryUtility represents utility template pricing.
+	PricingCategoryUtility PricingCategory = "utility"
+	// PricingCategoryAuthentication represents authentication template pricing.
+	PricingCategoryAuthentication PricingCategory = "authentication"
+	// PricingCategoryAuthenticationInternational represents authentication
+	// template pricing for recipients in countries with international rates.
+	PricingCategoryAuthenticationInternational PricingCategory = "authentication_international"
+	// PricingCategoryService represents service pricing, for replies within the customer service window.
+	PricingCategoryService PricingCategory = "service"
 )
 
 // WebhookStatusPricing represents pricing information in status notifications.
@@ -924,6 +1189,13 @@ type WebhookStatusPricing struct {
 	Billable     bool            `json:"billable"`
 	PricingModel PricingModel    `json:"pricing_model"`
 	Category     PricingCategory `json:"category"`
+	// Type tells whether the message is charged, under per-message pricing.
+	Type PricingType `json:"type,omitempty"`
+}
+
+// PerMessage reports whether the pricing is per message rather than per conversation.
+func (p *WebhookStatusPricing) PerMessage() bool {
+	return p.PricingModel == PricingModelPMP
 }
 
 // WebhookError represents an error in webhook notifications.
@@ -991,10 +1263,11 @@ type MediaResponse struct {
 // https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#retrieve-media-url
 type MediaError struct {
 	Error struct {
-		Message   string `json:"message"`
-		Type      string `json:"type"`
-		Code      int    `json:"code"`
-		ErrorData struct {
+		Message      string `json:"message"`
+		Type         string `json:"type"`
+		Code         int    `json:"code"`
+		ErrorSubcode int    `json:"error_subcode,omitempty"`
+		ErrorData    struct {
 			Details string `json:"details"`
 		} `json:"error_data"`
 		FBTraceID string `json:"fbtrace_id"`
@@ -1006,10 +1279,11 @@ type MediaError struct {
 // https://developers.facebook.com/docs/whatsapp/cloud-api/reference/messages#errors
 type APIError struct {
 	Error struct {
-		Message   string `json:"message"`
-		Type      string `json:"type"`
-		Code      int    `json:"code"`
-		ErrorData struct {
+		Message      string `json:"message"`
+		Type         string `json:"type"`
+		Code         int    `json:"code"`
+		ErrorSubcode int    `json:"error_subcode,omitempty"`
+		ErrorData    struct {
 			Details string `json:"details"`
 		} `json:"error_data"`
 		FBTraceID string `json:"fbtrace_id"`
@@ -1057,6 +1331,7 @@ const (
 	MimeTypeDocumentDOCX SupportedMimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
 	MimeTypeDocumentPPTX SupportedMimeType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
 	MimeTypeDocumentXLSX SupportedMimeType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MimeTypeDocumentText SupportedMimeType = "text/plain"
 
 	// Sticker MIME types
 	MimeTypeStickerWebP SupportedMimeType = "image/webp"
@@ -1083,7 +1358,8 @@ func ValidateMediaSize(mimeType string, size int64) error {
 			return fmt.Errorf("video size %d exceeds maximum allowed size %d", size, MaxVideoSize)
 		}
 	case mimeType == string(MimeTypeDocumentPDF) || mimeType == string(MimeTypeDocumentDOCX) ||
-		mimeType == string(MimeTypeDocumentPPTX) || mimeType == string(MimeTypeDocumentXLSX):
+		mimeType == string(MimeTypeDocumentPPTX) || mimeType == string(MimeTypeDocumentXLSX) ||
+		mimeType == string(MimeTypeDocumentText):
 		if size > MaxDocumentSize {
 			return fmt.Errorf("document size %d exceeds maximum allowed size %d", size, MaxDocumentSize)
 		}
@@ -1119,6 +1395,7 @@ func ValidateMimeType(mimeType string) error {
 		MimeTypeDocumentDOCX,
 		MimeTypeDocumentPPTX,
 		MimeTypeDocumentXLSX,
+		MimeTypeDocumentText,
 		// Sticker types
 		MimeTypeStickerWebP,
 	}
@@ -1139,7 +1416,8 @@ type UploadMediaParams struct {
 	File io.Reader `json:"-"`
 	// Filename is the name of the file being uploaded
 	Filename string `json:"-"`
-	// MimeType is the MIME type of the media file
+	// MimeType is the MIME type of the media file.
+	// UploadMedia detects it with DetectMimeType if it is empty.
 	MimeType string `json:"-"`
 	// MessagingProduct must be set to "whatsapp"
 	MessagingProduct MessagingProduct `json:"messaging_product"`
@@ -1167,7 +1445,15 @@ func (ump *UploadMediaParams) Validate() error {
 
 // NewUploadMediaParams creates a new UploadMediaParams instance with validation.
 // This is a convenience constructor that ensures all required fields are provided.
+// If mimeType is empty, it is detected with DetectMimeType.
 func NewUploadMediaParams(file io.Reader, filename, mimeType string) (*UploadMediaParams, error) {
+	if mimeType == "" && file != nil {
+		var err error
+		if mimeType, file, err = DetectMimeType(file, filename); err != nil {
+			return nil, err
+		}
+	}
+
 	params := &UploadMediaParams{
 		File:             file,
 		Filename:         filename,
@@ -1283,6 +1569,7 @@ func ExtractMediaID(message *WebhookMessage) string {
 	if message == nil {
 		return ""
 	}
+	message = message.Unwrap()
 
 	switch message.Type {
 	case MessageTypeImage:
@@ -1310,6 +1597,137 @@ func ExtractMediaID(message *WebhookMessage) string {
 	return ""
 }
 
+// ExtractText extracts the text the user sees in a webhook message, whatever
+// its type: the body of a text message, the caption of an image, video or
+// document, the text of a template quick reply button, the title of an
+// interactive reply, the name (or else the address) of a location, or the
+// note of an order. This is a convenience function for text processing
+// pipelines that don't care about the message type.
+// Returns empty string if the message doesn't contain text.
+//
+// Example usage:
+//
+//	if text := ExtractText(webhookMessage); text != "" {
+//	    intent := classifier.Classify(ctx, text)
+//	    // Route the message by intent...
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/payload-examples
+func ExtractText(message *WebhookMessage) string {
+	if message == nil {
+		return ""
+	}
+	message = message.Unwrap()
+
+	switch message.Type {
+	case MessageTypeText:
+		if message.Text != nil {
+			return message.Text.Body
+		}
+	case MessageTypeImage:
+		if message.Image != nil {
+			return message.Image.Caption
+		}
+	case MessageTypeVideo:
+		if message.Video != nil {
+			return message.Video.Caption
+		}
+	case MessageTypeDocument:
+		if message.Document != nil {
+			return message.Document.Caption
+		}
+	case MessageTypeButton:
+		if message.Button != nil {
+			return message.Button.Text
+		}
+	case MessageTypeInteractive:
+		if interactive := message.Interactive; interactive != nil {
+			if interactive.ButtonReply != nil {
+				return interactive.ButtonReply.Title
+			}
+			if interactive.ListReply != nil {
+				return interactive.ListReply.Title
+			}
+		}
+	case MessageTypeLocation:
+		if message.Location != nil {
+			if message.Location.Name != "" {
+				return message.Location.Name
+			}
+			return message.Location.Address
+		}
+	case MessageTypeOrder:
+		if message.Order != nil {
+			return message.Order.Text
+		}
+	}
+
+	return ""
+}
+
+// ReplySource represents the kind of element a user tapped to reply.
+type ReplySource string
+
+const (
+	// ReplySourceButton represents a reply button of an interactive message.
+	ReplySourceButton ReplySource = "button"
+	// ReplySourceList represents a row of a list message.
+	ReplySourceList ReplySource = "list"
+	// ReplySourceTemplateButton represents a quick reply button of a template message.
+	ReplySourceTemplateButton ReplySource = "template_button"
+)
+
+// Reply is a tap on a reply button or a list row, normalized across message types.
+type Reply struct {
+	// ID is the ID of the button or row, or the payload of a template button.
+	ID string `json:"id"`
+	// Title is the text of the button or row the user saw.
+	Title string `json:"title"`
+	// Source is the kind of element tapped.
+	Source ReplySource `json:"source"`
+}
+
+// ExtractReply extracts the reply of a webhook message sent by tapping a reply
+// button, a list row, or a quick reply button of a template, so the three can
+// be handled alike. Returns nil if the message isn't a reply.
+//
+// Example usage:
+//
+//	if reply := ExtractReply(webhookMessage); reply != nil {
+//	    switch reply.ID {
+//	    case "confirm":
+//	        // Confirm the order...
+//	    case "cancel":
+//	        // Cancel the order...
+//	    }
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/webhooks/payload-examples
+func ExtractReply(message *WebhookMessage) *Reply {
+	if message == nil {
+		return nil
+	}
+	message = message.Unwrap()
+
+	switch message.Type {
+	case MessageTypeButton:
+		if message.Button != nil {
+			return &Reply{ID: message.Button.Payload, Title: message.Button.Text, Source: ReplySourceTemplateButton}
+		}
+	case MessageTypeInteractive:
+		if interactive := message.Interactive; interactive != nil {
+			if interactive.ButtonReply != nil {
+				return &Reply{ID: interactive.ButtonReply.ID, Title: interactive.ButtonReply.Title, Source: ReplySourceButton}
+			}
+			if interactive.ListReply != nil {
+				return &Reply{ID: interactive.ListReply.ID, Title: interactive.ListReply.Title, Source: ReplySourceList}
+			}
+		}
+	}
+
+	return nil
+}
+
 // FlowActionPayload represents the payload for a flow action.
 // https://developers.facebook.com/docs/whatsapp/cloud-api/messages/interactive-flow-messages
 type FlowActionPayload struct {
@@ -0,0 +1,247 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strings"
+)
+
+// ModerationOutcome is the decision of a Moderation on a message.
+type ModerationOutcome string
+
+const (
+	// ModerationAllow passes the message to the handlers.
+	ModerationAllow ModerationOutcome = "allow"
+	// ModerationDeny removes the message from the notification before the
+	// handlers, the EventSink and the MessageStore see it.
+	ModerationDeny ModerationOutcome = "deny"
+	// ModerationFlag passes the message to the handlers, which can look up the
+	// verdict with WebhookContext.Moderation, e.g. to route it to a human.
+	ModerationFlag ModerationOutcome = "flag"
+)
+
+// ModerationVerdict is the decision of a Moderation on a message, and why.
+type ModerationVerdict struct {
+	Outcome ModerationOutcome
+	// Reason describes why a message was denied or flagged, for logs and reviewers.
+	Reason string
+}
+
+// ModerationContent is the content of an incoming message to be moderated.
+type ModerationContent struct {
+	// Message is the message.
+	Message *IncomingMessage
+	// Text is the text body, the media caption, or the button text of the
+	// message, if any.
+	Text string
+	// Media is the image, audio, video, document or sticker of the message, if any.
+	Media *WebhookMessageMedia
+
+	client *Client
+}
+
+// OpenMedia downloads the media of the message. It requires the Client of the Moderator.
+func (c *ModerationContent) OpenMedia(ctx context.Context) (io.ReadCloser, error) {
+	if c.Media == nil {
+		return nil, fmt.Errorf("message %s has no media", c.Message.ID)
+	}
+	if c.client == nil {
+		return nil, fmt.Errorf("downloading media requires the client of the moderator")
+	}
+	_, reader, err := c.client.GetAndDownloadMedia(ctx, c.Media.ID)
+	return reader, err
+}
+
+// MediaInfo returns the information of the media of the message, such as its
+// size, without downloading it. It requires the Client of the Moderator.
+func (c *ModerationContent) MediaInfo(ctx context.Context) (*MediaResponse, error) {
+	if c.Media == nil {
+		return nil, fmt.Errorf("message %s has no media", c.Message.ID)
+	}
+	if c.client == nil {
+		return nil, fmt.Errorf("getting media information requires the client of the moderator")
+	}
+	return c.client.GetMedia(ctx, c.Media.ID)
+}
+
+// Moderation decides whether incoming messages reach the handlers, e.g. to
+// filter abusive content for compliance. An error fails the notification,
+// unless the Moderator is FailOpen, so Meta delivers it again.
+type Moderation interface {
+	Moderate(ctx context.Context, content *ModerationContent) (ModerationVerdict, error)
+}
+
+// ModerationFunc is a function type that implements the Moderation interface.
+type ModerationFunc func(ctx context.Context, content *ModerationContent) (ModerationVerdict, error)
+
+// Moderate calls the function with the given parameters.
+func (f ModerationFunc) Moderate(ctx context.Context, content *ModerationContent) (ModerationVerdict, error) {
+	return f(ctx, content)
+}
+
+// Moderator runs a Moderation on every incoming message of a Webhook, after
+// replays are filtered and before the notification reaches the EventSink,
+// the MessageStore and the handlers. Status notifications aren't moderated.
+//
+// Example usage:
+//
+//	webhook.Moderator = &Moderator{
+//	    Moderation: &MediaPolicy{AllowedMimeTypes: []string{"image/*", "application/pdf"}, MaxBytes: 5 << 20},
+//	    Client:     client,
+//	    OnVerdict: func(ctx context.Context, message *IncomingMessage, verdict ModerationVerdict) {
+//	        log.Printf("moderation: message %s from %s: %s (%s)", message.ID, message.From, verdict.Outcome, verdict.Reason)
+//	    },
+//	}
+type Moderator struct {
+	// Moderation decides on the messages.
+	Moderation Moderation
+	// Client, if set, downloads the media of the messages for the Moderation.
+	Client *Client
+	// OnVerdict, if set, is called for every message that was denied or flagged.
+	OnVerdict func(ctx context.Context, message *IncomingMessage, verdict ModerationVerdict)
+	// FailOpen allows the messages the Moderation fails on, instead of failing
+	// the notification.
+	FailOpen bool
+}
+
+// moderate removes the denied messages from request, and records the
+// verdicts of flagged messages in wc.
+func (m *Moderator) moderate(ctx context.Context, request *WebhookRequest, wc *WebhookContext) error {
+	for i := range request.Entry {
+		entry := &request.Entry[i]
+		for j := range entry.Changes {
+			value := &entry.Changes[j].Value
+			messages := value.Messages[:0]
+			for k := range value.Messages {
+				message := newIncomingMessage(entry, value, &value.Messages[k])
+				verdict, err := m.Moderation.Moderate(ctx, newModerationContent(&message, m.Client))
+				if err != nil {
+					if !m.FailOpen {
+						return fmt.Errorf("moderating message %s: %w", message.ID, err)
+					}
+					verdict = ModerationVerdict{Outcome: ModerationAllow}
+				}
+				if verdict.Outcome != ModerationAllow && verdict.Outcome != "" && m.OnVerdict != nil {
+					m.OnVerdict(ctx, &message, verdict)
+				}
+				if verdict.Outcome == ModerationDeny {
+					continue
+				}
+				if verdict.Outcome == ModerationFlag {
+					wc.flag(message.ID, verdict)
+				}
+				messages = append(messages, value.Messages[k])
+			}
+			value.Messages = messages
+		}
+	}
+	return nil
+}
+
+// newModerationContent returns the content of message.
+func newModerationContent(message *IncomingMessage, client *Client) *ModerationContent {
+	content := &ModerationContent{Message: message, Media: messageMedia(message.WebhookMessage), client: client}
+	inner := message.Unwrap()
+	switch inner.Type {
+	case MessageTypeText:
+		if inner.Text != nil {
+			content.Text = inner.Text.Body
+		}
+	case MessageTypeButton:
+		if inner.Button != nil {
+			content.Text = inner.Button.Text
+		}
+	}
+	if content.Media != nil {
+		content.Text = content.Media.Caption
+	}
+	return content
+}
+
+// messageMedia returns the image, audio, video, document or sticker of message, if any.
+func messageMedia(message *WebhookMessage) *WebhookMessageMedia {
+	message = message.Unwrap()
+	switch message.Type {
+	case MessageTypeImage:
+		return message.Image
+	case MessageTypeAudio:
+		return message.Audio
+	case MessageTypeVideo:
+		return message.Video
+	case MessageTypeDocument:
+		return message.Document
+	case MessageTypeSticker:
+		return message.Sticker
+	}
+	return nil
+}
+
+// Moderation returns the verdict of the message with the ID if it was
+// flagged by the Moderator of the Webhook.
+func (wc *WebhookContext) Moderation(messageID string) (ModerationVerdict, bool) {
+	verdict, ok := wc.flagged[messageID]
+	return verdict, ok
+}
+
+// flag records the verdict of a flagged message.
+func (wc *WebhookContext) flag(messageID string, verdict ModerationVerdict) {
+	if wc.flagged == nil {
+		wc.flagged = make(map[string]ModerationVerdict)
+	}
+	wc.flagged[messageID] = verdict
+}
+
+// MediaPolicy is a Moderation checking the MIME type and the size of media
+// messages. Other messages are allowed.
+type MediaPolicy struct {
+	// AllowedMimeTypes, if set, are the MIME types media may have. A pattern
+	// like "image/*" matches all subtypes.
+	AllowedMimeTypes []string
+	// MaxBytes, if positive, is the maximum size of media. Checking it
+	// requires the Client of the Moderator, to get the media information.
+	MaxBytes int64
+	// Outcome is the outcome for media violating the policy, ModerationDeny if empty.
+	Outcome ModerationOutcome
+}
+
+// Moderate implements Moderation.
+func (p *MediaPolicy) Moderate(ctx context.Context, content *ModerationContent) (ModerationVerdict, error) {
+	if content.Media == nil {
+		return ModerationVerdict{Outcome: ModerationAllow}, nil
+	}
+	violation := ModerationVerdict{Outcome: p.Outcome}
+	if violation.Outcome == "" {
+		violation.Outcome = ModerationDeny
+	}
+	if len(p.AllowedMimeTypes) > 0 {
+		mediaType, _, err := mime.ParseMediaType(content.Media.MimeType)
+		if err != nil || !slices.ContainsFunc(p.AllowedMimeTypes, func(pattern string) bool {
+			return matchMimeType(pattern, mediaType)
+		}) {
+			violation.Reason = fmt.Sprintf("MIME type %q is not allowed", content.Media.MimeType)
+			return violation, nil
+		}
+	}
+	if p.MaxBytes > 0 {
+		info, err := content.MediaInfo(ctx)
+		if err != nil {
+			return ModerationVerdict{}, err
+		}
+		if info.FileSize > p.MaxBytes {
+			violation.Reason = fmt.Sprintf("media size %d exceeds %d bytes", info.FileSize, p.MaxBytes)
+			return violation, nil
+		}
+	}
+	return ModerationVerdict{Outcome: ModerationAllow}, nil
+}
+
+// matchMimeType reports whether mediaType matches pattern, e.g. "image/*".
+func matchMimeType(pattern, mediaType string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return strings.EqualFold(pattern, mediaType)
+}
@@ -0,0 +1,93 @@
+package whatsapp
+
+import (
+	"context"
+	"sync"
+)
+
+// WithOrderedSends makes the client send the messages to the same recipient
+// one after the other, even if the application sends concurrently. WhatsApp
+// may deliver messages sent concurrently in any order, so e.g. a follow-up
+// could overtake the message it refers to. A message is sent once the API
+// accepted the previous one to the same recipient; messages to different
+// recipients are not delayed.
+//
+// Messages are sent in the order their calls entered SendMessage. Goroutines
+// started one after the other may enter it in any order, so messages that
+// must arrive in order should be sent by the same goroutine, e.g. a worker
+// per conversation, while the option keeps other goroutines sending to the
+// same recipient from interleaving with them. Phone numbers are compared
+// ignoring formatting, so "+1 555-123-4567" and "15551234567" are the same
+// recipient.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithOrderedSends())
+//	go func() {
+//	    for params := range replies {
+//	        if _, err := client.SendText(ctx, recipient, params); err != nil {
+//	            log.Printf("sending reply: %v", err)
+//	        }
+//	    }
+//	}()
+func WithOrderedSends() ClientOption {
+	return func(wa *Client) {
+		wa.OrderedSends = true
+	}
+}
+
+// orderingKey returns the key of the recipient of request in recipientLocks:
+// the digits of phone numbers, or the IDs of groups.
+func orderingKey(request *Request) string {
+	if request.RecipientType == RecipientTypeGroup {
+		return "group:" + request.To
+	}
+	return phoneDigits(request.To)
+}
+
+// recipientLocks serializes the sends to every recipient in FIFO order.
+type recipientLocks struct {
+	mu    sync.Mutex
+	locks map[string]*recipientLock
+}
+
+// recipientLock is the lock of a recipient. The lock is held while its
+// channel is full, and goroutines blocked sending to it are served in order.
+type recipientLock struct {
+	ch   chan struct{}
+	refs int // refs is the number of goroutines holding or waiting for the lock.
+}
+
+// lock waits until the earlier sends to recipient finished, or ctx is done.
+// The returned function releases the lock.
+func (rl *recipientLocks) lock(ctx context.Context, recipient string) (func(), error) {
+	rl.mu.Lock()
+	if rl.locks == nil {
+		rl.locks = make(map[string]*recipientLock)
+	}
+	lock, ok := rl.locks[recipient]
+	if !ok {
+		lock = &recipientLock{ch: make(chan struct{}, 1)}
+		rl.locks[recipient] = lock
+	}
+	lock.refs++
+	rl.mu.Unlock()
+
+	done := func(acquired bool) {
+		if acquired {
+			<-lock.ch
+		}
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(rl.locks, recipient)
+		}
+	}
+	select {
+	case lock.ch <- struct{}{}:
+		return func() { done(true) }, nil
+	case <-ctx.Done():
+		done(false)
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,41 @@
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOrderedSendsNormalizeRecipients(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		messagesHandler(w, r)
+	}, WithOrderedSends())
+
+	var wg sync.WaitGroup
+	for _, recipient := range []string{"15551234567", "+1 555-123-4567", "+15551234567"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.SendText(context.Background(), recipient, &SendTextParams{Body: "hello"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := maxInFlight.Load(); got != 1 {
+		t.Errorf("%d messages to the same recipient were in flight at once, want 1", got)
+	}
+}
@@ -0,0 +1,648 @@
+package whatsapp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Wire types of the protocol buffer encoding.
+// https://protobuf.dev/programming-guides/encoding/
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// protoFields are the wire types of the known fields of a message of
+// proto/whatsapp.proto, by number.
+type protoFields map[int]int
+
+var (
+	protoWebhookMessageFields = protoFields{
+		1: protoBytes, 2: protoBytes, 3: protoBytes, 4: protoVarint, 5: protoBytes, 6: protoBytes,
+		7: protoBytes, 8: protoBytes, 9: protoBytes, 10: protoBytes, 11: protoBytes, 15: protoBytes,
+	}
+	protoRequestFields = protoFields{
+		1: protoBytes, 2: protoBytes, 3: protoBytes, 4: protoBytes, 5: protoBytes, 6: protoVarint,
+		7: protoBytes, 8: protoBytes, 9: protoBytes, 10: protoBytes, 15: protoBytes,
+	}
+	protoMediaFields = protoFields{
+		1: protoBytes, 2: protoBytes, 3: protoBytes, 4: protoBytes, 5: protoBytes, 6: protoBytes,
+		7: protoVarint, 8: protoVarint,
+	}
+	protoLocationFields = protoFields{1: protoFixed64, 2: protoFixed64, 3: protoBytes, 4: protoBytes}
+	protoReactionFields = protoFields{1: protoBytes, 2: protoBytes}
+	protoContextFields  = protoFields{1: protoBytes, 2: protoBytes, 3: protoVarint}
+	protoReplyFields    = protoFields{1: protoVarint, 2: protoBytes, 3: protoBytes}
+	protoTemplateFields = protoFields{1: protoBytes, 2: protoBytes}
+)
+
+// protoReplySources are the values of the Reply.Source enum of
+// proto/whatsapp.proto, by ReplySource.
+var protoReplySources = map[ReplySource]uint64{
+	ReplySourceTemplateButton: 1,
+	ReplySourceButton:         2,
+	ReplySourceList:           3,
+}
+
+// MarshalProto returns the encoding of the message as the WebhookMessage of
+// proto/whatsapp.proto, so it can be passed to services in other languages,
+// e.g. through a queue. The typed fields hold the common content, and the
+// json field the complete message.
+//
+// Example usage:
+//
+//	router.HandleFunc(OnType(MessageTypeText, MessageTypeImage), func(ctx context.Context, message *IncomingMessage) error {
+//	    data, err := message.MarshalProto()
+//	    if err != nil {
+//	        return err
+//	    }
+//	    return topic.Publish(ctx, data)
+//	})
+func (m *WebhookMessage) MarshalProto() ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var e protoEncoder
+	e.string(1, m.ID)
+	e.string(2, m.From)
+	e.string(3, m.GroupID)
+	if t := m.Time(); !t.IsZero() {
+		e.int64(4, t.Unix())
+	}
+	e.string(5, string(m.Type))
+	if c := m.Context; c != nil {
+		e.message(6, func(e *protoEncoder) {
+			e.string(1, c.From)
+			e.string(2, c.ID)
+			e.bool(3, c.Forwarded)
+		})
+	}
+	if m.Text != nil {
+		e.string(7, m.Text.Body)
+	}
+	if media := m.media(); media != nil {
+		e.message(8, protoMedia{
+			ID:       media.ID,
+			Caption:  media.Caption,
+			Filename: media.Filename,
+			MimeType: media.MimeType,
+			SHA256:   media.SHA256,
+			Voice:    media.Voice,
+			ViewOnce: media.ViewOnce,
+		}.encode)
+	}
+	if l := m.Location; l != nil {
+		e.message(9, func(e *protoEncoder) {
+			e.double(1, l.Latitude)
+			e.double(2, l.Longitude)
+			e.string(3, l.Name)
+			e.string(4, l.Address)
+		})
+	}
+	if r := m.Reaction; r != nil {
+		e.message(10, func(e *protoEncoder) {
+			e.string(1, r.MessageID)
+			e.string(2, r.Emoji)
+		})
+	}
+	// The reply of an ephemeral message is in the json field only
+	if reply := ExtractReply(m); reply != nil && !m.IsEphemeral() {
+		e.message(11, func(e *protoEncoder) {
+			e.uint64(1, protoReplySources[reply.Source])
+			e.string(2, reply.ID)
+			e.string(3, reply.Title)
+		})
+	}
+	e.bytes(15, data)
+	return e.buf, nil
+}
+
+// UnmarshalProto decodes data encoded as the WebhookMessage of
+// proto/whatsapp.proto into the message. The json field, if set, is decoded
+// first, and the typed fields set in data override it, so messages produced
+// by services filling only the typed fields can be decoded too. Unknown
+// fields are ignored, and fields with another wire type than in the schema
+// are an error.
+func (m *WebhookMessage) UnmarshalProto(data []byte) error {
+	*m = WebhookMessage{}
+	var decoded WebhookMessage
+	var (
+		media *protoMedia
+		reply *Reply
+	)
+	err := decodeProto(data, protoWebhookMessageFields, func(field int, v protoValue) error {
+		switch field {
+		case 1:
+			decoded.ID = v.string()
+		case 2:
+			decoded.From = v.string()
+		case 3:
+			decoded.GroupID = v.string()
+		case 4:
+			decoded.Timestamp = strconv.FormatInt(int64(v.number), 10)
+		case 5:
+			decoded.Type = MessageType(v.string())
+		case 6:
+			decoded.Context = &WebhookMessageContext{}
+			return decodeProto(v.bytes, protoContextFields, func(field int, v protoValue) error {
+				switch field {
+				case 1:
+					decoded.Context.From = v.string()
+				case 2:
+					decoded.Context.ID = v.string()
+				case 3:
+					decoded.Context.Forwarded = v.bool()
+				}
+				return nil
+			})
+		case 7:
+			decoded.Text = &WebhookMessageText{Body: v.string()}
+		case 8:
+			media = &protoMedia{}
+			return media.decode(v.bytes)
+		case 9:
+			decoded.Location = &WebhookMessageLocation{}
+			return decodeProto(v.bytes, protoLocationFields, func(field int, v protoValue) error {
+				switch field {
+				case 1:
+					decoded.Location.Latitude = v.double()
+				case 2:
+					decoded.Location.Longitude = v.double()
+				case 3:
+					decoded.Location.Name = v.string()
+				case 4:
+					decoded.Location.Address = v.string()
+				}
+				return nil
+			})
+		case 10:
+			decoded.Reaction = &WebhookMessageReaction{}
+			return decodeProto(v.bytes, protoReactionFields, func(field int, v protoValue) error {
+				switch field {
+				case 1:
+					decoded.Reaction.MessageID = v.string()
+				case 2:
+					decoded.Reaction.Emoji = v.string()
+				}
+				return nil
+			})
+		case 11:
+			reply = &Reply{}
+			return decodeProto(v.bytes, protoReplyFields, func(field int, v protoValue) error {
+				switch field {
+				case 1:
+					for source, number := range protoReplySources {
+						if number == v.number {
+							reply.Source = source
+						}
+					}
+				case 2:
+					reply.ID = v.string()
+				case 3:
+					reply.Title = v.string()
+				}
+				return nil
+			})
+		case 15:
+			if err := json.Unmarshal(v.bytes, m); err != nil {
+				return fmt.Errorf("decoding json field: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("decoding webhook message: %w", err)
+	}
+
+	if decoded.ID != "" {
+		m.ID = decoded.ID
+	}
+	if decoded.From != "" {
+		m.From = decoded.From
+	}
+	if decoded.GroupID != "" {
+		m.GroupID = decoded.GroupID
+	}
+	if decoded.Timestamp != "" {
+		m.Timestamp = decoded.Timestamp
+	}
+	if decoded.Type != "" {
+		m.Type = decoded.Type
+	}
+	if decoded.Context != nil {
+		if m.Context == nil {
+			m.Context = &WebhookMessageContext{}
+		}
+		m.Context.From, m.Context.ID, m.Context.Forwarded = decoded.Context.From, decoded.Context.ID, decoded.Context.Forwarded
+	}
+	if decoded.Text != nil {
+		m.Text = decoded.Text
+	}
+	if media != nil {
+		target := m.media()
+		if target == nil {
+			target = &WebhookMessageMedia{}
+			switch m.Type {
+			case MessageTypeImage:
+				m.Image = target
+			case MessageTypeAudio:
+				m.Audio = target
+			case MessageTypeVideo:
+				m.Video = target
+			case MessageTypeDocument:
+				m.Document = target
+			case MessageTypeSticker:
+				m.Sticker = target
+			default:
+				return fmt.Errorf("decoding webhook message: media in message of type %q", m.Type)
+			}
+		}
+		target.ID, target.Caption, target.Filename = media.ID, media.Caption, media.Filename
+		target.MimeType, target.SHA256 = media.MimeType, media.SHA256
+		target.Voice, target.ViewOnce = media.Voice, media.ViewOnce
+	}
+	if decoded.Location != nil {
+		m.Location = decoded.Location
+	}
+	if decoded.Reaction != nil {
+		m.Reaction = decoded.Reaction
+	}
+	if reply != nil {
+		switch reply.Source {
+		case ReplySourceTemplateButton:
+			m.Button = &WebhookMessageButton{Text: reply.Title, Payload: reply.ID}
+		case ReplySourceButton:
+			if m.Interactive == nil || m.Interactive.ButtonReply == nil {
+				m.Interactive = &WebhookMessageInteractive{Type: InteractiveTypeButtonReply, ButtonReply: &WebhookMessageInteractiveButton{}}
+			}
+			m.Interactive.ButtonReply.ID, m.Interactive.ButtonReply.Title = reply.ID, reply.Title
+		case ReplySourceList:
+			if m.Interactive == nil || m.Interactive.ListReply == nil {
+				m.Interactive = &WebhookMessageInteractive{Type: InteractiveTypeListReply, ListReply: &WebhookMessageInteractiveListItem{}}
+			}
+			m.Interactive.ListReply.ID, m.Interactive.ListReply.Title = reply.ID, reply.Title
+		}
+	}
+	return nil
+}
+
+// media returns the image, audio, video, document or sticker of the message
+// for its type, if any. Unlike messageMedia, it doesn't unwrap ephemeral messages.
+func (m *WebhookMessage) media() *WebhookMessageMedia {
+	switch m.Type {
+	case MessageTypeImage:
+		return m.Image
+	case MessageTypeAudio:
+		return m.Audio
+	case MessageTypeVideo:
+		return m.Video
+	case MessageTypeDocument:
+		return m.Document
+	case MessageTypeSticker:
+		return m.Sticker
+	}
+	return nil
+}
+
+// MarshalProto returns the encoding of the request as the Request of
+// proto/whatsapp.proto, e.g. to queue messages for a sender written in
+// another language. The typed fields hold the common content, and the json
+// field the complete request.
+func (r *Request) MarshalProto() ([]byte, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var e protoEncoder
+	e.string(1, r.To)
+	e.string(2, string(r.RecipientType))
+	e.string(3, string(r.Type))
+	if r.Context != nil {
+		e.string(4, r.Context.MessageID)
+	}
+	if r.Text != nil {
+		e.string(5, r.Text.Body)
+		e.bool(6, r.Text.PreviewURL)
+	}
+	if media := r.media(); media != nil {
+		e.message(7, media.encode)
+	}
+	if reaction := r.Reaction; reaction != nil {
+		e.message(8, func(e *protoEncoder) {
+			e.string(1, reaction.MessageID)
+			e.string(2, reaction.Emoji)
+		})
+	}
+	if template := r.Template; template != nil {
+		e.message(9, func(e *protoEncoder) {
+			e.string(1, template.Name)
+			if template.Language != nil {
+				e.string(2, template.Language.Code)
+			}
+		})
+	}
+	e.string(10, r.BizOpaqueCallbackData)
+	e.bytes(15, data)
+	return e.buf, nil
+}
+
+// UnmarshalProto decodes data encoded as the Request of proto/whatsapp.proto
+// into the request. The json field, if set, is decoded first, and the typed
+// fields set in data override it. Unknown fields are ignored, and fields
+// with another wire type than in the schema are an error. The messaging
+// product is always WhatsApp.
+func (r *Request) UnmarshalProto(data []byte) error {
+	*r = Request{}
+	var decoded Request
+	var (
+		media    *protoMedia
+		template *SendTemplateParams
+	)
+	err := decodeProto(data, protoRequestFields, func(field int, v protoValue) error {
+		switch field {
+		case 1:
+			decoded.To = v.string()
+		case 2:
+			decoded.RecipientType = RecipientType(v.string())
+		case 3:
+			decoded.Type = MessageType(v.string())
+		case 4:
+			decoded.Context = &RequestContext{MessageID: v.string()}
+		case 5, 6:
+			if decoded.Text == nil {
+				decoded.Text = &SendTextParams{}
+			}
+			if field == 5 {
+				decoded.Text.Body = v.string()
+			} else {
+				decoded.Text.PreviewURL = v.bool()
+			}
+		case 7:
+			media = &protoMedia{}
+			return media.decode(v.bytes)
+		case 8:
+			decoded.Reaction = &SendReactionParams{}
+			return decodeProto(v.bytes, protoReactionFields, func(field int, v protoValue) error {
+				switch field {
+				case 1:
+					decoded.Reaction.MessageID = v.string()
+				case 2:
+					decoded.Reaction.Emoji = v.string()
+				}
+				return nil
+			})
+		case 9:
+			template = &SendTemplateParams{}
+			return decodeProto(v.bytes, protoTemplateFields, func(field int, v protoValue) error {
+				switch field {
+				case 1:
+					template.Name = v.string()
+				case 2:
+					template.Language = &TemplateLanguage{Code: v.string()}
+				}
+				return nil
+			})
+		case 10:
+			decoded.BizOpaqueCallbackData = v.string()
+		case 15:
+			if err := json.Unmarshal(v.bytes, r); err != nil {
+				return fmt.Errorf("decoding json field: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("decoding request: %w", err)
+	}
+
+	r.MessagingProduct = MessagingProductWhatsApp
+	if decoded.To != "" {
+		r.To = decoded.To
+	}
+	if decoded.RecipientType != "" {
+		r.RecipientType = decoded.RecipientType
+	}
+	if decoded.Type != "" {
+		r.Type = decoded.Type
+	}
+	if decoded.Context != nil {
+		r.Context = decoded.Context
+	}
+	if decoded.Text != nil {
+		r.Text = decoded.Text
+	}
+	if media != nil {
+		switch r.Type {
+		case MessageTypeImage:
+			r.Image = &SendImageParams{ID: media.ID, Link: media.Link, Caption: media.Caption}
+		case MessageTypeAudio:
+			r.Audio = &SendAudioParams{ID: media.ID, Link: media.Link, Voice: media.Voice}
+		case MessageTypeDocument:
+			r.Document = &SendDocumentParams{ID: media.ID, Link: media.Link, Caption: media.Caption, Filename: media.Filename}
+		case MessageTypeSticker:
+			r.Sticker = &SendStickerParams{ID: media.ID, Link: media.Link}
+		default:
+			return fmt.Errorf("decoding request: media in request of type %q", r.Type)
+		}
+	}
+	if decoded.Reaction != nil {
+		r.Reaction = decoded.Reaction
+	}
+	if template != nil {
+		if r.Template == nil {
+			r.Template = &SendTemplateParams{}
+		}
+		r.Template.Name = template.Name
+		if template.Language != nil {
+			if r.Template.Language == nil {
+				r.Template.Language = &TemplateLanguage{}
+			}
+			r.Template.Language.Code = template.Language.Code
+		}
+	}
+	if decoded.BizOpaqueCallbackData != "" {
+		r.BizOpaqueCallbackData = decoded.BizOpaqueCallbackData
+	}
+	return nil
+}
+
+// media returns the media of the request for its type, if any.
+func (r *Request) media() *protoMedia {
+	switch {
+	case r.Type == MessageTypeImage && r.Image != nil:
+		return &protoMedia{ID: r.Image.ID, Link: r.Image.Link, Caption: r.Image.Caption}
+	case r.Type == MessageTypeAudio && r.Audio != nil:
+		return &protoMedia{ID: r.Audio.ID, Link: r.Audio.Link, Voice: r.Audio.Voice}
+	case r.Type == MessageTypeDocument && r.Document != nil:
+		return &protoMedia{ID: r.Document.ID, Link: r.Document.Link, Caption: r.Document.Caption, Filename: r.Document.Filename}
+	case r.Type == MessageTypeSticker && r.Sticker != nil:
+		return &protoMedia{ID: r.Sticker.ID, Link: r.Sticker.Link}
+	}
+	return nil
+}
+
+// protoMedia is the Media of proto/whatsapp.proto.
+type protoMedia struct {
+	ID, Link, Caption, Filename, MimeType, SHA256 string
+	Voice, ViewOnce                               bool
+}
+
+func (m protoMedia) encode(e *protoEncoder) {
+	e.string(1, m.ID)
+	e.string(2, m.Link)
+	e.string(3, m.Caption)
+	e.string(4, m.Filename)
+	e.string(5, m.MimeType)
+	e.string(6, m.SHA256)
+	e.bool(7, m.Voice)
+	e.bool(8, m.ViewOnce)
+}
+
+func (m *protoMedia) decode(data []byte) error {
+	return decodeProto(data, protoMediaFields, func(field int, v protoValue) error {
+		switch field {
+		case 1:
+			m.ID = v.string()
+		case 2:
+			m.Link = v.string()
+		case 3:
+			m.Caption = v.string()
+		case 4:
+			m.Filename = v.string()
+		case 5:
+			m.MimeType = v.string()
+		case 6:
+			m.SHA256 = v.string()
+		case 7:
+			m.Voice = v.bool()
+		case 8:
+			m.ViewOnce = v.bool()
+		}
+		return nil
+	})
+}
+
+// protoEncoder appends protocol buffer fields to buf. Fields with zero
+// values are omitted, as in proto3.
+type protoEncoder struct {
+	buf []byte
+}
+
+func (e *protoEncoder) key(field, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+func (e *protoEncoder) uint64(field int, v uint64) {
+	if v != 0 {
+		e.key(field, protoVarint)
+		e.buf = binary.AppendUvarint(e.buf, v)
+	}
+}
+
+func (e *protoEncoder) int64(field int, v int64) {
+	e.uint64(field, uint64(v))
+}
+
+func (e *protoEncoder) bool(field int, v bool) {
+	if v {
+		e.uint64(field, 1)
+	}
+}
+
+func (e *protoEncoder) double(field int, v float64) {
+	if v != 0 {
+		e.key(field, protoFixed64)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+	}
+}
+
+func (e *protoEncoder) string(field int, v string) {
+	if v != "" {
+		e.bytes(field, []byte(v))
+	}
+}
+
+func (e *protoEncoder) bytes(field int, v []byte) {
+	if len(v) > 0 {
+		e.key(field, protoBytes)
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+		e.buf = append(e.buf, v...)
+	}
+}
+
+// message appends the embedded message written by encode, even if it is
+// empty, as embedded messages have presence.
+func (e *protoEncoder) message(field int, encode func(e *protoEncoder)) {
+	var inner protoEncoder
+	encode(&inner)
+	e.key(field, protoBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(inner.buf)))
+	e.buf = append(e.buf, inner.buf...)
+}
+
+// protoValue is the value of a decoded protocol buffer field.
+type protoValue struct {
+	number uint64 // number is the value of varint and fixed fields.
+	bytes  []byte // bytes is the value of length-delimited fields.
+}
+
+func (v protoValue) string() string  { return string(v.bytes) }
+func (v protoValue) bool() bool      { return v.number != 0 }
+func (v protoValue) double() float64 { return math.Float64frombits(v.number) }
+
+// decodeProto calls field with the number and value of every known field of
+// the protocol buffer message in data, in order. Unknown fields are skipped,
+// and known fields with another wire type than in fields are an error.
+func decodeProto(data []byte, fields protoFields, field func(number int, v protoValue) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 || key>>3 == 0 || key>>3 > math.MaxInt32 {
+			return fmt.Errorf("invalid field key")
+		}
+		data = data[n:]
+		var v protoValue
+		switch wireType := key & 7; wireType {
+		case protoVarint:
+			if v.number, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("invalid varint of field %d", key>>3)
+			}
+			data = data[n:]
+		case protoFixed64:
+			if len(data) < 8 {
+				return fmt.Errorf("truncated field %d", key>>3)
+			}
+			v.number, data = binary.LittleEndian.Uint64(data), data[8:]
+		case protoFixed32:
+			if len(data) < 4 {
+				return fmt.Errorf("truncated field %d", key>>3)
+			}
+			v.number, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("truncated field %d", key>>3)
+			}
+			v.bytes, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported wire type %d of field %d", wireType, key>>3)
+		}
+		number := int(key >> 3)
+		wireType, known := fields[number]
+		if !known {
+			continue
+		}
+		if wireType != int(key&7) {
+			return fmt.Errorf("field %d has wire type %d, want %d", number, key&7, wireType)
+		}
+		if err := field(number, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package whatsapp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var protoTestMessages = map[string]*WebhookMessage{
+	"text": {
+		ID: "wamid.1", From: "15551234567", Timestamp: "1700000000", Type: MessageTypeText,
+		Text: &WebhookMessageText{Body: "Hello"},
+	},
+	"image reply": {
+		ID: "wamid.2", From: "15551234567", Timestamp: "1700000001", Type: MessageTypeImage,
+		Context: &WebhookMessageContext{From: "15550001111", ID: "wamid.0"},
+		Image:   &WebhookMessageMedia{ID: "media-1", Caption: "Look", MimeType: "image/jpeg", SHA256: "abc"},
+	},
+	"location": {
+		ID: "wamid.3", From: "15551234567", Type: MessageTypeLocation,
+		Location: &WebhookMessageLocation{Latitude: 52.52, Longitude: -13.405, Name: "Office", Address: "Main St 1"},
+	},
+	"reaction": {
+		ID: "wamid.4", From: "15551234567", GroupID: "group-1", Type: MessageTypeReaction,
+		Reaction: &WebhookMessageReaction{MessageID: "wamid.0", Emoji: "👍"},
+	},
+	"button reply": {
+		ID: "wamid.5", From: "15551234567", Type: MessageTypeInteractive,
+		Interactive: &WebhookMessageInteractive{
+			Type:        InteractiveTypeButtonReply,
+			ButtonReply: &WebhookMessageInteractiveButton{ID: "yes", Title: "Yes"},
+		},
+	},
+	"template button": {
+		ID: "wamid.6", From: "15551234567", Type: MessageTypeButton,
+		Button: &WebhookMessageButton{Text: "Stop", Payload: "unsubscribe"},
+	},
+}
+
+var protoTestRequests = map[string]*Request{
+	"text": {
+		MessagingProduct: MessagingProductWhatsApp, RecipientType: RecipientTypeIndividual,
+		To: "15551234567", Type: MessageTypeText,
+		Context: &RequestContext{MessageID: "wamid.0"},
+		Text:    &SendTextParams{Body: "Hello https://example.com", PreviewURL: true},
+	},
+	"document": {
+		MessagingProduct: MessagingProductWhatsApp, RecipientType: RecipientTypeIndividual,
+		To: "15551234567", Type: MessageTypeDocument,
+		Document:              &SendDocumentParams{Link: "https://example.com/a.pdf", Caption: "Invoice", Filename: "a.pdf"},
+		BizOpaqueCallbackData: "invoice-7",
+	},
+	"reaction": {
+		MessagingProduct: MessagingProductWhatsApp, RecipientType: RecipientTypeIndividual,
+		To: "15551234567", Type: MessageTypeReaction,
+		Reaction: &SendReactionParams{MessageID: "wamid.0", Emoji: "👍"},
+	},
+	"template": {
+		MessagingProduct: MessagingProductWhatsApp, RecipientType: RecipientTypeGroup,
+		To: "group-1", Type: MessageTypeTemplate,
+		Template: &SendTemplateParams{Name: "order_update", Language: &TemplateLanguage{Code: "en_US"}},
+	},
+}
+
+func TestWebhookMessageProtoRoundTrip(t *testing.T) {
+	for name, message := range protoTestMessages {
+		t.Run(name, func(t *testing.T) {
+			data, err := message.MarshalProto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded WebhookMessage
+			if err := decoded.UnmarshalProto(data); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&decoded, message) {
+				t.Errorf("UnmarshalProto(MarshalProto()) = %+v, want %+v", &decoded, message)
+			}
+		})
+	}
+}
+
+func TestRequestProtoRoundTrip(t *testing.T) {
+	for name, request := range protoTestRequests {
+		t.Run(name, func(t *testing.T) {
+			data, err := request.MarshalProto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded Request
+			if err := decoded.UnmarshalProto(data); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&decoded, request) {
+				t.Errorf("UnmarshalProto(MarshalProto()) = %+v, want %+v", &decoded, request)
+			}
+		})
+	}
+}
+
+// The wire bytes of the tests below are the encodings of the messages of
+// proto/whatsapp.proto produced by code generated with protoc, which writes
+// the fields in order of their numbers and omits the ones with zero values.
+const (
+	// protoWireLocation is a WebhookMessage with id "wamid.1", from
+	// "15551234567", timestamp 1700000000, type "location", a context with
+	// from "15550001111", id "wamid.0" and forwarded set, and a location at
+	// 52.52, 13.405 named "Office".
+	protoWireLocation = "0a0777616d69642e31120b3135353531323334353637" +
+		"2080e2cfaa06" +
+		"2a086c6f636174696f6e" +
+		"32180a0b3135353530303031313131120777616d69642e301801" +
+		"4a1a09c3f5285c8f424a40118fc2f5285ccf2a401a064f6666696365"
+	// protoWireListReply is a WebhookMessage with id "wamid.2", from
+	// "15551234567", type "interactive" and a reply with source
+	// SOURCE_LIST, id "row-1" and title "First row".
+	protoWireListReply = "0a0777616d69642e32120b3135353531323334353637" +
+		"2a0b696e746572616374697665" +
+		"5a1408031205726f772d311a09466972737420726f77"
+	// protoWireImageRequest is a Request to "15551234567", recipient type
+	// "individual", type "image", with media with link
+	// "https://example.com/a.png" and caption "Look", and callback data "campaign-7".
+	protoWireImageRequest = "0a0b3135353531323334353637120a696e646976696475616c1a05696d616765" +
+		"3a21121968747470733a2f2f6578616d706c652e636f6d2f612e706e671a044c6f6f6b" +
+		"520a63616d706169676e2d37"
+	// protoWireUnknownFields are fields 20 to 23 of a newer version of the
+	// schema, with the wire types varint, fixed32, bytes and fixed64.
+	protoWireUnknownFields = "a00105ad0101020304b2010178b9010000000000000000"
+)
+
+func mustDecodeHex(t testing.TB, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWebhookMessageProtoConformance(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		wire string
+		want *WebhookMessage
+	}{
+		{"location", protoWireLocation, &WebhookMessage{
+			ID: "wamid.1", From: "15551234567", Timestamp: "1700000000", Type: MessageTypeLocation,
+			Context:  &WebhookMessageContext{From: "15550001111", ID: "wamid.0", Forwarded: true},
+			Location: &WebhookMessageLocation{Latitude: 52.52, Longitude: 13.405, Name: "Office"},
+		}},
+		{"list reply", protoWireListReply, &WebhookMessage{
+			ID: "wamid.2", From: "15551234567", Type: MessageTypeInteractive,
+			Interactive: &WebhookMessageInteractive{
+				Type:      InteractiveTypeListReply,
+				ListReply: &WebhookMessageInteractiveListItem{ID: "row-1", Title: "First row"},
+			},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			wire := mustDecodeHex(t, tc.wire)
+			for _, data := range [][]byte{wire, append(bytes.Clone(wire), mustDecodeHex(t, protoWireUnknownFields)...)} {
+				var decoded WebhookMessage
+				if err := decoded.UnmarshalProto(data); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(&decoded, tc.want) {
+					t.Errorf("UnmarshalProto(%x) = %+v, want %+v", data, &decoded, tc.want)
+				}
+			}
+
+			// The typed fields are encoded as by generated code, followed by the json field
+			data, err := tc.want.MarshalProto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(data, wire) || len(data) == len(wire) || data[len(wire)] != 15<<3|protoBytes {
+				t.Errorf("MarshalProto() = %x, want %s followed by the json field", data, tc.wire)
+			}
+		})
+	}
+}
+
+func TestRequestProtoConformance(t *testing.T) {
+	wire := mustDecodeHex(t, protoWireImageRequest)
+	want := &Request{
+		MessagingProduct: MessagingProductWhatsApp, RecipientType: RecipientTypeIndividual,
+		To: "15551234567", Type: MessageTypeImage,
+		Image:                 &SendImageParams{Link: "https://example.com/a.png", Caption: "Look"},
+		BizOpaqueCallbackData: "campaign-7",
+	}
+	var decoded Request
+	if err := decoded.UnmarshalProto(append(bytes.Clone(wire), mustDecodeHex(t, protoWireUnknownFields)...)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, want) {
+		t.Errorf("UnmarshalProto() = %+v, want %+v", &decoded, want)
+	}
+	data, err := want.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, wire) || len(data) == len(wire) || data[len(wire)] != 15<<3|protoBytes {
+		t.Errorf("MarshalProto() = %x, want %s followed by the json field", data, protoWireImageRequest)
+	}
+}
+
+func TestUnmarshalProtoErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, wire, want string
+	}{
+		{"string as varint", "0801", "field 1 has wire type 0, want 2"},
+		{"varint as string", "22023130", "field 4 has wire type 2, want 0"},
+		{"nested wire type", "4a020801", "field 1 has wire type 0, want 1"},
+		{"truncated string", "0a05616263", "truncated field 1"},
+		{"truncated fixed64", "a1010102", "truncated field 20"},
+		{"truncated varint", "2080", "invalid varint of field 4"},
+		{"group", "a3010101", "unsupported wire type 3 of field 20"},
+		{"field zero", "0001", "invalid field key"},
+		{"invalid json", "7a017b", "decoding json field"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var message WebhookMessage
+			err := message.UnmarshalProto(mustDecodeHex(t, tc.wire))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("UnmarshalProto(%s) = %v, want an error containing %q", tc.wire, err, tc.want)
+			}
+		})
+	}
+}
+
+func FuzzUnmarshalProto(f *testing.F) {
+	for _, message := range protoTestMessages {
+		data, err := message.MarshalProto()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	for _, request := range protoTestRequests {
+		data, err := request.MarshalProto()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	for _, wire := range []string{protoWireLocation, protoWireListReply, protoWireImageRequest, protoWireUnknownFields, "0801", "4a020801"} {
+		f.Add(mustDecodeHex(f, wire))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Whatever the fields and their wire types, decoding fails or succeeds without panicking
+		var message WebhookMessage
+		if message.UnmarshalProto(data) == nil {
+			if _, err := message.MarshalProto(); err != nil {
+				t.Errorf("MarshalProto of a decoded message: %v", err)
+			}
+		}
+		var request Request
+		if request.UnmarshalProto(data) == nil {
+			if _, err := request.MarshalProto(); err != nil {
+				t.Errorf("MarshalProto of a decoded request: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// UserPurger is implemented by the stores that retain data about users, so it
+// can be erased on request, e.g. under the right to be forgotten of the GDPR.
+// MemoryMessageStore, MemoryScheduleStore, EncryptedMessageStore,
+// EncryptedScheduleStore, Scheduler, AutoResponder, History and ResponderBot
+// implement it. Caches of opaque IDs, such as
+// MemorySeenCache and MemoryIdempotencyStore, and the aggregates of Reporter
+// and CostTracker hold no data about users.
+type UserPurger interface {
+	// PurgeUser removes everything retained about the user with the WhatsApp
+	// ID waID, the phone number of the user without "+". Purging an unknown
+	// user is not an error.
+	PurgeUser(ctx context.Context, waID string) error
+}
+
+// SealedPurger is implemented by the stores wrapped by EncryptedMessageStore
+// and EncryptedScheduleStore, which can only tell the messages sent to a user
+// by decrypting them. MemoryMessageStore and MemoryScheduleStore implement it.
+type SealedPurger interface {
+	// PurgeSealed deletes the messages with a Sealed request for which match
+	// reports true.
+	PurgeSealed(ctx context.Context, match func(id string, sealed []byte) bool) error
+}
+
+// PurgeUser removes the data of the user with the WhatsApp ID waID from all
+// purgers, including custom stores implementing UserPurger. All purgers are
+// called even if some fail, and the errors are returned joined.
+//
+// Example usage:
+//
+//	if err := PurgeUser(ctx, waID, messageStore, scheduler, responder); err != nil {
+//	    return fmt.Errorf("erasing WhatsApp data of %s: %w", waID, err)
+//	}
+func PurgeUser(ctx context.Context, waID string, purgers ...UserPurger) error {
+	var errs []error
+	for _, purger := range purgers {
+		if err := purger.PurgeUser(ctx, waID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PurgeUser implements UserPurger by deleting the messages sent to the user.
+func (s *MemoryMessageStore) PurgeUser(ctx context.Context, waID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, message := range s.messages {
+		if isRecipient(message.Request, waID) {
+			delete(s.messages, id)
+		}
+	}
+	return nil
+}
+
+// PurgeSealed implements SealedPurger.
+func (s *MemoryMessageStore) PurgeSealed(ctx context.Context, match func(id string, sealed []byte) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, message := range s.messages {
+		if message.Sealed != nil && match(id, message.Sealed) {
+			delete(s.messages, id)
+		}
+	}
+	return nil
+}
+
+// PurgeUser implements UserPurger by deleting the messages scheduled for the user.
+func (s *MemoryScheduleStore) PurgeUser(ctx context.Context, waID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, message := range s.messages {
+		if isRecipient(message.Request, waID) {
+			delete(s.messages, id)
+		}
+	}
+	return nil
+}
+
+// PurgeSealed implements SealedPurger.
+func (s *MemoryScheduleStore) PurgeSealed(ctx context.Context, match func(id string, sealed []byte) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, message := range s.messages {
+		if message.Sealed != nil && match(id, message.Sealed) {
+			delete(s.messages, id)
+		}
+	}
+	return nil
+}
+
+// purgeSealed deletes the messages of store sent to the user, decrypting the
+// sealed ones with keys. Messages that can't be decrypted are kept and
+// reported in the error.
+func purgeSealed(ctx context.Context, store any, keys KeyProvider, waID string) error {
+	purger, ok := store.(SealedPurger)
+	if !ok {
+		return fmt.Errorf("purging encrypted messages: %T doesn't implement SealedPurger", store)
+	}
+	var errs []error
+	err := purger.PurgeSealed(ctx, func(id string, sealed []byte) bool {
+		request, err := openRequest(ctx, keys, id, sealed)
+		if err != nil {
+			errs = append(errs, err)
+			return false
+		}
+		return isRecipient(request, waID)
+	})
+	errs = append(errs, err)
+	if userPurger, ok := store.(UserPurger); ok {
+		// Messages stored before encryption was enabled are in plain text
+		errs = append(errs, userPurger.PurgeUser(ctx, waID))
+	}
+	return errors.Join(errs...)
+}
+
+// PurgeUser implements UserPurger by forgetting the last message sent to the
+// user, and by purging the store if it implements UserPurger.
+func (s *Scheduler) PurgeUser(ctx context.Context, waID string) error {
+	s.mu.Lock()
+	for recipient := range s.lastSent {
+		if samePhoneNumber(recipient, waID) {
+			delete(s.lastSent, recipient)
+		}
+	}
+	s.mu.Unlock()
+	if purger, ok := s.Store.(UserPurger); ok {
+		return purger.PurgeUser(ctx, waID)
+	}
+	return nil
+}
+
+// PurgeUser implements UserPurger by forgetting the last auto-reply to the user.
+func (ar *AutoResponder) PurgeUser(ctx context.Context, waID string) error {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	for user := range ar.replied {
+		if samePhoneNumber(user, waID) {
+			delete(ar.replied, user)
+		}
+	}
+	return nil
+}
+
+// isRecipient reports whether request is a message to the user with the WhatsApp ID waID.
+func isRecipient(request *Request, waID string) bool {
+	return request != nil && request.RecipientType != RecipientTypeGroup && samePhoneNumber(request.To, waID)
+}
@@ -0,0 +1,329 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQualityPollInterval is the period at which a QualityMonitor fetches
+// the quality of the phone number, if its Interval is not set.
+const DefaultQualityPollInterval = 15 * time.Minute
+
+// QualityUpdateEvent represents the event of a phone_number_quality_update notification.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#phone_number_quality_update
+type QualityUpdateEvent string
+
+const (
+	// QualityUpdateEventFlagged represents a phone number whose quality dropped
+	// to low. Its messaging limit is lowered if the quality doesn't improve.
+	QualityUpdateEventFlagged QualityUpdateEvent = "FLAGGED"
+	// QualityUpdateEventUnflagged represents a phone number whose quality recovered.
+	QualityUpdateEventUnflagged QualityUpdateEvent = "UNFLAGGED"
+	// QualityUpdateEventUpgrade represents a raised messaging limit.
+	QualityUpdateEventUpgrade QualityUpdateEvent = "UPGRADE"
+	// QualityUpdateEventDowngrade represents a lowered messaging limit.
+	QualityUpdateEventDowngrade QualityUpdateEvent = "DOWNGRADE"
+	// QualityUpdateEventOnboarding represents a phone number still being onboarded.
+	QualityUpdateEventOnboarding QualityUpdateEvent = "ONBOARDING"
+)
+
+// QualityEvent returns the event of a phone_number_quality_update notification.
+func (v *WebhookValue) QualityEvent() QualityUpdateEvent {
+	return QualityUpdateEvent(v.Event)
+}
+
+// QualityRating represents the quality of a phone number, based on how
+// recipients received its messages.
+// https://developers.facebook.com/docs/whatsapp/messaging-limits#quality-rating
+type QualityRating string
+
+const (
+	// QualityRatingGreen represents high quality.
+	QualityRatingGreen QualityRating = "GREEN"
+	// QualityRatingYellow represents medium quality.
+	QualityRatingYellow QualityRating = "YELLOW"
+	// QualityRatingRed represents low quality.
+	QualityRatingRed QualityRating = "RED"
+	// QualityRatingUnknown represents a quality that isn't rated yet.
+	QualityRatingUnknown QualityRating = "UNKNOWN"
+)
+
+// rank orders the ratings from low to high quality, 0 for unrated ones.
+func (r QualityRating) rank() int {
+	switch r {
+	case QualityRatingRed:
+		return 1
+	case QualityRatingYellow:
+		return 2
+	case QualityRatingGreen:
+		return 3
+	}
+	return 0
+}
+
+// MessagingLimit represents the messaging limit tier of a phone number, the
+// number of users it may start conversations with in 24 hours.
+// https://developers.facebook.com/docs/whatsapp/messaging-limits
+type MessagingLimit string
+
+const (
+	// MessagingLimitTier50 allows 50 conversations, the limit of unverified businesses.
+	MessagingLimitTier50 MessagingLimit = "TIER_50"
+	// MessagingLimitTier250 allows 250 conversations.
+	MessagingLimitTier250 MessagingLimit = "TIER_250"
+	// MessagingLimitTier1K allows 1,000 conversations.
+	MessagingLimitTier1K MessagingLimit = "TIER_1K"
+	// MessagingLimitTier10K allows 10,000 conversations.
+	MessagingLimitTier10K MessagingLimit = "TIER_10K"
+	// MessagingLimitTier100K allows 100,000 conversations.
+	MessagingLimitTier100K MessagingLimit = "TIER_100K"
+	// MessagingLimitTierUnlimited allows any number of conversations.
+	MessagingLimitTierUnlimited MessagingLimit = "TIER_UNLIMITED"
+)
+
+// Conversations returns the number of business-initiated conversations the
+// tier allows in 24 hours, math.MaxInt for the unlimited tier, and 0 for
+// unknown tiers.
+func (l MessagingLimit) Conversations() int {
+	switch l {
+	case MessagingLimitTier50:
+		return 50
+	case MessagingLimitTier250:
+		return 250
+	case MessagingLimitTier1K:
+		return 1_000
+	case MessagingLimitTier10K:
+		return 10_000
+	case MessagingLimitTier100K:
+		return 100_000
+	case MessagingLimitTierUnlimited:
+		return math.MaxInt
+	}
+	return 0
+}
+
+// PhoneNumberQuality is the quality and messaging limit of a phone number.
+type PhoneNumberQuality struct {
+	DisplayPhoneNumber string         `json:"display_phone_number,omitempty"`
+	QualityRating      QualityRating  `json:"quality_rating,omitempty"`
+	MessagingLimit     MessagingLimit `json:"messaging_limit_tier,omitempty"`
+}
+
+// GetPhoneNumberQuality fetches the quality rating and messaging limit tier of the phone number.
+// https://developers.facebook.com/docs/graph-api/reference/whats-app-business-account-to-number-current-status/
+func (wa *Client) GetPhoneNumberQuality(ctx context.Context) (*PhoneNumberQuality, error) {
+	var response PhoneNumberQuality
+	query := url.Values{"fields": {"display_phone_number,quality_rating,messaging_limit_tier"}}
+	if err := graphRequest(ctx, wa, http.MethodGet, []string{wa.PhoneNumberID}, query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// QualityChange is a change of the quality of a phone number observed by a QualityMonitor.
+type QualityChange struct {
+	Previous, Current PhoneNumberQuality
+	// Event is the event of the notification reporting the change, empty if
+	// the change was fetched.
+	Event QualityUpdateEvent
+}
+
+// Downgrade reports whether the change lowered the messaging limit or the
+// quality rating, or flagged the phone number.
+func (c *QualityChange) Downgrade() bool {
+	switch c.Event {
+	case QualityUpdateEventDowngrade, QualityUpdateEventFlagged:
+		return true
+	}
+	previous, current := c.Previous.MessagingLimit.Conversations(), c.Current.MessagingLimit.Conversations()
+	if previous > 0 && current > 0 && current < previous {
+		return true
+	}
+	previous, current = c.Previous.QualityRating.rank(), c.Current.QualityRating.rank()
+	return previous > 0 && current > 0 && current < previous
+}
+
+// QualityMonitor tracks the quality rating and messaging limit of the phone
+// number of a client, so high-volume senders can slow down before Meta
+// lowers their limit. The quality is fetched every Interval once started, and
+// updated from phone_number_quality_update notifications in between: the
+// monitor is a WebhookChangeHandler for Router.HandleField. It is safe for
+// concurrent use.
+//
+// Example usage:
+//
+//	monitor := NewQualityMonitor(client)
+//	monitor.OnDowngrade = func(ctx context.Context, change QualityChange) {
+//	    log.Printf("phone number quality dropped: %+v -> %+v", change.Previous, change.Current)
+//	}
+//	router.HandleField(WebhookFieldPhoneNumberQualityUpdate, monitor)
+//	if err := monitor.Start(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer monitor.Shutdown(ctx)
+type QualityMonitor struct {
+	// Client fetches the quality of its phone number.
+	Client *Client
+	// Interval is the period between fetches, DefaultQualityPollInterval if zero.
+	Interval time.Duration
+	// OnChange, if set, is called for every change of the quality and every
+	// notification, including downgrades.
+	OnChange func(ctx context.Context, change QualityChange)
+	// OnDowngrade, if set, is called for the changes that are downgrades.
+	OnDowngrade func(ctx context.Context, change QualityChange)
+	// OnError, if set, is called for the failed fetches of the monitor started by Start.
+	OnError func(err error)
+
+	mu      sync.Mutex
+	current PhoneNumberQuality
+	stop    chan struct{} // stop is closed by Shutdown.
+	done    chan struct{} // done is closed when the loop started by Start returned.
+}
+
+// NewQualityMonitor creates a QualityMonitor for the phone number of client.
+func NewQualityMonitor(client *Client) *QualityMonitor {
+	return &QualityMonitor{Client: client}
+}
+
+// Current returns the last known quality of the phone number. Its fields are
+// empty until fetched or notified.
+func (qm *QualityMonitor) Current() PhoneNumberQuality {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	return qm.current
+}
+
+// Poll fetches the quality of the phone number and reports its changes.
+func (qm *QualityMonitor) Poll(ctx context.Context) error {
+	quality, err := qm.Client.GetPhoneNumberQuality(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching phone number quality: %w", err)
+	}
+	qm.update(ctx, "", func(current *PhoneNumberQuality) {
+		*current = *quality
+	})
+	return nil
+}
+
+// HandleWebhookChange implements WebhookChangeHandler by updating the
+// messaging limit from phone_number_quality_update notifications. Other
+// changes, and notifications of other phone numbers, are ignored. The quality
+// rating isn't part of the notifications, and is updated by the next fetch.
+func (qm *QualityMonitor) HandleWebhookChange(ctx context.Context, entry *WebhookEntry, change *WebhookChange) error {
+	if change.Field != WebhookFieldPhoneNumberQualityUpdate {
+		return nil
+	}
+	value := &change.Value
+	if known := qm.Current().DisplayPhoneNumber; known != "" && value.DisplayPhoneNumber != "" && !samePhoneNumber(known, value.DisplayPhoneNumber) {
+		return nil
+	}
+	qm.update(ctx, value.QualityEvent(), func(current *PhoneNumberQuality) {
+		if value.DisplayPhoneNumber != "" && current.DisplayPhoneNumber == "" {
+			current.DisplayPhoneNumber = value.DisplayPhoneNumber
+		}
+		if value.CurrentLimit != "" {
+			current.MessagingLimit = value.CurrentLimit
+		}
+	})
+	return nil
+}
+
+// update applies modify to the current quality and calls the callbacks if it
+// changed or event is set.
+func (qm *QualityMonitor) update(ctx context.Context, event QualityUpdateEvent, modify func(current *PhoneNumberQuality)) {
+	qm.mu.Lock()
+	change := QualityChange{Previous: qm.current, Event: event}
+	modify(&qm.current)
+	change.Current = qm.current
+	qm.mu.Unlock()
+
+	if change.Previous == change.Current && event == "" {
+		return
+	}
+	if qm.OnChange != nil {
+		qm.OnChange(ctx, change)
+	}
+	if qm.OnDowngrade != nil && change.Downgrade() {
+		qm.OnDowngrade(ctx, change)
+	}
+}
+
+// Start implements Lifecycle. It fetches the quality in the background until
+// ctx is done or Shutdown is called, starting right away.
+func (qm *QualityMonitor) Start(ctx context.Context) error {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	if qm.stop != nil {
+		return fmt.Errorf("quality monitor already started")
+	}
+	qm.stop, qm.done = make(chan struct{}), make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		qm.run(ctx, stop)
+	}(qm.stop, qm.done)
+	return nil
+}
+
+// Shutdown implements Lifecycle. It stops the monitor started by Start.
+func (qm *QualityMonitor) Shutdown(ctx context.Context) error {
+	qm.mu.Lock()
+	stop, done := qm.stop, qm.done
+	if stop != nil {
+		select {
+		case <-stop:
+		default:
+			close(stop)
+		}
+	}
+	qm.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run fetches the quality until ctx is done or stop is closed.
+func (qm *QualityMonitor) run(ctx context.Context, stop <-chan struct{}) {
+	ticker := time.NewTicker(orDefault(qm.Interval, DefaultQualityPollInterval))
+	defer ticker.Stop()
+	for {
+		if err := qm.Poll(ctx); err != nil && ctx.Err() == nil && qm.OnError != nil {
+			qm.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// samePhoneNumber reports whether a and b are the same phone number, ignoring
+// formatting such as "+", spaces and dashes.
+func samePhoneNumber(a, b string) bool {
+	return phoneDigits(a) == phoneDigits(b)
+}
+
+// phoneDigits returns the digits of the phone number s.
+func phoneDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,79 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+const (
+	// MaxReplyButtons is the maximum number of buttons of a reply buttons message.
+	MaxReplyButtons = 3
+	// MaxReplyButtonTitleLength is the maximum length of a reply button title in characters.
+	MaxReplyButtonTitleLength = 20
+)
+
+// NewReplyButtons creates reply buttons from alternating ID and title pairs.
+//
+// Example usage:
+//
+//	buttons, err := NewReplyButtons("yes", "Yes", "no", "No")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	response, err := client.SendInteractiveButtons(ctx, "1234567890", &SendInteractiveButtonsParams{
+//	    Body:    &Body{Text: "Do you want to continue?"},
+//	    Buttons: buttons,
+//	})
+func NewReplyButtons(idTitlePairs ...string) ([]Button, error) {
+	if len(idTitlePairs)%2 != 0 {
+		return nil, fmt.Errorf("reply buttons need ID and title pairs, got %d values", len(idTitlePairs))
+	}
+	buttons := make([]Button, 0, len(idTitlePairs)/2)
+	for i := 0; i < len(idTitlePairs); i += 2 {
+		id, title := idTitlePairs[i], idTitlePairs[i+1]
+		if id == "" || title == "" {
+			return nil, fmt.Errorf("reply button %d: ID and title are required", i/2)
+		}
+		if n := TextLength(title); n > MaxReplyButtonTitleLength {
+			return nil, fmt.Errorf("reply button %d: title has %d characters, maximum is %d", i/2, n, MaxReplyButtonTitleLength)
+		}
+		buttons = append(buttons, Button{Type: ButtonTypeReply, Reply: &ReplyButton{ID: id, Title: title}})
+	}
+	if len(buttons) == 0 || len(buttons) > MaxReplyButtons {
+		return nil, fmt.Errorf("reply buttons message needs 1 to %d buttons, got %d", MaxReplyButtons, len(buttons))
+	}
+	return buttons, nil
+}
+
+// SendQuickReplies sends a reply buttons message with the given body text.
+// The buttons map button IDs to titles and are ordered by ID.
+//
+// Example usage:
+//
+//	response, err := client.SendQuickReplies(ctx, "1234567890", "How was your delivery?", map[string]string{
+//	    "1_good": "Good",
+//	    "2_bad":  "Bad",
+//	})
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/interactive-reply-buttons-messages
+func (wa *Client) SendQuickReplies(ctx context.Context, recipient, bodyText string, buttons map[string]string) (*MessagesResponse, error) {
+	ids := make([]string, 0, len(buttons))
+	for id := range buttons {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	pairs := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		pairs = append(pairs, id, buttons[id])
+	}
+	replyButtons, err := NewReplyButtons(pairs...)
+	if err != nil {
+		return nil, err
+	}
+	return wa.SendInteractiveButtons(ctx, recipient, &SendInteractiveButtonsParams{
+		Body:    &Body{Text: bodyText},
+		Buttons: replyButtons,
+	})
+}
@@ -0,0 +1,119 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// markReadRequest marks a received message as read.
+type markReadRequest struct {
+	MessagingProduct MessagingProduct `json:"messaging_product"`
+	Status           MessageStatus    `json:"status"`
+	MessageID        string           `json:"message_id"`
+	TypingIndicator  *typingIndicator `json:"typing_indicator,omitempty"`
+}
+
+// typingIndicator is the typing indicator shown with a read receipt.
+type typingIndicator struct {
+	Type string `json:"type"`
+}
+
+// MarkAsRead marks a received message, and the ones received before it, as
+// read, showing the blue check marks to the user. With typing, the user also
+// sees that a reply is being typed, until it's sent or for up to 25 seconds.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/guides/mark-message-as-read
+// https://developers.facebook.com/docs/whatsapp/cloud-api/typing-indicators
+func (wa *Client) MarkAsRead(ctx context.Context, messageID string, typing bool) (*SuccessResponse, error) {
+	if messageID == "" {
+		return nil, fmt.Errorf("message ID cannot be empty")
+	}
+	request := &markReadRequest{
+		MessagingProduct: MessagingProductWhatsApp,
+		Status:           MessageStatusRead,
+		MessageID:        messageID,
+	}
+	if typing {
+		request.TypingIndicator = &typingIndicator{Type: "text"}
+	}
+	var response SuccessResponse
+	if err := sendRequest(ctx, wa, "messages", request, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ReadReceipts marks the messages a handler accepted as read, so bots appear
+// responsive without marking messages in every handler. A message is marked
+// once the next handler returns without error, e.g. after queueing it for an
+// agent or a slow backend, which is when a typing indicator tells the user
+// the most. Its Middleware attaches it to a Router.
+//
+// Failing to mark a message doesn't fail its handling, as the webhook would
+// be retried for a cosmetic problem; the errors are passed to OnError instead.
+//
+// Example usage:
+//
+//	receipts := NewReadReceipts(client)
+//	receipts.TypingIndicator = true
+//	receipts.Types = []MessageType{MessageTypeText, MessageTypeInteractive, MessageTypeButton}
+//	receipts.RateLimiter = rate.NewLimiter(20, 5)
+//	router.Use(receipts.Middleware)
+type ReadReceipts struct {
+	// Client marks the messages as read. Messages sent to other phone numbers
+	// than the one of the client are ignored.
+	Client *Client
+	// Types, if set, restricts read receipts to the messages of the types.
+	// Ephemeral messages have the type of the message they wrap.
+	Types []MessageType
+	// TypingIndicator shows a typing indicator with the read receipts.
+	TypingIndicator bool
+	// RateLimiter, if set, paces the read receipts separately from the
+	// messages of the client, which may have a limiter of its own.
+	RateLimiter RateLimiter
+	// OnError, if set, is called with the errors of failed read receipts.
+	OnError func(err error)
+}
+
+// NewReadReceipts creates ReadReceipts marking the messages of all types as read with client.
+func NewReadReceipts(client *Client) *ReadReceipts {
+	return &ReadReceipts{Client: client}
+}
+
+// Middleware returns next wrapped with the read receipts. It is a MessageMiddleware.
+func (rr *ReadReceipts) Middleware(next MessageHandler) MessageHandler {
+	return MessageHandlerFunc(func(ctx context.Context, message *IncomingMessage) error {
+		if err := next.HandleMessage(ctx, message); err != nil {
+			return err
+		}
+		if !rr.applies(message) {
+			return nil
+		}
+		if err := rr.markAsRead(ctx, message); err != nil && rr.OnError != nil {
+			rr.OnError(fmt.Errorf("marking message %s from %s as read: %w", message.ID, message.From, err))
+		}
+		return nil
+	})
+}
+
+// applies reports whether message gets a read receipt.
+func (rr *ReadReceipts) applies(message *IncomingMessage) bool {
+	if message.ID == "" {
+		return false
+	}
+	if id := message.Metadata.PhoneNumberID; id != "" && id != rr.Client.PhoneNumberID {
+		return false
+	}
+	return len(rr.Types) == 0 || slices.Contains(rr.Types, message.Unwrap().Type)
+}
+
+// markAsRead waits for the rate limiter and marks message as read.
+func (rr *ReadReceipts) markAsRead(ctx context.Context, message *IncomingMessage) error {
+	if rr.RateLimiter != nil {
+		if err := rr.RateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	_, err := rr.Client.MarkAsRead(ctx, message.ID, rr.TypingIndicator)
+	return err
+}
@@ -0,0 +1,178 @@
+package whatsapp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RedactedSecret replaces the secrets removed by ScrubError and RedactingTransport.
+const RedactedSecret = "[REDACTED]"
+
+// secretPatterns match secrets in text without knowing them: bearer tokens,
+// secret query parameters, Meta access tokens and URL passwords.
+var secretPatterns = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\b(bearer|oauth)(\s+)[A-Za-z0-9._~+/=-]+`), "${1}${2}" + RedactedSecret},
+	{regexp.MustCompile(`(?i)\b(access_token|appsecret_proof|client_secret|fb_exchange_token)(=|":\s*")[^&\s"']+`), "${1}${2}" + RedactedSecret},
+	{regexp.MustCompile(`\bEAA[A-Za-z0-9]{20,}`), RedactedSecret},
+	{regexp.MustCompile(`(://[^:/@\s]+:)[^@/\s]+@`), "${1}" + RedactedSecret + "@"},
+}
+
+// ScrubError returns err with the secrets removed from its message: the
+// given secrets, such as the access token and the app secret, and anything
+// that looks like a bearer token, a Meta access token, an access_token or
+// appsecret_proof query parameter, or the password of a URL. The message and
+// details of a wrapped *GraphError and the URL of a wrapped *url.Error are
+// scrubbed in place, so they are clean when unwrapped too. The result wraps
+// err, so errors.Is and errors.As work as before.
+//
+// The client scrubs the errors it returns with its access token and app
+// secret. ScrubError is meant for the errors of other code that handles
+// them, e.g. before logging.
+//
+// Example usage:
+//
+//	if err != nil {
+//	    log.Printf("refreshing token: %v", ScrubError(err, oldToken, newToken))
+//	}
+func ScrubError(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+	var graphErr *GraphError
+	if errors.As(err, &graphErr) {
+		graphErr.Message = scrubSecrets(graphErr.Message, secrets)
+		graphErr.Details = scrubSecrets(graphErr.Details, secrets)
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = scrubSecrets(urlErr.URL, secrets)
+	}
+	message := err.Error()
+	if scrubbed := scrubSecrets(message, secrets); scrubbed != message {
+		return &scrubbedError{err: err, message: scrubbed}
+	}
+	return err
+}
+
+// scrubbedError is an error whose message has secrets removed.
+type scrubbedError struct {
+	err     error
+	message string
+}
+
+func (e *scrubbedError) Error() string {
+	return e.message
+}
+
+func (e *scrubbedError) Unwrap() error {
+	return e.err
+}
+
+// scrubSecrets returns s with the secrets and the secretPatterns replaced
+// with RedactedSecret. Empty secrets are ignored.
+func scrubSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, RedactedSecret)
+		}
+	}
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+// scrub returns err scrubbed of the access token and the app secret of the client.
+func (wa *Client) scrub(err error) error {
+	return ScrubError(err, wa.AccessToken, wa.AppSecret)
+}
+
+// RedactingTransport is an http.RoundTripper that logs every request and
+// response in full, headers and bodies, with secrets redacted as by
+// ScrubError, for debugging the exchanges with the API. Bodies are read into
+// memory to be logged, so use it for debugging only.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithDebugLogging(func(dump []byte) {
+//	    log.Printf("%s", dump)
+//	}))
+type RedactingTransport struct {
+	// Base sends the requests, http.DefaultTransport if nil.
+	Base http.RoundTripper
+	// Log receives the dump of every request and response.
+	Log func(dump []byte)
+	// Secrets are redacted in addition to the patterns of ScrubError, e.g.
+	// the access token.
+	Secrets []string
+	// OmitBodies logs the headers only, e.g. to keep media out of the logs.
+	OmitBodies bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RedactingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.Log != nil {
+		dump, err := httputil.DumpRequestOut(req, !t.OmitBodies)
+		if err != nil {
+			return nil, ScrubError(fmt.Errorf("dumping request: %w", err), t.Secrets...)
+		}
+		t.Log(t.redact(dump))
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		if t.Log != nil {
+			t.Log(t.redact([]byte(fmt.Sprintf("%s %s failed: %v", req.Method, req.URL, err))))
+		}
+		return nil, err
+	}
+	if t.Log != nil {
+		dump, err := httputil.DumpResponse(resp, !t.OmitBodies)
+		if err != nil {
+			resp.Body.Close()
+			return nil, ScrubError(fmt.Errorf("dumping response: %w", err), t.Secrets...)
+		}
+		t.Log(t.redact(dump))
+	}
+	return resp, nil
+}
+
+// redact returns dump with the secrets removed.
+func (t *RedactingTransport) redact(dump []byte) []byte {
+	return []byte(scrubSecrets(string(bytes.TrimSpace(dump)), t.Secrets))
+}
+
+// WithDebugLogging logs every API request and response of the client in
+// full with log, through a RedactingTransport redacting the access token, the
+// app secret and the appsecret_proof. Apply it after the options configuring the transport,
+// such as WithTunedTransport, which need the transport to be an *http.Transport.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithTunedTransport(), WithDebugLogging(func(dump []byte) {
+//	    log.Printf("%s", dump)
+//	}))
+func WithDebugLogging(log func(dump []byte)) ClientOption {
+	return func(wa *Client) {
+		client := *wa.Client
+		client.Transport = &RedactingTransport{
+			Base:    client.Transport,
+			Log:     log,
+			Secrets: []string{wa.AccessToken, wa.AppSecret},
+		}
+		wa.Client = &client
+	}
+}
@@ -0,0 +1,430 @@
+package whatsapp
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultRenderWidth is the width, in characters, at which a Renderer without
+// a Width wraps text.
+const DefaultRenderWidth = 60
+
+// ANSI escape sequences of the styles of a Renderer with ANSI set.
+const (
+	ansiBold          = "\x1b[1m"
+	ansiDim           = "\x1b[2m"
+	ansiItalic        = "\x1b[3m"
+	ansiStrikethrough = "\x1b[9m"
+	ansiReset         = "\x1b[0m"
+)
+
+// renderMarkers are the styles of the WhatsApp formatting markers a Renderer
+// with ANSI set turns into styles.
+var renderMarkers = map[byte]string{'*': ansiBold, '_': ansiItalic, '~': ansiStrikethrough}
+
+// Renderer produces text previews of outgoing messages, showing what a
+// customer will receive: the header, body and footer of interactive
+// messages, their buttons, list rows and calls to action, media with their
+// captions, reactions, and the parameters of templates. Previews are meant
+// for logs and approval workflows; they aren't a faithful rendering.
+//
+// Example usage:
+//
+//	renderer := &Renderer{ANSI: true}
+//	fmt.Println(renderer.Render(request))
+//	if !confirm("Send this message?") {
+//	    return nil
+//	}
+//	_, err := client.SendMessage(ctx, request)
+type Renderer struct {
+	// Width is the width, in characters, at which text is wrapped,
+	// DefaultRenderWidth if zero.
+	Width int
+	// ANSI styles the preview with terminal escape sequences: WhatsApp
+	// formatting such as *bold* is shown as such, and footers and IDs are dimmed.
+	ANSI bool
+}
+
+// Render returns a plain text preview of request, as produced by a Renderer
+// with the default settings.
+//
+// Example usage:
+//
+//	log.Printf("sending:\n%s", Render(request))
+func Render(request *Request) string {
+	return (&Renderer{}).Render(request)
+}
+
+// Render returns the preview of request.
+func (r *Renderer) Render(request *Request) string {
+	if request == nil {
+		return ""
+	}
+	p := &preview{renderer: r, width: orDefault(r.Width, DefaultRenderWidth)}
+	to := "To: " + request.To
+	if request.RecipientType == RecipientTypeGroup {
+		to += " (group)"
+	}
+	if request.Context != nil && request.Context.MessageID != "" {
+		to += ", replying to " + request.Context.MessageID
+	}
+	p.meta(to)
+
+	switch request.Type {
+	case MessageTypeText:
+		if request.Text != nil {
+			p.text(request.Text.Body)
+			if request.Text.PreviewURL {
+				p.note("[link preview]")
+			}
+		}
+	case MessageTypeImage:
+		if request.Image != nil {
+			p.media("image", request.Image.ID, request.Image.Link, "")
+			p.text(request.Image.Caption)
+		}
+	case MessageTypeAudio:
+		if request.Audio != nil {
+			kind := "audio"
+			if request.Audio.Voice {
+				kind = "voice note"
+			}
+			p.media(kind, request.Audio.ID, request.Audio.Link, "")
+		}
+	case MessageTypeDocument:
+		if request.Document != nil {
+			p.media("document", request.Document.ID, request.Document.Link, request.Document.Filename)
+			p.text(request.Document.Caption)
+		}
+	case MessageTypeSticker:
+		if request.Sticker != nil {
+			p.media("sticker", request.Sticker.ID, request.Sticker.Link, "")
+		}
+	case MessageTypeReaction:
+		if request.Reaction != nil {
+			if request.Reaction.Emoji == "" {
+				p.note("Removes the reaction to " + request.Reaction.MessageID)
+			} else {
+				p.note(fmt.Sprintf("Reacts %s to %s", request.Reaction.Emoji, request.Reaction.MessageID))
+			}
+		}
+	case MessageTypeInteractive:
+		if request.Interactive != nil {
+			p.interactive(request.Interactive)
+		}
+	case MessageTypeTemplate:
+		if request.Template != nil {
+			p.template(request.Template)
+		}
+	default:
+		p.note(fmt.Sprintf("[%s message]", request.Type))
+	}
+
+	if request.BizOpaqueCallbackData != "" {
+		p.meta("Callback data: " + request.BizOpaqueCallbackData)
+	}
+	return p.String()
+}
+
+// preview is a preview being rendered. Lines of the message are drawn with a
+// bar on their left, the lines about the message without.
+type preview struct {
+	strings.Builder
+	renderer *Renderer
+	width    int
+}
+
+// line writes a line of the message in style, if ANSI is set.
+func (p *preview) line(text, style string) {
+	p.WriteString("│ ")
+	if p.renderer.ANSI && style != "" {
+		text = style + text + ansiReset
+	}
+	p.WriteString(text)
+	p.WriteByte('\n')
+}
+
+// meta writes a line about the message, such as its recipient.
+func (p *preview) meta(text string) {
+	if p.renderer.ANSI {
+		text = ansiDim + text + ansiReset
+	}
+	p.WriteString(text)
+	p.WriteByte('\n')
+}
+
+// text writes the lines of text, wrapped, with its formatting.
+func (p *preview) text(text string) {
+	if text == "" {
+		return
+	}
+	for _, paragraph := range strings.Split(text, "\n") {
+		lines := p.wrap(paragraph)
+		if !p.renderer.ANSI {
+			for _, line := range lines {
+				p.line(line, "")
+			}
+			continue
+		}
+		// The markers are found in the wrapped paragraph, so the styles of
+		// spans broken by the wrapping continue on the next line.
+		markers := formatMarkers(strings.Join(lines, " "))
+		var styles []string // styles are the styles of the open spans.
+		offset := 0
+		for _, line := range lines {
+			var b strings.Builder
+			b.WriteString(strings.Join(styles, ""))
+			for i := range len(line) {
+				style, ok := markers[offset+i]
+				if !ok {
+					b.WriteByte(line[i])
+					continue
+				}
+				if j := slices.Index(styles, style); j >= 0 {
+					styles = slices.Delete(styles, j, j+1)
+					b.WriteString(ansiReset + strings.Join(styles, ""))
+				} else {
+					styles = append(styles, style)
+					b.WriteString(style)
+				}
+			}
+			if len(styles) > 0 {
+				b.WriteString(ansiReset)
+			}
+			p.line(b.String(), "")
+			offset += len(line) + 1
+		}
+	}
+}
+
+// formatMarkers returns the styles of the formatting markers of a paragraph
+// that open or close a span, by their offset. A marker opens a span if it
+// doesn't follow a word character and precedes a non-space, and closes it if
+// it follows a non-space and doesn't precede a word character. Spans contain
+// text, but not their own marker, so e.g. "*a* *b*" has two bold spans.
+func formatMarkers(paragraph string) map[int]string {
+	markers := make(map[int]string)
+	open := make(map[byte]int) // open are the offsets of the markers of the spans being read.
+	for i := range len(paragraph) {
+		marker := paragraph[i]
+		style, ok := renderMarkers[marker]
+		if !ok {
+			continue
+		}
+		before, after := ' ', ' '
+		if i > 0 {
+			before, _ = utf8.DecodeLastRuneInString(paragraph[:i])
+		}
+		if i+1 < len(paragraph) {
+			after, _ = utf8.DecodeRuneInString(paragraph[i+1:])
+		}
+		start, opened := open[marker]
+		switch {
+		case opened && !unicode.IsSpace(before) && before != rune(marker) && !isWordRune(after) && after != rune(marker):
+			markers[start], markers[i] = style, style
+			delete(open, marker)
+		case !isWordRune(before) && before != rune(marker) && !unicode.IsSpace(after) && after != rune(marker):
+			open[marker] = i
+		default:
+			delete(open, marker)
+		}
+	}
+	return markers
+}
+
+// isWordRune reports whether r is part of a word, so a marker next to it is
+// part of the text.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// note writes a line the customer sees that isn't text, e.g. a reaction.
+func (p *preview) note(text string) {
+	p.line(text, ansiItalic)
+}
+
+// dim writes a line in small print, e.g. a footer.
+func (p *preview) dim(text string) {
+	for _, line := range p.wrap(text) {
+		p.line(line, ansiDim)
+	}
+}
+
+// media writes the placeholder of a media attachment.
+func (p *preview) media(kind, id, link, filename string) {
+	placeholder := "[" + kind
+	if filename != "" {
+		placeholder += ": " + filename
+	}
+	placeholder += "]"
+	switch {
+	case link != "":
+		placeholder += " " + link
+	case id != "":
+		placeholder += " media " + id
+	}
+	p.line(placeholder, ansiItalic)
+}
+
+// button writes a button with its label and, dimmed, what it does.
+func (p *preview) button(label, detail string) {
+	p.option("[ "+label+" ]", detail)
+}
+
+// option writes a choice of the customer and, dimmed, its detail.
+func (p *preview) option(text, detail string) {
+	if detail != "" {
+		if p.renderer.ANSI {
+			detail = ansiDim + detail + ansiReset
+		}
+		text += " " + detail
+	}
+	p.line(text, "")
+}
+
+// separator writes the line between the content and the actions of a message.
+func (p *preview) separator() {
+	p.line(strings.Repeat("─", min(p.width, 20)), ansiDim)
+}
+
+func (p *preview) interactive(interactive *Interactive) {
+	if header := interactive.Header; header != nil {
+		switch header.Type {
+		case HeaderTypeText:
+			p.line(header.Text, ansiBold)
+		case HeaderTypeImage, HeaderTypeVideo, HeaderTypeDocument:
+			media := header.Image
+			if header.Type == HeaderTypeVideo {
+				media = header.Video
+			} else if header.Type == HeaderTypeDocument {
+				media = header.Document
+			}
+			if media != nil {
+				p.media(string(header.Type), media.ID, media.Link, media.Filename)
+			}
+		}
+	}
+	if interactive.Body != nil {
+		p.text(interactive.Body.Text)
+	}
+	if interactive.Footer != nil && interactive.Footer.Text != "" {
+		p.dim(interactive.Footer.Text)
+	}
+
+	action := interactive.Action
+	if action == nil {
+		return
+	}
+	p.separator()
+	switch params := action.Parameters.(type) {
+	case *CTAURLParameters:
+		p.button("↗ "+params.DisplayText, params.URL)
+		return
+	case *FlowParameters:
+		detail := "flow " + params.FlowID
+		if params.FlowAction != "" {
+			detail += ", " + string(params.FlowAction)
+		}
+		if params.FlowActionPayload != nil && params.FlowActionPayload.Screen != "" {
+			detail += " to " + params.FlowActionPayload.Screen
+		}
+		p.button(params.FlowCTA, detail)
+		return
+	}
+	for _, button := range action.Buttons {
+		if button.Reply != nil {
+			p.button(button.Reply.Title, "id "+button.Reply.ID)
+		}
+	}
+	if len(action.Sections) > 0 {
+		p.button("≡ "+action.Button, "")
+		for _, section := range action.Sections {
+			if section.Title != "" {
+				p.line("  "+section.Title, ansiBold)
+			}
+			for _, row := range section.Rows {
+				text := "  ○ " + row.Title
+				if row.Description != "" {
+					text += " - " + row.Description
+				}
+				p.option(text, "id "+row.ID)
+			}
+		}
+	}
+	if len(action.Buttons) == 0 && len(action.Sections) == 0 && action.Name != "" {
+		p.button(action.Name, "")
+	}
+}
+
+func (p *preview) template(template *SendTemplateParams) {
+	name := "[template " + template.Name
+	if template.Language != nil && template.Language.Code != "" {
+		name += ", " + template.Language.Code
+	}
+	p.line(name+"]", ansiItalic)
+	for _, component := range template.Components {
+		label := string(component.Type)
+		if component.SubType != "" || component.Index != "" {
+			label += fmt.Sprintf(" %s %s", component.SubType, component.Index)
+		}
+		values := make([]string, 0, len(component.Parameters))
+		for i, param := range component.Parameters {
+			name := param.ParameterName
+			if name == "" {
+				name = fmt.Sprint(i + 1)
+			}
+			values = append(values, fmt.Sprintf("{{%s}} = %s", name, templateParameterPreview(param)))
+		}
+		if len(values) == 0 {
+			continue
+		}
+		p.line(strings.TrimSpace(label)+": "+strings.Join(values, ", "), "")
+	}
+}
+
+// templateParameterPreview returns the value of param as the customer sees it.
+func templateParameterPreview(param TemplateParameter) string {
+	switch {
+	case param.Text != "":
+		return param.Text
+	case param.Payload != "":
+		return "payload " + param.Payload
+	case param.Currency != nil:
+		return param.Currency.FallbackValue
+	case param.DateTime != nil:
+		return param.DateTime.FallbackValue
+	case param.Image != nil:
+		return "[image " + cmp.Or(param.Image.Link, param.Image.ID) + "]"
+	case param.Document != nil:
+		return "[document " + cmp.Or(param.Document.Filename, param.Document.Link, param.Document.ID) + "]"
+	case param.Action != nil:
+		return "[flow]"
+	}
+	return "[" + string(param.Type) + "]"
+}
+
+// wrap splits text into lines of at most the width of the preview, at spaces
+// where possible. Line breaks of text are kept.
+func (p *preview) wrap(text string) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case TextLength(line)+1+TextLength(word) <= p.width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,116 @@
+package whatsapp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRendererFormatting(t *testing.T) {
+	const (
+		b, i, s, r = ansiBold, ansiItalic, ansiStrikethrough, ansiReset
+	)
+	for _, tc := range []struct {
+		name  string
+		width int
+		body  string
+		want  []string
+	}{
+		{"bold", 0, "*a*", []string{b + "a" + r}},
+		{"spans sharing a separator", 0, "*a* *b*", []string{b + "a" + r + " " + b + "b" + r}},
+		{"adjacent styles", 0, "*a*,_b_,~c~", []string{b + "a" + r + "," + i + "b" + r + "," + s + "c" + r}},
+		{"nested", 0, "*bold _both_ bold*", []string{b + "bold " + i + "both" + r + b + " bold" + r}},
+		{"punctuation", 0, "(*a*).", []string{"(" + b + "a" + r + ")."}},
+		{"inside words", 0, "snake_case_name 2*3*4", []string{"snake_case_name 2*3*4"}},
+		{"spaces inside markers", 0, "* a * *b *", []string{"* a * *b *"}},
+		{"unclosed", 0, "*a _b", []string{"*a _b"}},
+		{"marker inside span", 0, "*a*b*", []string{"*a*b*"}},
+		{"later opening marker", 0, "*a *b*", []string{"*a " + b + "b" + r}},
+		{"empty", 0, "** __", []string{"** __"}},
+		{"across lines", 0, "*a\nb*", []string{"*a", "b*"}},
+		{"wrapped span", 10, "say *hello big world* now", []string{
+			"say " + b + "hello" + r,
+			b + "big world" + r,
+			"now",
+		}},
+		{"wrapped nested span", 8, "_aa *bb cc* dd_", []string{
+			i + "aa " + b + "bb" + r,
+			i + b + "cc" + r + i + " dd" + r,
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			renderer := &Renderer{ANSI: true, Width: tc.width}
+			got := renderer.Render(&Request{To: "15551234567", Type: MessageTypeText, Text: &SendTextParams{Body: tc.body}})
+			want := ansiDim + "To: 15551234567" + r + "\n"
+			for _, line := range tc.want {
+				want += "│ " + line + "\n"
+			}
+			if got != want {
+				t.Errorf("Render(%q) =\n%q\nwant\n%q", tc.body, got, want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		request *Request
+		want    []string
+	}{
+		{"wrapped text", &Request{
+			To: "15551234567", Type: MessageTypeText,
+			Context: &RequestContext{MessageID: "wamid.0"},
+			Text:    &SendTextParams{Body: "*Hello* " + strings.Repeat("word ", 12) + "\n\nbye", PreviewURL: true},
+		}, []string{
+			"To: 15551234567, replying to wamid.0",
+			"│ *Hello* word word word word word word word word word word",
+			"│ word word",
+			"│ ",
+			"│ bye",
+			"│ [link preview]",
+		}},
+		{"image", &Request{
+			To: "group-1", RecipientType: RecipientTypeGroup, Type: MessageTypeImage,
+			Image:                 &SendImageParams{ID: "media-1", Caption: "Look"},
+			BizOpaqueCallbackData: "campaign-7",
+		}, []string{
+			"To: group-1 (group)",
+			"│ [image] media media-1",
+			"│ Look",
+			"Callback data: campaign-7",
+		}},
+		{"reaction", &Request{
+			To: "15551234567", Type: MessageTypeReaction,
+			Reaction: &SendReactionParams{MessageID: "wamid.0", Emoji: "👍"},
+		}, []string{
+			"To: 15551234567",
+			"│ Reacts 👍 to wamid.0",
+		}},
+		{"reply buttons", &Request{
+			To: "15551234567", Type: MessageTypeInteractive,
+			Interactive: &Interactive{
+				Type:   InteractiveTypeButton,
+				Body:   &Body{Text: "Continue?"},
+				Footer: &Footer{Text: "Reply to choose"},
+				Action: &Action{Buttons: []Button{
+					{Type: ButtonTypeReply, Reply: &ReplyButton{ID: "yes", Title: "Yes"}},
+					{Type: ButtonTypeReply, Reply: &ReplyButton{ID: "no", Title: "No"}},
+				}},
+			},
+		}, []string{
+			"To: 15551234567",
+			"│ Continue?",
+			"│ Reply to choose",
+			"│ " + strings.Repeat("─", 20),
+			"│ [ Yes ] id yes",
+			"│ [ No ] id no",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			want := strings.Join(tc.want, "\n") + "\n"
+			if got := Render(tc.request); got != want {
+				t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrReplayExpired is reported for messages and statuses older than the webhook replay window.
+	ErrReplayExpired = errors.New("notification is older than the replay window")
+	// ErrReplaySeen is reported for messages and statuses that were already processed.
+	ErrReplaySeen = errors.New("notification was already processed")
+)
+
+// SeenCache remembers the IDs of processed webhook notifications.
+// Share a cache between instances, e.g. backed by Redis, to detect replays across them.
+type SeenCache interface {
+	// MarkSeen atomically records id and reports whether it was recorded before.
+	MarkSeen(ctx context.Context, id string) (bool, error)
+	// Forget removes id, so that a notification that failed to be handled is
+	// handled again when Meta redelivers it.
+	Forget(ctx context.Context, id string) error
+}
+
+// MemorySeenCache is a SeenCache keeping IDs in memory for a limited time.
+// It is safe for concurrent use.
+type MemorySeenCache struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemorySeenCache creates a cache remembering IDs for ttl. Meta retries
+// failed deliveries for up to 7 days, so a ttl shorter than that only
+// protects against quick retries.
+func NewMemorySeenCache(ttl time.Duration) *MemorySeenCache {
+	return &MemorySeenCache{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// MarkSeen implements SeenCache.
+func (c *MemorySeenCache) MarkSeen(ctx context.Context, id string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for k, seenAt := range c.seen {
+			if now.Sub(seenAt) >= c.ttl {
+				delete(c.seen, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if seenAt, ok := c.seen[id]; ok && now.Sub(seenAt) < c.ttl {
+		return true, nil
+	}
+	c.seen[id] = now
+	return false, nil
+}
+
+// Forget implements SeenCache.
+func (c *MemorySeenCache) Forget(ctx context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.seen, id)
+	return nil
+}
+
+// replayCheckEnabled reports whether the webhook checks for replays.
+func (wh *Webhook) replayCheckEnabled() bool {
+	return wh.ReplayWindow > 0 || wh.SeenIDs != nil
+}
+
+// filterReplays removes replayed messages and statuses from the request, unless
+// AllowReplays is set, and reports them to OnReplay. It reports whether the
+// request consisted of replays only, and returns the keys it marked as seen,
+// to be forgotten if handling the request fails.
+func (wh *Webhook) filterReplays(ctx context.Context, request *WebhookRequest) (onlyReplays bool, marked []string, err error) {
+	defer func() {
+		if err != nil {
+			wh.forgetSeen(ctx, marked)
+			marked = nil
+		}
+	}()
+	now := time.Now()
+	var replays, remaining int
+	for i := range request.Entry {
+		for j := range request.Entry[i].Changes {
+			value := &request.Entry[i].Changes[j].Value
+
+			messages := value.Messages[:0]
+			for _, message := range value.Messages {
+				replay, err := wh.checkReplay(ctx, now, message.ID, message.ID, message.Timestamp)
+				if err != nil {
+					return false, marked, err
+				}
+				if !replay && wh.SeenIDs != nil {
+					marked = append(marked, message.ID)
+				}
+				if replay {
+					replays++
+				}
+				if !replay || wh.AllowReplays {
+					messages = append(messages, message)
+				}
+			}
+			value.Messages = messages
+
+			statuses := value.Statuses[:0]
+			for _, status := range value.Statuses {
+				// A message has a status notification for every status it goes through
+				key := status.ID + "/" + string(status.Status)
+				replay, err := wh.checkReplay(ctx, now, status.ID, key, status.Timestamp)
+				if err != nil {
+					return false, marked, err
+				}
+				if !replay && wh.SeenIDs != nil {
+					marked = append(marked, key)
+				}
+				if replay {
+					replays++
+				}
+				if !replay || wh.AllowReplays {
+					statuses = append(statuses, status)
+				}
+			}
+			value.Statuses = statuses
+
+			remaining += len(value.Messages) + len(value.Statuses)
+		}
+	}
+	return replays > 0 && remaining == 0, marked, nil
+}
+
+// checkReplay reports whether the notification is a replay.
+func (wh *Webhook) checkReplay(ctx context.Context, now time.Time, id, key, timestamp string) (bool, error) {
+	var reason error
+	if wh.ReplayWindow > 0 {
+		if t, ok := parseUnixTime(timestamp); ok && now.Sub(t) > wh.ReplayWindow {
+			reason = ErrReplayExpired
+		}
+	}
+	if reason == nil && wh.SeenIDs != nil {
+		seen, err := wh.SeenIDs.MarkSeen(ctx, key)
+		if err != nil {
+			return false, fmt.Errorf("checking seen IDs: %w", err)
+		}
+		if seen {
+			reason = ErrReplaySeen
+		}
+	}
+	if reason != nil && wh.OnReplay != nil {
+		wh.OnReplay(id, reason)
+	}
+	return reason != nil, nil
+}
+
+// forgetSeen removes the keys from SeenIDs after handling a request failed,
+// so its redelivery isn't dropped as a replay. Errors are ignored: the
+// redelivery is dropped then, as without Forget.
+func (wh *Webhook) forgetSeen(ctx context.Context, keys []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range keys {
+		wh.SeenIDs.Forget(ctx, key)
+	}
+}
+
+// seenResponse records whether the response to a request whose IDs were
+// marked as seen reports a failure, so that they are forgotten.
+type seenResponse struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *seenResponse) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *seenResponse) Write(p []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (sr *seenResponse) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// failed reports whether the response asks Meta to redeliver the notification.
+func (sr *seenResponse) failed() bool {
+	return sr.status != 0 && (sr.status < 200 || sr.status > 299)
+}
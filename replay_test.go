@@ -0,0 +1,89 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAppSecret = "app-secret"
+
+// newSignedWebhookRequest returns a notification with body signed with testAppSecret.
+func newSignedWebhookRequest(body string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(testAppSecret))
+	mac.Write([]byte(body))
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+const replayTestBody = `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages","value":{"messaging_product":"whatsapp","messages":[{"from":"15551234567","id":"wamid.1","timestamp":"1700000000","type":"text","text":{"body":"hi"}}]}}]}]}`
+
+func TestWebhookForgetsSeenIDsOnFailure(t *testing.T) {
+	var calls int
+	wh := NewWebhook("verify", testAppSecret, WebhookHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *WebhookRequest) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "Temporary failure", http.StatusInternalServerError)
+		}
+	}))
+	wh.SeenIDs = NewMemorySeenCache(time.Hour)
+
+	for i, want := range []int{http.StatusInternalServerError, http.StatusOK, http.StatusOK} {
+		w := httptest.NewRecorder()
+		wh.ServeHTTP(w, newSignedWebhookRequest(replayTestBody))
+		if w.Code != want {
+			t.Fatalf("delivery %d: status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2: the redelivery after the failure only", calls)
+	}
+}
+
+func TestWebhookForgetsSeenIDsOnPanic(t *testing.T) {
+	var calls int
+	wh := NewWebhook("verify", testAppSecret, WebhookHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *WebhookRequest) {
+		calls++
+		if calls == 1 {
+			panic("handler failed")
+		}
+	}))
+	wh.SeenIDs = NewMemorySeenCache(time.Hour)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic of the handler wasn't propagated")
+			}
+		}()
+		wh.ServeHTTP(httptest.NewRecorder(), newSignedWebhookRequest(replayTestBody))
+	}()
+	wh.ServeHTTP(httptest.NewRecorder(), newSignedWebhookRequest(replayTestBody))
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestMemorySeenCacheForget(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemorySeenCache(time.Hour)
+	if seen, _ := c.MarkSeen(ctx, "id"); seen {
+		t.Fatal("MarkSeen of a new ID reported it as seen")
+	}
+	if seen, _ := c.MarkSeen(ctx, "id"); !seen {
+		t.Fatal("MarkSeen of a marked ID reported it as new")
+	}
+	if err := c.Forget(ctx, "id"); err != nil {
+		t.Fatal(err)
+	}
+	if seen, _ := c.MarkSeen(ctx, "id"); seen {
+		t.Error("MarkSeen of a forgotten ID reported it as seen")
+	}
+}
@@ -0,0 +1,76 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReplyText replies to the message with a text message quoting it. The text
+// is sent with SendText, so long texts, URL shortening and link previews are
+// handled as configured on the client; every message sent for it quotes the message.
+//
+// Example usage:
+//
+//	router.HandleFunc(OnType(MessageTypeText), func(ctx context.Context, message *IncomingMessage) error {
+//	    _, err := message.ReplyText(ctx, client, "Thanks, we got it!")
+//	    return err
+//	})
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/guides/send-messages#contextual-replies
+func (m *WebhookMessage) ReplyText(ctx context.Context, wa *Client, text string) (*MessagesResponse, error) {
+	if m.From == "" {
+		return nil, fmt.Errorf("message sender cannot be empty")
+	}
+	return wa.SendText(m.replyContext(ctx), m.From, &SendTextParams{Body: text})
+}
+
+// ReplyButtons replies to the message with an interactive reply buttons message quoting it.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/interactive-reply-buttons-messages
+func (m *WebhookMessage) ReplyButtons(ctx context.Context, wa *Client, params *SendInteractiveButtonsParams) (*MessagesResponse, error) {
+	if params == nil {
+		return nil, fmt.Errorf("interactive buttons parameters cannot be nil")
+	}
+	return m.reply(ctx, wa, &Request{
+		Type:        MessageTypeInteractive,
+		Interactive: newInteractiveButtons(params),
+	})
+}
+
+// React reacts to the message with an emoji. An empty emoji removes the reaction.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/messages/reaction-messages
+func (m *WebhookMessage) React(ctx context.Context, wa *Client, emoji string) (*MessagesResponse, error) {
+	if m.From == "" {
+		return nil, fmt.Errorf("message sender cannot be empty")
+	}
+	if m.ID == "" {
+		return nil, fmt.Errorf("message ID cannot be empty")
+	}
+	return wa.SendReaction(ctx, m.From, &SendReactionParams{MessageID: m.ID, Emoji: emoji})
+}
+
+// reply completes request as a contextual reply to the message and sends it.
+func (m *WebhookMessage) reply(ctx context.Context, wa *Client, request *Request) (*MessagesResponse, error) {
+	if m.From == "" {
+		return nil, fmt.Errorf("message sender cannot be empty")
+	}
+	request.MessagingProduct = MessagingProductWhatsApp
+	request.RecipientType = RecipientTypeIndividual
+	request.To = m.From
+	if m.ID != "" {
+		request.Context = &RequestContext{MessageID: m.ID}
+	}
+	return wa.SendMessage(ctx, request)
+}
+
+// replyToKey is the context key of the ID of the message that the messages
+// sent with the context reply to.
+type replyToKey struct{}
+
+// replyContext returns ctx making SendMessage quote the message in the
+// requests without a context.
+func (m *WebhookMessage) replyContext(ctx context.Context) context.Context {
+	if m.ID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, replyToKey{}, m.ID)
+}
@@ -0,0 +1,54 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReplyTextQuotesEveryMessage(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []Request
+	)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var request Request
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		mu.Lock()
+		requests = append(requests, request)
+		mu.Unlock()
+		messagesHandler(w, r)
+	})
+	client.LongTextStrategy = LongTextSplit
+
+	message := &WebhookMessage{ID: "wamid.in", From: "15551234567", Type: MessageTypeText}
+	text := strings.Repeat("word ", MaxTextBodyLength/5+10)
+	if _, err := message.ReplyText(context.Background(), client, text); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("sent %d messages, want the text split in 2", len(requests))
+	}
+	for i, request := range requests {
+		if request.To != message.From || request.Context == nil || request.Context.MessageID != message.ID {
+			t.Errorf("message %d to %s with context %+v, want a reply to %s", i+1, request.To, request.Context, message.ID)
+		}
+	}
+}
+
+func TestReactRequiresSender(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("React sent a request for a message without sender")
+		messagesHandler(w, r)
+	})
+
+	message := &WebhookMessage{ID: "wamid.in", Type: MessageTypeText}
+	if _, err := message.React(context.Background(), client, "👍"); err == nil || !strings.Contains(err.Error(), "sender") {
+		t.Errorf("React() error = %v, want an error about the empty sender", err)
+	}
+}
@@ -0,0 +1,167 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// callbackDataKey is the context key of the callback data.
+type callbackDataKey struct{}
+
+// ContextWithCallbackData returns a context that makes the messages sent with
+// it carry data as biz_opaque_callback_data, unless the request sets its own.
+// The data is returned in the status notifications of the messages, e.g. to
+// aggregate them by campaign with a Reporter.
+//
+// Example usage:
+//
+//	ctx := ContextWithCallbackData(ctx, "spring-sale")
+//	results := client.SendTemplateToMany(ctx, recipients, template, 0)
+func ContextWithCallbackData(ctx context.Context, data string) context.Context {
+	return context.WithValue(ctx, callbackDataKey{}, data)
+}
+
+// CallbackDataFromContext returns the callback data of ctx, if any.
+func CallbackDataFromContext(ctx context.Context) (string, bool) {
+	data, ok := ctx.Value(callbackDataKey{}).(string)
+	return data, ok && data != ""
+}
+
+// DeliveryStats are the delivery metrics of the messages of a campaign.
+type DeliveryStats struct {
+	// Campaign is the biz_opaque_callback_data of the messages, empty for untagged messages.
+	Campaign string `json:"campaign"`
+	// Sent, Delivered, Read and Failed are the numbers of status notifications of each status.
+	Sent      int `json:"sent"`
+	Delivered int `json:"delivered"`
+	Read      int `json:"read"`
+	Failed    int `json:"failed"`
+	// ErrorCodes are the numbers of failures by error code.
+	ErrorCodes map[int]int `json:"error_codes,omitempty"`
+}
+
+// Reporter aggregates the status notifications of sent messages into delivery
+// metrics per campaign, taken from the biz_opaque_callback_data of the
+// messages, see ContextWithCallbackData. It is an EventSink, and is safe for
+// concurrent use. Every notification is counted, so redelivered notifications
+// should be filtered by the replay protection of the Webhook.
+//
+// Example usage:
+//
+//	reporter := NewReporter()
+//	webhook.EventSink = reporter
+//	...
+//	stats := reporter.Stats("spring-sale")
+//	log.Printf("%d of %d messages delivered, %d read", stats.Delivered, stats.Sent, stats.Read)
+type Reporter struct {
+	mu        sync.Mutex
+	campaigns map[string]*DeliveryStats
+}
+
+// NewReporter creates a Reporter without statistics.
+func NewReporter() *Reporter {
+	return &Reporter{campaigns: make(map[string]*DeliveryStats)}
+}
+
+// WriteEvent implements EventSink by recording the statuses of request.
+func (r *Reporter) WriteEvent(ctx context.Context, request *WebhookRequest) error {
+	for _, status := range request.Statuses() {
+		r.Record(status.WebhookStatus)
+	}
+	return nil
+}
+
+// Record adds a status notification to the statistics of its campaign.
+// Statuses other than sent, delivered, read and failed are ignored.
+func (r *Reporter) Record(status *WebhookStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.campaigns[status.BizOpaqueCallbackData]
+	if stats == nil {
+		stats = &DeliveryStats{Campaign: status.BizOpaqueCallbackData}
+		r.campaigns[status.BizOpaqueCallbackData] = stats
+	}
+	switch status.Status {
+	case MessageStatusSent:
+		stats.Sent++
+	case MessageStatusDelivered:
+		stats.Delivered++
+	case MessageStatusRead:
+		stats.Read++
+	case MessageStatusFailed:
+		stats.Failed++
+		if stats.ErrorCodes == nil {
+			stats.ErrorCodes = make(map[int]int)
+		}
+		for _, err := range status.Errors {
+			stats.ErrorCodes[err.Code]++
+		}
+	}
+}
+
+// Stats returns the statistics of the campaign.
+func (r *Reporter) Stats(campaign string) DeliveryStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stats, ok := r.campaigns[campaign]; ok {
+		return stats.clone()
+	}
+	return DeliveryStats{Campaign: campaign}
+}
+
+// Report returns the statistics of all campaigns, sorted by campaign.
+func (r *Reporter) Report() []DeliveryStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := make([]DeliveryStats, 0, len(r.campaigns))
+	for _, campaign := range slices.Sorted(maps.Keys(r.campaigns)) {
+		report = append(report, r.campaigns[campaign].clone())
+	}
+	return report
+}
+
+// Reset removes the statistics of all campaigns.
+func (r *Reporter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.campaigns)
+}
+
+// WriteCSV writes the report as CSV with a header line, one line per
+// campaign. The error codes are written as code:count pairs separated by spaces.
+func (r *Reporter) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"campaign", "sent", "delivered", "read", "failed", "error_codes"})
+	for _, stats := range r.Report() {
+		var codes []byte
+		for _, code := range slices.Sorted(maps.Keys(stats.ErrorCodes)) {
+			if len(codes) > 0 {
+				codes = append(codes, ' ')
+			}
+			codes = fmt.Appendf(codes, "%d:%d", code, stats.ErrorCodes[code])
+		}
+		cw.Write([]string{
+			stats.Campaign,
+			strconv.Itoa(stats.Sent),
+			strconv.Itoa(stats.Delivered),
+			strconv.Itoa(stats.Read),
+			strconv.Itoa(stats.Failed),
+			string(codes),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// clone returns a copy of the statistics that doesn't share the error codes.
+func (s *DeliveryStats) clone() DeliveryStats {
+	clone := *s
+	clone.ErrorCodes = maps.Clone(s.ErrorCodes)
+	return clone
+}
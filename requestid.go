@@ -0,0 +1,97 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// DefaultRequestIDHeader is the header of the request IDs of a client
+// configured with WithRequestIDHeader without one.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a context that makes the API requests made
+// with it carry id, e.g. the ID of the incoming request being served, in the
+// RequestIDHeader of the client. The ID is also set on the *GraphError of a
+// failed request.
+//
+// Example usage:
+//
+//	ctx := ContextWithRequestID(r.Context(), r.Header.Get("X-Request-ID"))
+//	_, err := client.SendText(ctx, "1234567890", &SendTextParams{Body: "Hello!"})
+//	var graphErr *GraphError
+//	if errors.As(err, &graphErr) {
+//	    log.Printf("request %s failed, fbtrace_id %s: %v", graphErr.RequestID, graphErr.FBTraceID, err)
+//	}
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithUserAgent sets the User-Agent header of every API request, so the
+// traffic of a service can be identified, e.g. in the logs of an egress proxy.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithUserAgent("orders-service/1.4"))
+func WithUserAgent(userAgent string) ClientOption {
+	return func(wa *Client) {
+		wa.UserAgent = userAgent
+	}
+}
+
+// WithRequestIDHeader sends the request ID of every API request in header,
+// DefaultRequestIDHeader if empty. The ID is the one of the context, see
+// ContextWithRequestID, or a random one. It is passed to interceptors in
+// RequestInfo, and set on the *GraphError of failed requests, so the
+// FBTraceID of Meta can be matched with the logs of the application.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithRequestIDHeader(""), WithAfterResponse(
+//	    func(resp *http.Response, info *RequestInfo, err error) {
+//	        if err == nil {
+//	            log.Printf("%s %s: %s (request %s)", info.Method, info.URL, resp.Status, info.RequestID)
+//	        }
+//	    }))
+func WithRequestIDHeader(header string) ClientOption {
+	return func(wa *Client) {
+		wa.RequestIDHeader = orDefault(header, DefaultRequestIDHeader)
+	}
+}
+
+// withRequestID returns ctx with a random request ID, unless it has one
+// already. Operations retrying a request call it before the first attempt, so
+// that all attempts carry the same ID.
+func withRequestID(ctx context.Context) context.Context {
+	if _, ok := RequestIDFromContext(ctx); ok {
+		return ctx
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return ContextWithRequestID(ctx, hex.EncodeToString(id))
+}
+
+// attemptKey is the context key of the number of the attempt to send a request.
+type attemptKey struct{}
+
+// withAttempt returns ctx making its requests attempt number attempt.
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// attemptFromContext returns the number of the attempt of the requests made with ctx.
+func attemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
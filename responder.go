@@ -0,0 +1,166 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultResponderMaxTurns is the number of turns of a conversation a
+	// ResponderBot passes to its Responder, if its MaxTurns is not set.
+	DefaultResponderMaxTurns = 20
+	// DefaultResponderIdleTimeout is the IdleTimeout of the History of a
+	// ResponderBot without one. It matches the customer service window.
+	DefaultResponderIdleTimeout = 24 * time.Hour
+)
+
+// ConversationRole is the author of a turn of a conversation.
+type ConversationRole string
+
+const (
+	// ConversationRoleUser marks the turns of the WhatsApp user.
+	ConversationRoleUser ConversationRole = "user"
+	// ConversationRoleAssistant marks the turns of the business.
+	ConversationRoleAssistant ConversationRole = "assistant"
+)
+
+// ConversationTurn is a message of a conversation between a user and the business.
+type ConversationTurn struct {
+	Role ConversationRole `json:"role"`
+	Text string           `json:"text"`
+	Time time.Time        `json:"time"`
+	// MessageID is the WhatsApp message ID, if known.
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// Responder produces the reply to the last turn of a conversation, e.g. by
+// prompting a language model with the conversation. It is the only thing
+// a bot built with ResponderBot implements.
+type Responder interface {
+	Prompt(ctx context.Context, conversation []ConversationTurn) (string, error)
+}
+
+// ResponderFunc is a function type that implements the Responder interface.
+type ResponderFunc func(ctx context.Context, conversation []ConversationTurn) (string, error)
+
+// Prompt calls the function with the given parameters.
+func (f ResponderFunc) Prompt(ctx context.Context, conversation []ConversationTurn) (string, error) {
+	return f(ctx, conversation)
+}
+
+// InboundText returns the text of message as a user would read it: the body
+// of texts, the title of button and list replies, the caption of media, and
+// the name and address of locations, with surrounding whitespace removed. It
+// is empty for messages without text.
+func InboundText(message *WebhookMessage) string {
+	if reply := ExtractReply(message); reply != nil {
+		return strings.TrimSpace(reply.Title)
+	}
+	message = message.Unwrap()
+	switch message.Type {
+	case MessageTypeText:
+		if message.Text != nil {
+			return strings.TrimSpace(message.Text.Body)
+		}
+	case MessageTypeImage, MessageTypeVideo, MessageTypeDocument:
+		if media := messageMedia(message); media != nil {
+			return strings.TrimSpace(media.Caption)
+		}
+	case MessageTypeLocation:
+		if message.Location != nil {
+			return strings.TrimSpace(strings.Join([]string{message.Location.Name, message.Location.Address}, "\n"))
+		}
+	}
+	return ""
+}
+
+// ResponderBot is a MessageHandler answering the text of incoming messages
+// with the replies of a Responder. It records the conversations in a
+// History, passes the recent turns to the Responder, and sends the reply
+// converted with FromMarkdown and split into messages of MaxTextBodyLength
+// characters. Group messages and messages without text are ignored.
+//
+// Example usage:
+//
+//	bot := NewResponderBot(client, ResponderFunc(func(ctx context.Context, conversation []ConversationTurn) (string, error) {
+//	    return llm.Complete(ctx, systemPrompt, conversation)
+//	}))
+//	router.NotFound = bot
+type ResponderBot struct {
+	// Client sends the replies.
+	Client *Client
+	// Responder produces the replies.
+	Responder Responder
+	// History records the conversations. If nil, the bot keeps the turns it
+	// passes to the Responder in a History of its own, forgetting them after
+	// DefaultResponderIdleTimeout.
+	History *History
+	// MaxTurns is the number of recent turns passed to the Responder,
+	// DefaultResponderMaxTurns if zero.
+	MaxTurns int
+	// MaxTokens, if positive, limits the recent turns passed to the Responder
+	// to the tokens estimated by EstimateTokens.
+	MaxTokens int
+	// Format converts the replies to WhatsApp formatting, FromMarkdown if nil.
+	Format func(reply string) string
+
+	once    sync.Once
+	history *History // history is History, or the bot's own History if it is nil.
+}
+
+// NewResponderBot creates a ResponderBot sending the replies of responder with client.
+func NewResponderBot(client *Client, responder Responder) *ResponderBot {
+	return &ResponderBot{Client: client, Responder: responder}
+}
+
+// HandleMessage implements MessageHandler.
+func (b *ResponderBot) HandleMessage(ctx context.Context, message *IncomingMessage) error {
+	if InboundText(message.WebhookMessage) == "" || message.IsGroup() {
+		return nil
+	}
+	history := b.conversations()
+	history.AddIncoming(message)
+	conversation := history.Window(message.From, orDefault(b.MaxTurns, DefaultResponderMaxTurns), b.MaxTokens)
+	reply, err := b.Responder.Prompt(ctx, conversation)
+	if err != nil {
+		return fmt.Errorf("prompting responder for %s: %w", message.From, err)
+	}
+	format := b.Format
+	if format == nil {
+		format = FromMarkdown
+	}
+	if reply = strings.TrimSpace(format(reply)); reply == "" {
+		return nil
+	}
+	for _, chunk := range SplitText(reply, MaxTextBodyLength) {
+		response, err := b.Client.SendText(ctx, message.From, &SendTextParams{Body: chunk})
+		if err != nil {
+			return fmt.Errorf("sending reply to %s: %w", message.From, err)
+		}
+		turn := ConversationTurn{Role: ConversationRoleAssistant, Text: chunk}
+		if len(response.Messages) > 0 {
+			turn.MessageID = response.Messages[0].ID
+		}
+		history.Add(message.From, turn)
+	}
+	return nil
+}
+
+// PurgeUser implements UserPurger by forgetting the conversation with the user.
+func (b *ResponderBot) PurgeUser(ctx context.Context, waID string) error {
+	return b.conversations().PurgeUser(ctx, waID)
+}
+
+// conversations returns the History recording the conversations.
+func (b *ResponderBot) conversations() *History {
+	b.once.Do(func() {
+		b.history = b.History
+		if b.history == nil {
+			b.history = &History{MaxTurns: orDefault(b.MaxTurns, DefaultResponderMaxTurns), IdleTimeout: DefaultResponderIdleTimeout}
+		}
+	})
+	return b.history
+}
@@ -0,0 +1,198 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MessageHandler handles an incoming message routed by a Router.
+type MessageHandler interface {
+	HandleMessage(ctx context.Context, message *IncomingMessage) error
+}
+
+// MessageHandlerFunc is a function type that implements the MessageHandler interface.
+type MessageHandlerFunc func(ctx context.Context, message *IncomingMessage) error
+
+// HandleMessage calls the function with the given parameters.
+func (f MessageHandlerFunc) HandleMessage(ctx context.Context, message *IncomingMessage) error {
+	return f(ctx, message)
+}
+
+// MessageMiddleware wraps a MessageHandler with behavior shared by all routes,
+// such as logging or auto-replies. See Router.Use.
+type MessageMiddleware func(next MessageHandler) MessageHandler
+
+// MessagePredicate reports whether a route applies to a message.
+type MessagePredicate func(message *IncomingMessage) bool
+
+// Router dispatches incoming messages to the handler of the first route whose
+// predicate matches, so handlers are registered declaratively instead of in a
+// single switch statement. It implements WebhookChangeHandler, so every
+// message of a notification is routed in isolation, and changes of other
+// fields, such as account updates, are passed to the handlers registered with
+// HandleField. Routes and middleware must be registered before the router
+// handles messages.
+//
+// Example usage:
+//
+//	router := NewRouter()
+//	router.HandleFunc(OnText("stop", "unsubscribe"), unsubscribe)
+//	router.HandleFunc(OnTextMatching(regexp.MustCompile(`(?i)order #?(\d+)`)), orderStatus)
+//	router.HandleFunc(OnButtonID("confirm"), confirmOrder)
+//	router.HandleFunc(OnListRowPrefix("product:"), showProduct)
+//	router.NotFound = MessageHandlerFunc(showMenu)
+//	router.HandleField(WebhookFieldAccountUpdate, WebhookChangeHandlerFunc(alertCompliance))
+//
+//	webhook := NewWebhook(verifyToken, appSecret, nil)
+//	webhook.ChangeHandler = router
+type Router struct {
+	// NotFound handles the messages no route matched. They are ignored if it's nil.
+	NotFound MessageHandler
+
+	routes     []route
+	middleware []MessageMiddleware
+	fields     map[string]WebhookChangeHandler
+}
+
+// route is a handler registered with a Router.
+type route struct {
+	predicate MessagePredicate
+	handler   MessageHandler
+}
+
+// NewRouter creates a router without routes.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers handler for the messages matching predicate. Routes are
+// tried in registration order.
+func (r *Router) Handle(predicate MessagePredicate, handler MessageHandler) {
+	r.routes = append(r.routes, route{predicate: predicate, handler: handler})
+}
+
+// HandleFunc registers the handler function for the messages matching predicate.
+func (r *Router) HandleFunc(predicate MessagePredicate, handler func(ctx context.Context, message *IncomingMessage) error) {
+	r.Handle(predicate, MessageHandlerFunc(handler))
+}
+
+// HandleField registers handler for the changes of field, e.g.
+// WebhookFieldAccountUpdate. Changes of fields without a handler are ignored,
+// except messages, which are routed by the routes of the router.
+//
+// Example usage:
+//
+//	router.HandleField(WebhookFieldAccountUpdate, WebhookChangeHandlerFunc(func(ctx context.Context, entry *WebhookEntry, change *WebhookChange) error {
+//	    if change.Value.Event == AccountUpdateEventAccountRestriction {
+//	        return pager.Alert(ctx, "WhatsApp account %s restricted", entry.ID)
+//	    }
+//	    return nil
+//	}))
+func (r *Router) HandleField(field string, handler WebhookChangeHandler) {
+	if r.fields == nil {
+		r.fields = make(map[string]WebhookChangeHandler)
+	}
+	r.fields[field] = handler
+}
+
+// Use adds middleware wrapping the routing of every message. The first
+// middleware added is the outermost.
+func (r *Router) Use(middleware ...MessageMiddleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// HandleMessage passes message through the middleware to the handler of the
+// first matching route, or to NotFound.
+func (r *Router) HandleMessage(ctx context.Context, message *IncomingMessage) error {
+	var handler MessageHandler = MessageHandlerFunc(r.route)
+	for _, middleware := range slices.Backward(r.middleware) {
+		handler = middleware(handler)
+	}
+	return handler.HandleMessage(ctx, message)
+}
+
+// route calls the handler of the first route matching message.
+func (r *Router) route(ctx context.Context, message *IncomingMessage) error {
+	for _, route := range r.routes {
+		if route.predicate(message) {
+			return route.handler.HandleMessage(ctx, message)
+		}
+	}
+	if r.NotFound != nil {
+		return r.NotFound.HandleMessage(ctx, message)
+	}
+	return nil
+}
+
+// HandleWebhookChange passes change to the handler of its field, if any, and
+// routes every message of change otherwise. All messages are routed even if
+// some fail, and the errors are returned joined.
+func (r *Router) HandleWebhookChange(ctx context.Context, entry *WebhookEntry, change *WebhookChange) error {
+	if handler, ok := r.fields[change.Field]; ok {
+		return handler.HandleWebhookChange(ctx, entry, change)
+	}
+	value := &change.Value
+	var errs []error
+	for i := range value.Messages {
+		message := newIncomingMessage(entry, value, &value.Messages[i])
+		if err := r.HandleMessage(ctx, &message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OnType matches messages of any of the types. Ephemeral messages match the
+// type of the message they wrap.
+func OnType(types ...MessageType) MessagePredicate {
+	return func(message *IncomingMessage) bool {
+		return slices.Contains(types, message.Unwrap().Type)
+	}
+}
+
+// OnText matches text messages whose body is one of the keywords, ignoring
+// case and surrounding whitespace.
+func OnText(keywords ...string) MessagePredicate {
+	return func(message *IncomingMessage) bool {
+		inner := message.Unwrap()
+		if inner.Type != MessageTypeText || inner.Text == nil {
+			return false
+		}
+		body := strings.TrimSpace(inner.Text.Body)
+		return slices.ContainsFunc(keywords, func(keyword string) bool {
+			return strings.EqualFold(body, keyword)
+		})
+	}
+}
+
+// OnTextMatching matches text messages whose body matches re.
+func OnTextMatching(re *regexp.Regexp) MessagePredicate {
+	return func(message *IncomingMessage) bool {
+		inner := message.Unwrap()
+		return inner.Type == MessageTypeText && inner.Text != nil && re.MatchString(inner.Text.Body)
+	}
+}
+
+// OnButtonID matches taps on reply buttons of interactive messages, and on
+// quick reply buttons of templates, with any of the IDs or payloads.
+func OnButtonID(ids ...string) MessagePredicate {
+	return func(message *IncomingMessage) bool {
+		reply := ExtractReply(message.WebhookMessage)
+		if reply == nil || reply.Source == ReplySourceList {
+			return false
+		}
+		return slices.Contains(ids, reply.ID)
+	}
+}
+
+// OnListRowPrefix matches list replies whose row ID starts with prefix, so
+// rows can carry parameters, e.g. "product:" matches "product:1234".
+func OnListRowPrefix(prefix string) MessagePredicate {
+	return func(message *IncomingMessage) bool {
+		reply := ExtractReply(message.WebhookMessage)
+		return reply != nil && reply.Source == ReplySourceList && strings.HasPrefix(reply.ID, prefix)
+	}
+}
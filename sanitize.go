@@ -0,0 +1,90 @@
+package whatsapp
+
+import (
+	"strings"
+)
+
+const (
+	// MaxListButtonTextLength is the maximum length of the button text of a list message in characters.
+	MaxListButtonTextLength = 20
+	// MaxListSectionTitleLength is the maximum length of a list section title in characters.
+	MaxListSectionTitleLength = 24
+	// MaxListRowTitleLength is the maximum length of a list row title in characters.
+	MaxListRowTitleLength = 24
+	// MaxListRowDescriptionLength is the maximum length of a list row description in characters.
+	MaxListRowDescriptionLength = 72
+)
+
+// WithInteractiveSanitizer makes the client pass interactive messages through
+// SanitizeInteractive before sending them, so texts built from dynamic content,
+// such as product names, are shortened instead of rejected.
+func WithInteractiveSanitizer() ClientOption {
+	return func(wa *Client) {
+		wa.SanitizeInteractive = true
+	}
+}
+
+// SanitizeInteractive returns a copy of interactive whose texts fit the limits
+// of the API: the header, body and footer, reply button titles, and the list
+// button text, section titles, row titles and row descriptions. Longer texts
+// are cut at a character boundary and end with an ellipsis. Line breaks and
+// tabs in titles, which the API rejects, are replaced with spaces. IDs are
+// never changed, since replies refer to them. The original is not modified.
+//
+// Example usage:
+//
+//	row := ListRow{ID: product.SKU, Title: product.Name, Description: product.Summary}
+//	...
+//	interactive = SanitizeInteractive(interactive)
+func SanitizeInteractive(interactive *Interactive) *Interactive {
+	if interactive == nil {
+		return nil
+	}
+	sanitized := *interactive
+	if header := interactive.Header; header != nil && header.Type == HeaderTypeText {
+		sanitized.Header = &Header{Type: header.Type, Text: TruncateText(header.Text, MaxInteractiveHeaderTextLength)}
+	}
+	if interactive.Body != nil {
+		sanitized.Body = &Body{Text: TruncateText(interactive.Body.Text, MaxInteractiveBodyLength)}
+	}
+	if interactive.Footer != nil {
+		sanitized.Footer = &Footer{Text: TruncateText(interactive.Footer.Text, MaxInteractiveFooterLength)}
+	}
+	if interactive.Action == nil {
+		return &sanitized
+	}
+
+	action := *interactive.Action
+	action.Button = sanitizeTitle(action.Button, MaxListButtonTextLength)
+	if action.Buttons != nil {
+		action.Buttons = make([]Button, len(interactive.Action.Buttons))
+		for i, button := range interactive.Action.Buttons {
+			if button.Reply != nil {
+				button.Reply = &ReplyButton{ID: button.Reply.ID, Title: sanitizeTitle(button.Reply.Title, MaxReplyButtonTitleLength)}
+			}
+			action.Buttons[i] = button
+		}
+	}
+	if action.Sections != nil {
+		action.Sections = make([]ListSection, len(interactive.Action.Sections))
+		for i, section := range interactive.Action.Sections {
+			rows := make([]ListRow, len(section.Rows))
+			for j, row := range section.Rows {
+				row.Title = sanitizeTitle(row.Title, MaxListRowTitleLength)
+				row.Description = TruncateText(row.Description, MaxListRowDescriptionLength)
+				rows[j] = row
+			}
+			action.Sections[i] = ListSection{Title: sanitizeTitle(section.Title, MaxListSectionTitleLength), Rows: rows}
+		}
+	}
+	sanitized.Action = &action
+	return &sanitized
+}
+
+// titleReplacer replaces the whitespace titles must not contain.
+var titleReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// sanitizeTitle puts title on a single line and truncates it to limit characters.
+func sanitizeTitle(title string, limit int) string {
+	return TruncateText(strings.TrimSpace(titleReplacer.Replace(title)), limit)
+}
@@ -0,0 +1,320 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSchedulerPollInterval is the default interval the Scheduler checks for due messages in.
+const DefaultSchedulerPollInterval = time.Second
+
+// ErrWindowClosed is reported for a scheduled message whose recipient is outside
+// the 24-hour customer service window when the Scheduler has no Fallback.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/guides/send-messages#customer-service-windows
+var ErrWindowClosed = errors.New("customer service window is closed")
+
+// ScheduledMessage is a message waiting to be sent by a Scheduler.
+type ScheduledMessage struct {
+	// ID identifies the scheduled message in the store.
+	ID string
+	// SendAt is the earliest time the message is sent.
+	SendAt time.Time
+	// Request is the message to send.
+	Request *Request
+	// Sealed is the encrypted request of messages stored by an
+	// EncryptedScheduleStore, whose Request is nil.
+	Sealed []byte
+}
+
+// ScheduleStore persists the messages of a Scheduler, so they survive restarts.
+type ScheduleStore interface {
+	// Put adds the message, or replaces the message with the same ID.
+	Put(ctx context.Context, message *ScheduledMessage) error
+	// Due returns up to limit messages with SendAt not after now, earliest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]*ScheduledMessage, error)
+	// Delete removes the message with the given ID. Deleting an unknown ID is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// Scheduler sends messages at a given time. It keeps a minimum interval
+// between messages to the same recipient and checks the 24-hour customer
+// service window before sending free-form messages.
+//
+// Example usage:
+//
+//	scheduler := NewScheduler(client, NewMemoryScheduleStore())
+//	scheduler.RecipientInterval = 6 * time.Second
+//	scheduler.WindowOpen = func(ctx context.Context, recipient string) bool {
+//	    return time.Since(lastInbound[recipient]) < 24*time.Hour
+//	}
+//	scheduler.OnResult = func(message *ScheduledMessage, response *MessagesResponse, err error) {
+//	    if err != nil {
+//	        log.Printf("Scheduled message failed: %v", err)
+//	    }
+//	}
+//	go scheduler.Run(ctx)
+//
+//	id, err := scheduler.Schedule(ctx, time.Now().Add(time.Hour), &Request{
+//	    MessagingProduct: MessagingProductWhatsApp,
+//	    RecipientType:    RecipientTypeIndividual,
+//	    To:               "1234567890",
+//	    Type:             MessageTypeText,
+//	    Text:             &SendTextParams{Body: "Your appointment starts in one hour."},
+//	})
+type Scheduler struct {
+	// Client sends the messages.
+	Client *Client
+	// Store keeps the scheduled messages.
+	Store ScheduleStore
+	// PollInterval is the interval due messages are checked in. Zero means DefaultSchedulerPollInterval.
+	PollInterval time.Duration
+	// RecipientInterval is the minimum time between two messages to the same recipient.
+	// Messages due earlier are postponed. Zero disables the limit.
+	RecipientInterval time.Duration
+	// WindowOpen, if set, reports whether the customer service window of the recipient is open.
+	// Template messages are sent regardless of the window.
+	WindowOpen func(ctx context.Context, recipient string) bool
+	// Fallback, if set, returns the message to send instead of a free-form message
+	// whose recipient's window is closed, typically a template message.
+	Fallback func(ctx context.Context, message *ScheduledMessage) (*Request, error)
+	// OnResult, if set, is called after every attempt to send a due message.
+	// The message is not retried after a failure. Store errors are reported
+	// with a nil message.
+	OnResult func(message *ScheduledMessage, response *MessagesResponse, err error)
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+	stop     chan struct{} // stop is closed by Shutdown.
+	done     chan struct{} // done is closed when the loop started by Start returned.
+}
+
+// NewScheduler creates a scheduler sending the messages of store with client.
+func NewScheduler(client *Client, store ScheduleStore) *Scheduler {
+	return &Scheduler{Client: client, Store: store}
+}
+
+// Schedule stores request to be sent at sendAt and returns the ID of the scheduled message.
+func (s *Scheduler) Schedule(ctx context.Context, sendAt time.Time, request *Request) (string, error) {
+	if request == nil {
+		return "", fmt.Errorf("request cannot be nil")
+	}
+	var id [16]byte
+	rand.Read(id[:])
+	message := &ScheduledMessage{ID: hex.EncodeToString(id[:]), SendAt: sendAt, Request: request}
+	if err := s.Store.Put(ctx, message); err != nil {
+		return "", fmt.Errorf("storing scheduled message: %w", err)
+	}
+	return message.ID, nil
+}
+
+// Cancel removes a scheduled message that was not sent yet.
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	return s.Store.Delete(ctx, id)
+}
+
+// Run sends due messages until ctx is done, and returns the context error.
+func (s *Scheduler) Run(ctx context.Context) error {
+	return s.run(ctx, nil)
+}
+
+// Start implements Lifecycle. It sends due messages in the background until
+// ctx is done or Shutdown is called.
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return fmt.Errorf("scheduler already started")
+	}
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		s.run(ctx, stop)
+	}(s.stop, s.done)
+	return nil
+}
+
+// Shutdown implements Lifecycle. It stops the scheduler started by Start after
+// the message being sent, if any. Messages that are due but not sent yet stay
+// in the store.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	if stop != nil {
+		select {
+		case <-stop:
+		default:
+			close(stop)
+		}
+	}
+	s.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run sends due messages until ctx is done or stop is closed.
+func (s *Scheduler) run(ctx context.Context, stop <-chan struct{}) error {
+	ticker := time.NewTicker(orDefault(s.PollInterval, DefaultSchedulerPollInterval))
+	defer ticker.Stop()
+	for {
+		if err := s.dispatchDue(ctx, stop); err != nil && ctx.Err() == nil && s.OnResult != nil {
+			s.OnResult(nil, nil, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchDue sends all messages that are due now, until stop is closed.
+func (s *Scheduler) dispatchDue(ctx context.Context, stop <-chan struct{}) error {
+	const batchSize = 100
+	for {
+		now := time.Now()
+		due, err := s.Store.Due(ctx, now, batchSize)
+		if err != nil {
+			return fmt.Errorf("loading due messages: %w", err)
+		}
+		for _, message := range due {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			if err := s.dispatch(ctx, message, now); err != nil {
+				return err
+			}
+		}
+		if len(due) < batchSize {
+			return nil
+		}
+	}
+}
+
+// dispatch sends a due message, or postpones it if its recipient was messaged too recently.
+func (s *Scheduler) dispatch(ctx context.Context, message *ScheduledMessage, now time.Time) error {
+	recipient := message.Request.To
+	if next, ok := s.nextAllowed(recipient, now); !ok {
+		postponed := *message
+		postponed.SendAt = next
+		if err := s.Store.Put(ctx, &postponed); err != nil {
+			return fmt.Errorf("postponing scheduled message: %w", err)
+		}
+		return nil
+	}
+
+	response, sendErr := s.send(ctx, message)
+	if err := s.Store.Delete(ctx, message.ID); err != nil {
+		return fmt.Errorf("deleting scheduled message: %w", err)
+	}
+	if s.OnResult != nil {
+		s.OnResult(message, response, sendErr)
+	}
+	return nil
+}
+
+// send sends the message, or its fallback if the recipient's window is closed.
+func (s *Scheduler) send(ctx context.Context, message *ScheduledMessage) (*MessagesResponse, error) {
+	request := message.Request
+	if request.Type != MessageTypeTemplate && s.WindowOpen != nil && !s.WindowOpen(ctx, request.To) {
+		if s.Fallback == nil {
+			return nil, ErrWindowClosed
+		}
+		fallback, err := s.Fallback(ctx, message)
+		if err != nil {
+			return nil, fmt.Errorf("building fallback message: %w", err)
+		}
+		request = fallback
+	}
+	return s.Client.SendMessage(ctx, request)
+}
+
+// nextAllowed reserves a send to recipient at now if the recipient interval allows it.
+// Otherwise it returns the earliest time a message may be sent.
+func (s *Scheduler) nextAllowed(recipient string, now time.Time) (time.Time, bool) {
+	if s.RecipientInterval <= 0 {
+		return now, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastSent == nil {
+		s.lastSent = make(map[string]time.Time)
+	}
+	if next := s.lastSent[recipient].Add(s.RecipientInterval); now.Before(next) {
+		return next, false
+	}
+	for r, sent := range s.lastSent {
+		if now.Sub(sent) >= s.RecipientInterval {
+			delete(s.lastSent, r)
+		}
+	}
+	s.lastSent[recipient] = now
+	return now, true
+}
+
+// MemoryScheduleStore is a ScheduleStore keeping messages in memory.
+// Scheduled messages are lost on restart. It is safe for concurrent use.
+type MemoryScheduleStore struct {
+	mu       sync.Mutex
+	messages map[string]*ScheduledMessage
+}
+
+// NewMemoryScheduleStore creates an empty in-memory schedule store.
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{messages: make(map[string]*ScheduledMessage)}
+}
+
+// Put implements ScheduleStore.
+func (s *MemoryScheduleStore) Put(ctx context.Context, message *ScheduledMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *message
+	s.messages[message.ID] = &stored
+	return nil
+}
+
+// Due implements ScheduleStore.
+func (s *MemoryScheduleStore) Due(ctx context.Context, now time.Time, limit int) ([]*ScheduledMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []*ScheduledMessage
+	for _, message := range s.messages {
+		if !message.SendAt.After(now) {
+			stored := *message
+			due = append(due, &stored)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].SendAt.Before(due[j].SendAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Delete implements ScheduleStore.
+func (s *MemoryScheduleStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, id)
+	return nil
+}
@@ -0,0 +1,248 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// webhookObject is the object of WhatsApp Business Account notifications.
+const webhookObject = "whatsapp_business_account"
+
+// SchemaAnomalyKind tells how a notification differs from the shape the package expects.
+type SchemaAnomalyKind string
+
+const (
+	// SchemaUnknownField is a field the package doesn't decode, e.g. a new
+	// field added by Meta. Its value is lost.
+	SchemaUnknownField SchemaAnomalyKind = "unknown_field"
+	// SchemaMissingField is a required field missing from the notification,
+	// such as the ID of a message, or the content of a message of its type.
+	SchemaMissingField SchemaAnomalyKind = "missing_field"
+	// SchemaUnknownObject is an object other than whatsapp_business_account.
+	SchemaUnknownObject SchemaAnomalyKind = "unknown_object"
+	// SchemaUnknownChange is a change of a field the package has no constant for.
+	SchemaUnknownChange SchemaAnomalyKind = "unknown_change"
+)
+
+// SchemaAnomaly is a difference between a notification and the shape the package expects.
+type SchemaAnomaly struct {
+	Kind SchemaAnomalyKind
+	// Path is the path of the field in the notification, e.g.
+	// "entry[0].changes[0].value.messages[0].id".
+	Path string
+	// Value is the unexpected value of unknown objects and changes.
+	Value string
+}
+
+// String returns a description of the anomaly.
+func (a SchemaAnomaly) String() string {
+	if a.Value != "" {
+		return fmt.Sprintf("%s %s: %q", a.Kind, a.Path, a.Value)
+	}
+	return fmt.Sprintf("%s %s", a.Kind, a.Path)
+}
+
+// SchemaAnomalyHandler is called for every anomaly of a notification. See
+// Webhook.OnSchemaAnomaly.
+//
+// Example usage:
+//
+//	webhook.OnSchemaAnomaly = func(ctx context.Context, anomaly SchemaAnomaly) {
+//	    log.Printf("webhook: %s, the Cloud API may have changed", anomaly)
+//	}
+type SchemaAnomalyHandler func(ctx context.Context, anomaly SchemaAnomaly)
+
+// webhookChangeFields are the change fields with a constant.
+var webhookChangeFields = []string{
+	WebhookFieldMessages,
+	WebhookFieldAccountReviewUpdate,
+	WebhookFieldAccountUpdate,
+	WebhookFieldAccountAlerts,
+	WebhookFieldPhoneNumberQualityUpdate,
+}
+
+// messageContentTypes are the message types whose content is in a field named after the type.
+var messageContentTypes = []MessageType{
+	MessageTypeText, MessageTypeImage, MessageTypeAudio, MessageTypeVideo,
+	MessageTypeDocument, MessageTypeSticker, MessageTypeLocation, MessageTypeContacts,
+	MessageTypeButton, MessageTypeInteractive, MessageTypeOrder, MessageTypeSystem,
+	MessageTypeReaction,
+}
+
+// ValidateWebhookPayload checks the JSON body of a notification against the
+// shape the package decodes: fields it doesn't know, required fields that are
+// missing, and objects and changes of unknown kinds. It helps to detect
+// changes of the Cloud API contract early, e.g. on recorded notifications.
+// Anomalies don't prevent decoding, an error is only returned for invalid JSON.
+func ValidateWebhookPayload(body []byte) ([]SchemaAnomaly, error) {
+	var payload any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	var anomalies []SchemaAnomaly
+	report := func(kind SchemaAnomalyKind, path, value string) {
+		anomalies = append(anomalies, SchemaAnomaly{Kind: kind, Path: path, Value: value})
+	}
+	checkUnknownFields(payload, reflect.TypeFor[WebhookRequest](), "", report)
+	checkRequiredFields(payload, report)
+	return anomalies, nil
+}
+
+// checkUnknownFields reports the object keys of value without a field in t.
+func checkUnknownFields(value any, t reflect.Type, path string, report func(kind SchemaAnomalyKind, path, value string)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[json.Unmarshaler]()) {
+		// Custom decoding accepts its own shapes
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			field, ok := fields[key]
+			if !ok {
+				report(SchemaUnknownField, joinPath(path, key), "")
+				continue
+			}
+			checkUnknownFields(object[key], field, joinPath(path, key), report)
+		}
+	case reflect.Slice:
+		if array, ok := value.([]any); ok {
+			for i, element := range array {
+				checkUnknownFields(element, t.Elem(), fmt.Sprintf("%s[%d]", path, i), report)
+			}
+		}
+	case reflect.Map:
+		if object, ok := value.(map[string]any); ok {
+			for _, key := range slices.Sorted(maps.Keys(object)) {
+				checkUnknownFields(object[key], t.Elem(), joinPath(path, key), report)
+			}
+		}
+	}
+}
+
+// jsonFieldCache caches the JSON fields of struct types.
+var jsonFieldCache sync.Map // reflect.Type -> map[string]reflect.Type
+
+// jsonFields returns the types of the fields of struct type t by JSON name,
+// including the fields of embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	if fields, ok := jsonFieldCache.Load(t); ok {
+		return fields.(map[string]reflect.Type)
+	}
+	fields := make(map[string]reflect.Type)
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if field.Anonymous && field.Tag.Get("json") == "" {
+				// The fields of embedded structs are visible fields of their own
+				continue
+			}
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	jsonFieldCache.Store(t, fields)
+	return fields
+}
+
+// checkRequiredFields reports the missing required fields of the notification,
+// and objects and changes of unknown kinds.
+func checkRequiredFields(payload any, report func(kind SchemaAnomalyKind, path, value string)) {
+	request, _ := payload.(map[string]any)
+	if object, _ := request["object"].(string); object != webhookObject {
+		report(SchemaUnknownObject, "object", object)
+	}
+	entries, _ := request["entry"].([]any)
+	for i, entry := range entries {
+		entry, _ := entry.(map[string]any)
+		path := fmt.Sprintf("entry[%d]", i)
+		requireFields(entry, path, report, "id")
+		changes, _ := entry["changes"].([]any)
+		for j, change := range changes {
+			change, _ := change.(map[string]any)
+			path := fmt.Sprintf("%s.changes[%d]", path, j)
+			field, _ := change["field"].(string)
+			switch {
+			case field == "":
+				report(SchemaMissingField, path+".field", "")
+			case !slices.Contains(webhookChangeFields, field):
+				report(SchemaUnknownChange, path+".field", field)
+			}
+			value, _ := change["value"].(map[string]any)
+			if field == WebhookFieldMessages {
+				checkMessagesValue(value, path+".value", report)
+			}
+		}
+	}
+}
+
+// checkMessagesValue reports the missing required fields of the value of a messages change.
+func checkMessagesValue(value map[string]any, path string, report func(kind SchemaAnomalyKind, path, value string)) {
+	requireFields(value, path, report, "messaging_product", "metadata")
+	if metadata, ok := value["metadata"].(map[string]any); ok {
+		requireFields(metadata, path+".metadata", report, "phone_number_id")
+	}
+	messages, _ := value["messages"].([]any)
+	for i, message := range messages {
+		message, _ := message.(map[string]any)
+		path := fmt.Sprintf("%s.messages[%d]", path, i)
+		requireFields(message, path, report, "id", "from", "timestamp", "type")
+		messageType, _ := message["type"].(string)
+		if slices.Contains(messageContentTypes, MessageType(messageType)) {
+			requireFields(message, path, report, messageType)
+		}
+	}
+	statuses, _ := value["statuses"].([]any)
+	for i, status := range statuses {
+		status, _ := status.(map[string]any)
+		requireFields(status, fmt.Sprintf("%s.statuses[%d]", path, i), report, "id", "status", "timestamp", "recipient_id")
+	}
+}
+
+// requireFields reports the keys missing from object, or set to null or an empty string.
+func requireFields(object map[string]any, path string, report func(kind SchemaAnomalyKind, path, value string), keys ...string) {
+	for _, key := range keys {
+		if value, ok := object[key]; !ok || value == nil || value == "" {
+			report(SchemaMissingField, joinPath(path, key), "")
+		}
+	}
+}
+
+// joinPath appends key to the field path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// reportSchemaAnomalies calls OnSchemaAnomaly for every anomaly of body.
+func (wh *Webhook) reportSchemaAnomalies(ctx context.Context, body []byte) {
+	anomalies, err := ValidateWebhookPayload(body)
+	if err != nil {
+		// Decoding the request failed already
+		return
+	}
+	for _, anomaly := range anomalies {
+		wh.OnSchemaAnomaly(ctx, anomaly)
+	}
+}
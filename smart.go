@@ -0,0 +1,104 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// codeReEngagementMessage is the error code of free-form messages sent outside
+// the 24-hour customer service window.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+const codeReEngagementMessage = 131047
+
+// SendPath tells which message SendSmart delivered.
+type SendPath int
+
+const (
+	// SendPathFreeForm means the free-form message was sent.
+	SendPathFreeForm SendPath = iota
+	// SendPathTemplate means the fallback template was sent because the
+	// customer service window was closed.
+	SendPathTemplate
+)
+
+// String returns the name of the path.
+func (p SendPath) String() string {
+	switch p {
+	case SendPathFreeForm:
+		return "free-form"
+	case SendPathTemplate:
+		return "template"
+	default:
+		return "unknown"
+	}
+}
+
+// SmartResponse is the response of SendSmart.
+type SmartResponse struct {
+	*MessagesResponse
+	// Path tells which message was sent.
+	Path SendPath
+	// FreeFormErr is the error of the free-form message if the template was sent instead.
+	FreeFormErr error
+}
+
+// WithFallbackTemplate sets the template SendSmart sends when the customer
+// service window of the recipient is closed.
+func WithFallbackTemplate(params *SendTemplateParams) ClientOption {
+	return func(wa *Client) {
+		wa.FallbackTemplate = params
+	}
+}
+
+// SendSmart sends a free-form message and, if the API rejects it because the
+// recipient's 24-hour customer service window is closed (error 131047), sends
+// the fallback template configured with WithFallbackTemplate to the same
+// recipient instead. Without a fallback template, the error is returned as is.
+//
+// The API reports a closed window synchronously only in some cases; it may
+// also arrive later as a failed status in the status webhook.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithFallbackTemplate(&SendTemplateParams{
+//	    Name:     "reconnect",
+//	    Language: &TemplateLanguage{Code: "en_US"},
+//	}))
+//
+//	response, err := client.SendSmart(ctx, &Request{
+//	    MessagingProduct: MessagingProductWhatsApp,
+//	    RecipientType:    RecipientTypeIndividual,
+//	    To:               "1234567890",
+//	    Type:             MessageTypeText,
+//	    Text:             &SendTextParams{Body: "Your order has shipped!"},
+//	})
+//	if err == nil && response.Path == SendPathTemplate {
+//	    log.Printf("Window closed, sent template instead: %v", response.FreeFormErr)
+//	}
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/guides/send-messages#customer-service-windows
+func (wa *Client) SendSmart(ctx context.Context, request *Request) (*SmartResponse, error) {
+	if request == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	response, err := wa.SendMessage(ctx, request)
+	if err == nil {
+		return &SmartResponse{MessagesResponse: response, Path: SendPathFreeForm}, nil
+	}
+	if wa.FallbackTemplate == nil || !isReEngagementError(err) {
+		return nil, err
+	}
+
+	response, fallbackErr := wa.SendTemplate(ctx, request.To, wa.FallbackTemplate)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("sending fallback template after %w: %w", err, fallbackErr)
+	}
+	return &SmartResponse{MessagesResponse: response, Path: SendPathTemplate, FreeFormErr: err}, nil
+}
+
+// isReEngagementError reports whether err was caused by a closed customer service window.
+func isReEngagementError(err error) bool {
+	var graphErr *GraphError
+	return errors.As(err, &graphErr) && graphErr.Code == codeReEngagementMessage
+}
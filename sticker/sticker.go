@@ -0,0 +1,168 @@
+// Package sticker validates and prepares WhatsApp sticker files before upload,
+// so that sticker messages that would be rejected by the API fail client-side.
+//
+// Example usage:
+//
+//	data, err := os.ReadFile("sticker.png")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	// Convert PNG to WebP with your encoder of choice, then validate and upload
+//	uploaded, err := sticker.Upload(ctx, client, data, sticker.ConverterFunc(encodeWebP))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	_, err = client.SendSticker(ctx, "1234567890", &whatsapp.SendStickerParams{ID: uploaded.ID})
+//
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/media#supported-media-types
+package sticker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/yarcat/whatsapp-go"
+)
+
+const (
+	// Size is the required width and height of a sticker in pixels.
+	Size = 512
+	// MaxStaticSize is the maximum file size of a static sticker (100KB).
+	MaxStaticSize = whatsapp.MaxStickerSize
+	// MaxAnimatedSize is the maximum file size of an animated sticker (500KB).
+	MaxAnimatedSize = 500 * 1024
+
+	// Filename is the name used for uploaded sticker files.
+	Filename = "sticker.webp"
+)
+
+// ErrNotWebP is returned for data that is not a WebP image.
+var ErrNotWebP = errors.New("sticker is not a WebP image")
+
+// Info describes a WebP image.
+type Info struct {
+	Width    int
+	Height   int
+	Animated bool
+}
+
+// DecodeInfo parses the WebP container headers in data and returns the image dimensions.
+// The image data itself is not decoded.
+func DecodeInfo(data []byte) (*Info, error) {
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrNotWebP
+	}
+
+	chunk, payload := string(data[12:16]), data[20:]
+	switch chunk {
+	case "VP8 ":
+		// Lossy: 3 bytes frame tag, 3 bytes start code, then 14-bit width and height
+		if payload[3] != 0x9d || payload[4] != 0x01 || payload[5] != 0x2a {
+			return nil, fmt.Errorf("invalid VP8 start code")
+		}
+		return &Info{
+			Width:  int(binary.LittleEndian.Uint16(payload[6:8]) & 0x3fff),
+			Height: int(binary.LittleEndian.Uint16(payload[8:10]) & 0x3fff),
+		}, nil
+	case "VP8L":
+		// Lossless: signature byte, then 14 bits each of width-1 and height-1
+		if payload[0] != 0x2f {
+			return nil, fmt.Errorf("invalid VP8L signature")
+		}
+		bits := binary.LittleEndian.Uint32(payload[1:5])
+		return &Info{
+			Width:  int(bits&0x3fff) + 1,
+			Height: int(bits>>14&0x3fff) + 1,
+		}, nil
+	case "VP8X":
+		// Extended: flags byte, 3 reserved bytes, then 24 bits each of canvas width-1 and height-1
+		return &Info{
+			Width:    int(uint24(payload[4:7])) + 1,
+			Height:   int(uint24(payload[7:10])) + 1,
+			Animated: payload[0]&0x02 != 0,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported WebP chunk %q", chunk)
+	}
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// Validate checks that data is a 512x512 WebP image within the size limit
+// for static or animated stickers.
+func Validate(data []byte) error {
+	info, err := DecodeInfo(data)
+	if err != nil {
+		return err
+	}
+	if info.Width != Size || info.Height != Size {
+		return fmt.Errorf("sticker must be %dx%d pixels, got %dx%d", Size, Size, info.Width, info.Height)
+	}
+
+	limit := MaxStaticSize
+	if info.Animated {
+		limit = MaxAnimatedSize
+	}
+	if len(data) > limit {
+		return fmt.Errorf("sticker size %d exceeds maximum allowed size %d", len(data), limit)
+	}
+	return nil
+}
+
+// Converter converts image data of another format (e.g. PNG) into a WebP sticker.
+// The package doesn't ship a WebP encoder, so conversion is left to the caller.
+type Converter interface {
+	ConvertToWebP(data []byte, mimeType string) ([]byte, error)
+}
+
+// ConverterFunc is a function type that implements the Converter interface.
+type ConverterFunc func(data []byte, mimeType string) ([]byte, error)
+
+// ConvertToWebP calls the function with the given parameters.
+func (f ConverterFunc) ConvertToWebP(data []byte, mimeType string) ([]byte, error) {
+	return f(data, mimeType)
+}
+
+// Prepare returns data ready to be uploaded as a sticker. Non-WebP data is converted
+// with conv first, and the result is validated with Validate. A nil conv means only
+// WebP input is accepted.
+func Prepare(data []byte, conv Converter) ([]byte, error) {
+	if mimeType := http.DetectContentType(data); mimeType != string(whatsapp.MimeTypeStickerWebP) {
+		if conv == nil {
+			return nil, fmt.Errorf("%w: got %s and no converter is configured", ErrNotWebP, mimeType)
+		}
+		converted, err := conv.ConvertToWebP(data, mimeType)
+		if err != nil {
+			return nil, fmt.Errorf("converting %s to WebP: %w", mimeType, err)
+		}
+		data = converted
+	}
+
+	if err := Validate(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Upload prepares data with Prepare and uploads it as a sticker.
+// The returned media ID can be used in whatsapp.SendStickerParams.
+func Upload(ctx context.Context, wa *whatsapp.Client, data []byte, conv Converter) (*whatsapp.UploadMediaResponse, error) {
+	data, err := Prepare(data, conv)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sticker: %w", err)
+	}
+
+	params, err := whatsapp.NewUploadMediaParams(bytes.NewReader(data), Filename, string(whatsapp.MimeTypeStickerWebP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload params: %w", err)
+	}
+	return wa.UploadMedia(ctx, params)
+}
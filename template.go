@@ -0,0 +1,184 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// TemplateComponentType represents the type of a template component.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/messages#template-object
+type TemplateComponentType string
+
+const (
+	// TemplateComponentTypeHeader represents the header component.
+	TemplateComponentTypeHeader TemplateComponentType = "header"
+	// TemplateComponentTypeBody represents the body component.
+	TemplateComponentTypeBody TemplateComponentType = "body"
+	// TemplateComponentTypeButton represents a button component.
+	TemplateComponentTypeButton TemplateComponentType = "button"
+)
+
+// TemplateParameterType represents the type of a template parameter.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/messages#parameter-object
+type TemplateParameterType string
+
+const (
+	TemplateParameterTypeText     TemplateParameterType = "text"
+	TemplateParameterTypeCurrency TemplateParameterType = "currency"
+	TemplateParameterTypeDateTime TemplateParameterType = "date_time"
+	TemplateParameterTypeImage    TemplateParameterType = "image"
+	TemplateParameterTypeDocument TemplateParameterType = "document"
+	TemplateParameterTypePayload  TemplateParameterType = "payload"
+	TemplateParameterTypeAction   TemplateParameterType = "action"
+)
+
+// SendTemplateParams contains parameters for sending a template message.
+// Template messages are the only messages that can be sent outside the
+// 24-hour customer service window.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/guides/send-message-templates
+type SendTemplateParams struct {
+	// Name is the required name of the approved template.
+	Name string `json:"name"`
+	// Language is the required language of the template.
+	Language *TemplateLanguage `json:"language"`
+	// Components contains the values of the template variables.
+	Components []TemplateComponent `json:"components,omitempty"`
+}
+
+// Validate validates the template message parameters
+func (stp *SendTemplateParams) Validate() error {
+	if stp == nil {
+		return fmt.Errorf("template parameters cannot be nil")
+	}
+	if stp.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if stp.Language == nil || stp.Language.Code == "" {
+		return fmt.Errorf("template language code is required")
+	}
+	for _, component := range stp.Components {
+		if component.Type == TemplateComponentTypeButton && component.SubType == "flow" {
+			if len(component.Parameters) != 1 || component.Parameters[0].Action == nil {
+				return fmt.Errorf("flow button %s needs one action parameter", component.Index)
+			}
+		}
+	}
+	return nil
+}
+
+// FlowButtonComponent returns the component of a template button that opens
+// a flow. The data, if not nil, is the initial data of the first screen of
+// flows with the navigate action, and must marshal to a JSON object. Flows with
+// the data_exchange action get their first screen from the flow endpoint and
+// take no data.
+//
+// Example usage:
+//
+//	button, err := FlowButtonComponent(0, flowToken, AppointmentData{Department: "cardiology"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	params := &SendTemplateParams{
+//	    Name:       "book_appointment",
+//	    Language:   &TemplateLanguage{Code: "en_US"},
+//	    Components: []TemplateComponent{button},
+//	}
+func FlowButtonComponent(index int, flowToken string, data any) (TemplateComponent, error) {
+	action := &TemplateButtonAction{FlowToken: flowToken}
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err == nil {
+			err = json.Unmarshal(encoded, &action.FlowActionData)
+		}
+		if err != nil {
+			return TemplateComponent{}, fmt.Errorf("flow action data must marshal to a JSON object: %w", err)
+		}
+	}
+	return TemplateComponent{
+		Type:       TemplateComponentTypeButton,
+		SubType:    "flow",
+		Index:      strconv.Itoa(index),
+		Parameters: []TemplateParameter{{Type: TemplateParameterTypeAction, Action: action}},
+	}, nil
+}
+
+// TemplateLanguage specifies the language of a template message.
+type TemplateLanguage struct {
+	// Code is the language and locale code, e.g. "en_US".
+	Code string `json:"code"`
+	// Policy is the language policy. The only supported value is "deterministic".
+	Policy string `json:"policy,omitempty"`
+}
+
+// TemplateComponent contains the parameters of a template component.
+type TemplateComponent struct {
+	Type TemplateComponentType `json:"type"`
+	// SubType is the button type of button components, e.g. "quick_reply" or "url".
+	SubType string `json:"sub_type,omitempty"`
+	// Index is the position of the button in button components, starting at "0".
+	Index      string              `json:"index,omitempty"`
+	Parameters []TemplateParameter `json:"parameters,omitempty"`
+}
+
+// TemplateParameter is the value of a template variable.
+type TemplateParameter struct {
+	Type TemplateParameterType `json:"type"`
+	// ParameterName is the name of the variable in templates with named parameters.
+	ParameterName string              `json:"parameter_name,omitempty"`
+	Text          string              `json:"text,omitempty"`
+	Payload       string              `json:"payload,omitempty"`
+	Currency      *TemplateCurrency   `json:"currency,omitempty"`
+	DateTime      *TemplateDateTime   `json:"date_time,omitempty"`
+	Image         *SendImageParams    `json:"image,omitempty"`
+	Document      *SendDocumentParams `json:"document,omitempty"`
+	// Action is the parameter of flow buttons.
+	Action *TemplateButtonAction `json:"action,omitempty"`
+}
+
+// TemplateButtonAction is the parameter of a button that opens a flow.
+// https://developers.facebook.com/docs/whatsapp/flows/guides/sendingaflow#templatemessages
+type TemplateButtonAction struct {
+	// FlowToken identifies the flow session. Defaults to "unused" when empty.
+	FlowToken string `json:"flow_token,omitempty"`
+	// FlowActionData is the initial data of the first screen of navigate flows.
+	FlowActionData map[string]any `json:"flow_action_data,omitempty"`
+}
+
+// TemplateCurrency is the value of a currency parameter.
+type TemplateCurrency struct {
+	// FallbackValue is shown where the currency can't be localized by the client.
+	FallbackValue string `json:"fallback_value"`
+	// Code is the ISO 4217 currency code.
+	Code string `json:"code"`
+	// Amount1000 is the amount multiplied by 1000.
+	Amount1000 int64 `json:"amount_1000"`
+}
+
+// TemplateDateTime is the value of a date_time parameter.
+type TemplateDateTime struct {
+	// FallbackValue is the formatted date and time.
+	FallbackValue string `json:"fallback_value"`
+}
+
+// SendTemplate sends a template message.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/guides/send-message-templates
+func (wa *Client) SendTemplate(ctx context.Context, recipient string, params *SendTemplateParams) (*MessagesResponse, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid template parameters: %w", err)
+	}
+	if wa.TemplatePreflight {
+		if err := wa.preflightTemplate(ctx, params); err != nil {
+			return nil, fmt.Errorf("template preflight failed: %w", err)
+		}
+	}
+	request := &Request{
+		MessagingProduct: MessagingProductWhatsApp,
+		RecipientType:    RecipientTypeIndividual,
+		To:               recipient,
+		Type:             MessageTypeTemplate,
+		Template:         params,
+	}
+	return wa.SendMessage(ctx, request)
+}
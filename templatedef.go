@@ -0,0 +1,165 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MessageTemplate is the definition of a message template of the WhatsApp Business Account.
+// https://developers.facebook.com/docs/graph-api/reference/whats-app-business-hsm/
+type MessageTemplate struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Status   string `json:"status"`
+	Category string `json:"category"`
+	// ParameterFormat is "POSITIONAL" for {{1}} style or "NAMED" for {{name}} style variables.
+	ParameterFormat string                     `json:"parameter_format,omitempty"`
+	Components      []MessageTemplateComponent `json:"components"`
+}
+
+// MessageTemplateComponent is a component of a template definition.
+type MessageTemplateComponent struct {
+	// Type is "HEADER", "BODY", "FOOTER" or "BUTTONS".
+	Type string `json:"type"`
+	// Format is the header format: "TEXT", "IMAGE", "VIDEO", "DOCUMENT" or "LOCATION".
+	Format  string                  `json:"format,omitempty"`
+	Text    string                  `json:"text,omitempty"`
+	Buttons []MessageTemplateButton `json:"buttons,omitempty"`
+}
+
+// MessageTemplateButton is a button of a template definition.
+type MessageTemplateButton struct {
+	// Type is e.g. "QUICK_REPLY", "URL", "PHONE_NUMBER", "COPY_CODE" or "FLOW".
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	URL    string `json:"url,omitempty"`
+	FlowID string `json:"flow_id,omitempty"`
+	// FlowAction is "navigate" or "data_exchange" for FLOW buttons.
+	FlowAction string `json:"flow_action,omitempty"`
+	// NavigateScreen is the first screen of navigate FLOW buttons.
+	NavigateScreen string `json:"navigate_screen,omitempty"`
+}
+
+// ErrTemplateNotFound is returned by GetTemplate if the business account has
+// no template with the given name and language.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// messageTemplateFields are the template fields requested by GetTemplate.
+const messageTemplateFields = "id,name,language,status,category,parameter_format,components"
+
+// templateVariableRegexp matches template variables like {{1}} or {{name}}.
+var templateVariableRegexp = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// GetTemplate returns the definition of the template with the given name and language.
+// https://developers.facebook.com/docs/graph-api/reference/whats-app-business-account/message_templates/
+func (wa *Client) GetTemplate(ctx context.Context, name, language string) (*MessageTemplate, error) {
+	wabaID, err := wa.businessAccount()
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"name": {name}, "fields": {messageTemplateFields}}
+	var response struct {
+		Data []MessageTemplate `json:"data"`
+	}
+	if err := graphRequest(ctx, wa, http.MethodGet, []string{wabaID, "message_templates"}, query, nil, &response); err != nil {
+		return nil, err
+	}
+	for i, template := range response.Data {
+		// The name filter matches substrings
+		if template.Name == name && template.Language == language {
+			return &response.Data[i], nil
+		}
+	}
+	return nil, fmt.Errorf("template %q in language %q: %w", name, language, ErrTemplateNotFound)
+}
+
+// Variables returns the distinct variables of the component text in order of appearance,
+// e.g. ["1", "2"] for positional or ["name"] for named parameters.
+func (c *MessageTemplateComponent) Variables() []string {
+	var variables []string
+	for _, match := range templateVariableRegexp.FindAllStringSubmatch(c.Text, -1) {
+		if !slices.Contains(variables, match[1]) {
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+// CheckTemplateParams checks that params provide exactly the header and body
+// parameters the template definition expects, which would otherwise fail with
+// error 132000 after the message was sent. All mismatches are returned together.
+//
+// Example usage:
+//
+//	template, err := client.GetTemplate(ctx, "order_update", "en_US")
+//	...
+//	if err := CheckTemplateParams(template, params); err != nil {
+//	    log.Fatal(err)
+//	}
+func CheckTemplateParams(template *MessageTemplate, params *SendTemplateParams) error {
+	if template == nil {
+		return fmt.Errorf("template cannot be nil")
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, definition := range template.Components {
+		var componentType TemplateComponentType
+		switch definition.Type {
+		case "HEADER":
+			componentType = TemplateComponentTypeHeader
+		case "BODY":
+			componentType = TemplateComponentTypeBody
+		default:
+			continue
+		}
+
+		var provided []TemplateParameter
+		for _, component := range params.Components {
+			if component.Type == componentType {
+				provided = append(provided, component.Parameters...)
+			}
+		}
+
+		if componentType == TemplateComponentTypeHeader && definition.Format != "" && definition.Format != "TEXT" {
+			if len(provided) != 1 || !strings.EqualFold(string(provided[0].Type), definition.Format) {
+				errs = append(errs, fmt.Errorf("header needs one %s parameter", strings.ToLower(definition.Format)))
+			}
+			continue
+		}
+
+		expected := definition.Variables()
+		if len(provided) != len(expected) {
+			errs = append(errs, fmt.Errorf("%s has %d parameters, template expects %d", componentType, len(provided), len(expected)))
+			continue
+		}
+		if template.ParameterFormat == "NAMED" {
+			for _, param := range provided {
+				if !slices.Contains(expected, param.ParameterName) {
+					errs = append(errs, fmt.Errorf("%s has unknown parameter %q, template expects %v", componentType, param.ParameterName, expected))
+				}
+			}
+		}
+	}
+
+	for _, component := range params.Components {
+		if component.Type != TemplateComponentTypeHeader && component.Type != TemplateComponentTypeBody {
+			continue
+		}
+		if !slices.ContainsFunc(template.Components, func(c MessageTemplateComponent) bool {
+			return strings.EqualFold(c.Type, string(component.Type))
+		}) && len(component.Parameters) > 0 {
+			errs = append(errs, fmt.Errorf("template has no %s, but parameters were provided for it", component.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
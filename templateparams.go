@@ -0,0 +1,167 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localeFormat describes how numbers and dates are written in a locale.
+type localeFormat struct {
+	decimal, group string
+	// symbolAfter puts the currency symbol after the amount, separated by a space.
+	symbolAfter bool
+	date        string
+	time        string
+}
+
+// localeFormats are the formats of the languages and locales supported by the
+// formatting helpers. Locales are looked up by their full code first, e.g.
+// "en_GB", and then by language, e.g. "en".
+var localeFormats = map[string]localeFormat{
+	"en":    {decimal: ".", group: ",", date: "1/2/2006", time: "3:04 PM"},
+	"en_GB": {decimal: ".", group: ",", date: "02/01/2006", time: "15:04"},
+	"en_IN": {decimal: ".", group: ",", date: "02/01/2006", time: "3:04 PM"},
+	"de":    {decimal: ",", group: ".", symbolAfter: true, date: "02.01.2006", time: "15:04"},
+	"es":    {decimal: ",", group: ".", symbolAfter: true, date: "02/01/2006", time: "15:04"},
+	"es_MX": {decimal: ".", group: ",", date: "02/01/2006", time: "15:04"},
+	"fr":    {decimal: ",", group: " ", symbolAfter: true, date: "02/01/2006", time: "15:04"},
+	"it":    {decimal: ",", group: ".", symbolAfter: true, date: "02/01/2006", time: "15:04"},
+	"nl":    {decimal: ",", group: ".", date: "02-01-2006", time: "15:04"},
+	"pt":    {decimal: ",", group: ".", symbolAfter: true, date: "02/01/2006", time: "15:04"},
+	"pt_BR": {decimal: ",", group: ".", date: "02/01/2006", time: "15:04"},
+	"ru":    {decimal: ",", group: " ", symbolAfter: true, date: "02.01.2006", time: "15:04"},
+	"id":    {decimal: ",", group: ".", date: "02/01/2006", time: "15.04"},
+	"tr":    {decimal: ",", group: ".", date: "02.01.2006", time: "15:04"},
+}
+
+// currencySymbols are the symbols of common currencies. Other currencies are written with their code.
+var currencySymbols = map[string]string{
+	"USD": "$", "EUR": "€", "GBP": "£", "INR": "₹", "BRL": "R$", "MXN": "$",
+	"JPY": "¥", "IDR": "Rp", "RUB": "₽", "TRY": "₺",
+}
+
+// currencyDecimals are the minor unit digits of currencies that don't use two.
+var currencyDecimals = map[string]int{"JPY": 0, "IDR": 0, "KRW": 0, "CLP": 0, "BHD": 3, "KWD": 3, "OMR": 3}
+
+// lookupLocale returns the format of locale, falling back to English.
+func lookupLocale(locale string) localeFormat {
+	locale = strings.ReplaceAll(locale, "-", "_")
+	if format, ok := localeFormats[locale]; ok {
+		return format
+	}
+	language, _, _ := strings.Cut(locale, "_")
+	if format, ok := localeFormats[language]; ok {
+		return format
+	}
+	return localeFormats["en"]
+}
+
+// FormatCurrency formats an amount given in thousandths of the currency unit
+// the way it is written in locale, e.g. "$1,234.50" for "en_US" or
+// "1.234,50 €" for "de". Unknown locales are formatted like English.
+func FormatCurrency(amount1000 int64, code, locale string) string {
+	format := lookupLocale(locale)
+	code = strings.ToUpper(code)
+	decimals, ok := currencyDecimals[code]
+	if !ok {
+		decimals = 2
+	}
+
+	negative := amount1000 < 0
+	if negative {
+		amount1000 = -amount1000
+	}
+	// Round half up to the minor unit of the currency
+	scale := int64(1000)
+	for range decimals {
+		scale /= 10
+	}
+	minor := (amount1000 + scale/2) / scale
+	unit := int64(1)
+	for range decimals {
+		unit *= 10
+	}
+
+	digits := strconv.FormatInt(minor/unit, 10)
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteString(format.group)
+		}
+		grouped.WriteRune(d)
+	}
+	number := grouped.String()
+	if decimals > 0 {
+		number += format.decimal + fmt.Sprintf("%0*d", decimals, minor%unit)
+	}
+
+	symbol, ok := currencySymbols[code]
+	if !ok {
+		symbol = code
+	}
+	var result string
+	if format.symbolAfter || !ok {
+		result = number + " " + symbol
+	} else {
+		result = symbol + number
+	}
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
+
+// FormatDateTime formats t the way dates and times are written in locale,
+// e.g. "1/2/2006 3:04 PM" for "en_US". Unknown locales are formatted like English.
+func FormatDateTime(t time.Time, locale string) string {
+	format := lookupLocale(locale)
+	return t.Format(format.date + " " + format.time)
+}
+
+// TextParameter returns a text template parameter.
+func TextParameter(text string) TemplateParameter {
+	return TemplateParameter{Type: TemplateParameterTypeText, Text: text}
+}
+
+// NamedTextParameter returns a text parameter of a template with named parameters.
+func NamedTextParameter(name, text string) TemplateParameter {
+	return TemplateParameter{Type: TemplateParameterTypeText, ParameterName: name, Text: text}
+}
+
+// CurrencyParameter returns a currency template parameter for an amount given in
+// thousandths of the currency unit, with a fallback value formatted for locale.
+//
+// Example usage:
+//
+//	// $12.99 for an en_US template
+//	param := CurrencyParameter(12990, "USD", "en_US")
+func CurrencyParameter(amount1000 int64, code, locale string) TemplateParameter {
+	return TemplateParameter{
+		Type: TemplateParameterTypeCurrency,
+		Currency: &TemplateCurrency{
+			FallbackValue: FormatCurrency(amount1000, code, locale),
+			Code:          strings.ToUpper(code),
+			Amount1000:    amount1000,
+		},
+	}
+}
+
+// DateTimeParameter returns a date_time template parameter with a fallback value formatted for locale.
+func DateTimeParameter(t time.Time, locale string) TemplateParameter {
+	return TemplateParameter{
+		Type:     TemplateParameterTypeDateTime,
+		DateTime: &TemplateDateTime{FallbackValue: FormatDateTime(t, locale)},
+	}
+}
+
+// BodyComponent returns a body component with the given parameters.
+func BodyComponent(params ...TemplateParameter) TemplateComponent {
+	return TemplateComponent{Type: TemplateComponentTypeBody, Parameters: params}
+}
+
+// HeaderComponent returns a header component with the given parameters.
+func HeaderComponent(params ...TemplateParameter) TemplateComponent {
+	return TemplateComponent{Type: TemplateComponentTypeHeader, Parameters: params}
+}
@@ -0,0 +1,217 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultTemplateCacheTTL is the default time template definitions are cached for the preflight check.
+const DefaultTemplateCacheTTL = time.Hour
+
+// templateButtonSubTypes maps the button sub types of template messages to
+// the button types of template definitions.
+var templateButtonSubTypes = map[string]string{
+	"quick_reply": "QUICK_REPLY",
+	"url":         "URL",
+	"copy_code":   "COPY_CODE",
+	"flow":        "FLOW",
+}
+
+// WithTemplatePreflight makes SendTemplate check the parameters against the
+// template definition before sending, see PreflightTemplate. Definitions are
+// fetched with GetTemplate, which requires the business account ID, and cached
+// for ttl. Zero ttl means DefaultTemplateCacheTTL.
+func WithTemplatePreflight(ttl time.Duration) ClientOption {
+	return func(wa *Client) {
+		wa.TemplatePreflight = true
+		wa.TemplateCacheTTL = ttl
+	}
+}
+
+// PreflightTemplate checks params against the template definition: that the
+// template is approved, has the requested language, gets the expected header
+// and body parameters (see CheckTemplateParams), and that the button indexes
+// and sub types exist in the template. All mismatches are returned together.
+func PreflightTemplate(template *MessageTemplate, params *SendTemplateParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	if template == nil {
+		return fmt.Errorf("template cannot be nil")
+	}
+
+	var errs []error
+	if template.Status != "" && template.Status != "APPROVED" {
+		errs = append(errs, fmt.Errorf("template %q is %s, not APPROVED", template.Name, template.Status))
+	}
+	if template.Language != params.Language.Code {
+		errs = append(errs, fmt.Errorf("template %q has language %q, not %q", template.Name, template.Language, params.Language.Code))
+	}
+	if err := CheckTemplateParams(template, params); err != nil {
+		errs = append(errs, err)
+	}
+
+	var buttons []MessageTemplateButton
+	for _, component := range template.Components {
+		if component.Type == "BUTTONS" {
+			buttons = append(buttons, component.Buttons...)
+		}
+	}
+	for _, component := range params.Components {
+		if component.Type != TemplateComponentTypeButton {
+			continue
+		}
+		index, err := strconv.Atoi(component.Index)
+		if err != nil || index < 0 || index >= len(buttons) {
+			errs = append(errs, fmt.Errorf("button index %q is out of range, template has %d buttons", component.Index, len(buttons)))
+			continue
+		}
+		if want, ok := templateButtonSubTypes[component.SubType]; ok && buttons[index].Type != want {
+			errs = append(errs, fmt.Errorf("button %d is a %s button, not %s", index, buttons[index].Type, component.SubType))
+			continue
+		}
+		if component.SubType == "flow" {
+			errs = append(errs, checkFlowButton(index, buttons[index], component)...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// templateNotFoundTTL is the longest time the absence of a template is
+// cached, so that a template created meanwhile is found soon.
+const templateNotFoundTTL = time.Minute
+
+// templateCache caches template definitions by name and language, and the
+// absence of templates. Concurrent misses of a template share a single fetch.
+type templateCache struct {
+	mu      sync.Mutex
+	entries map[string]*templateCacheEntry
+}
+
+// templateCacheEntry is a template definition being fetched, or fetched.
+// Its other fields are set once done is closed.
+type templateCacheEntry struct {
+	done      chan struct{}
+	template  *MessageTemplate
+	err       error // err is the error of the fetch. Only ErrTemplateNotFound stays cached.
+	fetchedAt time.Time
+}
+
+// expired reports whether the fetched entry is older than ttl.
+func (e *templateCacheEntry) expired(ttl time.Duration) bool {
+	if e.err != nil {
+		ttl = min(ttl, templateNotFoundTTL)
+	}
+	return time.Since(e.fetchedAt) >= ttl
+}
+
+// get returns the cached template with key, or fetches it. Only the absence
+// of the template is cached of the errors of fetch.
+func (c *templateCache) get(ctx context.Context, key string, ttl time.Duration, fetch func(context.Context) (*MessageTemplate, error)) (*MessageTemplate, error) {
+	for {
+		c.mu.Lock()
+		if c.entries == nil {
+			c.entries = make(map[string]*templateCacheEntry)
+		}
+		entry, ok := c.entries[key]
+		if ok {
+			select {
+			case <-entry.done:
+				ok = !entry.expired(ttl)
+			default:
+			}
+		}
+		if !ok {
+			entry = &templateCacheEntry{done: make(chan struct{})}
+			c.entries[key] = entry
+			c.mu.Unlock()
+			return c.fetch(ctx, key, entry, fetch)
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.err != nil && !errors.Is(entry.err, ErrTemplateNotFound) && ctx.Err() == nil &&
+			(errors.Is(entry.err, context.Canceled) || errors.Is(entry.err, context.DeadlineExceeded)) {
+			// The context of the caller that fetched the template ended, fetch it again
+			continue
+		}
+		return entry.template, entry.err
+	}
+}
+
+// fetch fetches the template of entry, which other callers wait for.
+func (c *templateCache) fetch(ctx context.Context, key string, entry *templateCacheEntry, fetch func(context.Context) (*MessageTemplate, error)) (*MessageTemplate, error) {
+	entry.template, entry.err = fetch(ctx)
+	entry.fetchedAt = time.Now()
+	if entry.err != nil && !errors.Is(entry.err, ErrTemplateNotFound) {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.template, entry.err
+}
+
+// preflightTemplate fetches the template definition, using the cache, and checks params against it.
+func (wa *Client) preflightTemplate(ctx context.Context, params *SendTemplateParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	key := params.Name + "\x00" + params.Language.Code
+	ttl := orDefault(wa.TemplateCacheTTL, DefaultTemplateCacheTTL)
+	template, err := wa.templates.get(ctx, key, ttl, func(ctx context.Context) (*MessageTemplate, error) {
+		return wa.GetTemplate(ctx, params.Name, params.Language.Code)
+	})
+	if errors.Is(err, ErrTemplateNotFound) && wa.TestMode != nil {
+		// Test accounts and mock servers may lack the definition, the API decides
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("fetching template definition: %w", err)
+	}
+	return PreflightTemplate(template, params)
+}
+
+// checkFlowButton checks the action parameter of a flow button against the
+// flow action of the button definition: data_exchange flows identify the
+// session by the flow token and take no flow action data, and the flow action
+// data of navigate flows is the data of the navigate screen of the definition.
+func checkFlowButton(index int, button MessageTemplateButton, component TemplateComponent) []error {
+	var errs []error
+	for _, param := range component.Parameters {
+		action := param.Action
+		if action == nil {
+			continue
+		}
+		switch button.FlowAction {
+		case string(FlowActionDataExchange):
+			if action.FlowToken == "" || action.FlowToken == "unused" {
+				errs = append(errs, fmt.Errorf("flow button %d uses data_exchange and needs a flow token identifying the session", index))
+			}
+			if len(action.FlowActionData) > 0 {
+				errs = append(errs, fmt.Errorf("flow button %d uses data_exchange and takes no flow action data", index))
+			}
+		case string(FlowActionNavigate):
+			if button.NavigateScreen == "" {
+				if len(action.FlowActionData) > 0 {
+					errs = append(errs, fmt.Errorf("flow button %d has no navigate screen to receive the flow action data", index))
+				}
+				continue
+			}
+			if err := ValidateFlowScreenName(button.NavigateScreen); err != nil {
+				errs = append(errs, fmt.Errorf("flow button %d: %w", index, err))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,122 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPreflightTemplateFlowButton(t *testing.T) {
+	template := func(button MessageTemplateButton) *MessageTemplate {
+		button.Type, button.Text, button.FlowID = "FLOW", "Open", "flow-1"
+		return &MessageTemplate{
+			Name: "book", Language: "en_US", Status: "APPROVED",
+			Components: []MessageTemplateComponent{{Type: "BUTTONS", Buttons: []MessageTemplateButton{button}}},
+		}
+	}
+	navigate := template(MessageTemplateButton{FlowAction: "navigate", NavigateScreen: "WELCOME"})
+	navigateWithoutScreen := template(MessageTemplateButton{FlowAction: "navigate"})
+	dataExchange := template(MessageTemplateButton{FlowAction: "data_exchange"})
+	data := map[string]any{"department": "cardiology"}
+
+	for _, tc := range []struct {
+		name     string
+		template *MessageTemplate
+		token    string
+		data     any
+		want     string
+	}{
+		{"navigate with data", navigate, "token", data, ""},
+		{"navigate without token", navigate, "", nil, ""},
+		{"navigate data without screen", navigateWithoutScreen, "token", data, "has no navigate screen"},
+		{"navigate without screen", navigateWithoutScreen, "token", nil, ""},
+		{"data exchange", dataExchange, "token", nil, ""},
+		{"data exchange without token", dataExchange, "", nil, "needs a flow token"},
+		{"data exchange with unused token", dataExchange, "unused", nil, "needs a flow token"},
+		{"data exchange with data", dataExchange, "token", data, "takes no flow action data"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			button, err := FlowButtonComponent(0, tc.token, tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = PreflightTemplate(tc.template, &SendTemplateParams{
+				Name:       "book",
+				Language:   &TemplateLanguage{Code: "en_US"},
+				Components: []TemplateComponent{button},
+			})
+			switch {
+			case tc.want == "" && err != nil:
+				t.Errorf("PreflightTemplate() = %v, want nil", err)
+			case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+				t.Errorf("PreflightTemplate() = %v, want an error containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestPreflightTemplateCache(t *testing.T) {
+	params := &SendTemplateParams{Name: "hello_world", Language: &TemplateLanguage{Code: "en_US"}}
+	const (
+		found    = `{"data":[{"name":"hello_world","language":"en_US","status":"APPROVED"}]}`
+		notFound = `{"data":[]}`
+		failure  = `{"error":{"message":"unavailable","code":2}}`
+	)
+	isGraphError := func(err error) bool {
+		var graphErr *GraphError
+		return errors.As(err, &graphErr)
+	}
+	for _, tc := range []struct {
+		name     string
+		status   int
+		body     string
+		testMode bool
+		wantErr  func(error) bool
+		cached   bool
+	}{
+		{"found", http.StatusOK, found, false, nil, true},
+		{"not found", http.StatusOK, notFound, false, func(err error) bool { return errors.Is(err, ErrTemplateNotFound) }, true},
+		{"not found in test mode", http.StatusOK, notFound, true, nil, true},
+		{"server error in test mode", http.StatusInternalServerError, failure, true, isGraphError, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var fetches atomic.Int32
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				fetches.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.status)
+				io.WriteString(w, tc.body)
+			}, WithBusinessAccountID("waba-1"), WithTemplatePreflight(0))
+			if tc.testMode {
+				client.TestMode = &TestMode{}
+			}
+
+			// Concurrent misses share a fetch, and the absence of a template is cached
+			var wg sync.WaitGroup
+			for range 5 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					client.preflightTemplate(context.Background(), params)
+				}()
+			}
+			wg.Wait()
+			for range 5 {
+				err := client.preflightTemplate(context.Background(), params)
+				if (tc.wantErr == nil && err != nil) || (tc.wantErr != nil && !tc.wantErr(err)) {
+					t.Fatalf("preflightTemplate() = %v", err)
+				}
+			}
+			if got := fetches.Load(); (got == 1) != tc.cached {
+				t.Errorf("fetched the template %d times, want cached %v", got, tc.cached)
+			}
+		})
+	}
+}
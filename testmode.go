@@ -0,0 +1,97 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// codeRecipientNotAllowed is the error code of messages from test phone
+// numbers to recipients missing from their allowed list.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+const codeRecipientNotAllowed = 131030
+
+// MaxTestRecipients is the number of recipients Meta allows a test phone number to message.
+const MaxTestRecipients = 5
+
+// ErrRecipientNotAllowed is returned for messages to recipients a test phone
+// number may not message, see TestMode.
+var ErrRecipientNotAllowed = errors.New("recipient not in the allowed list of the test phone number")
+
+// TestMode describes the test phone number of a sandbox WhatsApp Business
+// Account, see WithTestMode.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/get-started#send-a-test-message
+type TestMode struct {
+	// Recipients are the phone numbers the test phone number may message, as
+	// added to the allowed list in the App Dashboard, at most
+	// MaxTestRecipients. Any recipient is allowed if it's empty.
+	Recipients []string
+	// MockURL, if set, replaces the base URL of the client, so requests are
+	// sent to a mock of the Graph API, e.g. an httptest.Server, instead of Meta.
+	MockURL string
+}
+
+// Validate checks the number of recipients.
+func (tm *TestMode) Validate() error {
+	if len(tm.Recipients) > MaxTestRecipients {
+		return fmt.Errorf("test mode allows at most %d recipients, got %d", MaxTestRecipients, len(tm.Recipients))
+	}
+	return nil
+}
+
+// allows reports whether the test phone number may message recipient.
+func (tm *TestMode) allows(recipient string) bool {
+	return len(tm.Recipients) == 0 || slices.ContainsFunc(tm.Recipients, func(allowed string) bool {
+		return samePhoneNumber(allowed, recipient)
+	})
+}
+
+// WithTestMode makes the client follow the rules of the test phone numbers
+// Meta provides for development, so CI environments can run end-to-end tests
+// without touching production accounts:
+//
+//   - messages to recipients other than mode.Recipients fail with
+//     ErrRecipientNotAllowed without being sent, and so do the ones rejected
+//     by the API for the same reason (error 131030);
+//   - template preflight, see WithTemplatePreflight, skips templates the
+//     account doesn't have, as sandbox accounts only have sample templates,
+//     so mock servers may answer the definition requests with an empty list.
+//     Other failures to fetch a definition still fail the send;
+//   - all requests go to mode.MockURL, if set.
+//
+// Example usage:
+//
+//	client := NewClient(token, testPhoneNumberID, WithTestMode(TestMode{
+//	    Recipients: []string{"15550100001", "15550100002"},
+//	    MockURL:    os.Getenv("WHATSAPP_MOCK_URL"),
+//	}))
+func WithTestMode(mode TestMode) ClientOption {
+	return func(wa *Client) {
+		if err := mode.Validate(); err != nil {
+			wa.configErr = err
+			return
+		}
+		wa.TestMode = &mode
+		if mode.MockURL != "" {
+			wa.BaseURL = mode.MockURL
+		}
+	}
+}
+
+// checkTestRecipient returns ErrRecipientNotAllowed if the client is in test
+// mode and may not message the recipient of request. Groups aren't checked.
+func (wa *Client) checkTestRecipient(request *Request) error {
+	if wa.TestMode == nil || request == nil || request.RecipientType == RecipientTypeGroup || wa.TestMode.allows(request.To) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrRecipientNotAllowed, request.To)
+}
+
+// testModeError marks errors of messages to recipients not allowed in test mode with ErrRecipientNotAllowed.
+func (wa *Client) testModeError(err error) error {
+	var graphErr *GraphError
+	if wa.TestMode != nil && errors.As(err, &graphErr) && graphErr.Code == codeRecipientNotAllowed {
+		return fmt.Errorf("%w: %w", ErrRecipientNotAllowed, err)
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package whatsapp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxTextBodyLength is the maximum number of characters allowed in a text message body.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/reference/messages#text-object
+const MaxTextBodyLength = 4096
+
+// LongTextStrategy selects how SendText handles a body longer than MaxTextBodyLength.
+type LongTextStrategy int
+
+const (
+	// LongTextSend sends the body unchanged and leaves the rejection to the API.
+	// This is the default strategy.
+	LongTextSend LongTextStrategy = iota
+	// LongTextError fails before any network call.
+	LongTextError
+	// LongTextTruncate cuts the body down to MaxTextBodyLength characters, ending it with an ellipsis.
+	LongTextTruncate
+	// LongTextSplit splits the body with SplitText and sends the chunks as separate messages.
+	LongTextSplit
+	// LongTextDocument uploads the body as a plain text file and sends it as a document message.
+	LongTextDocument
+)
+
+// longTextFilename is the name of the document sent by the LongTextDocument strategy.
+const longTextFilename = "message.txt"
+
+// WithLongTextStrategy sets the strategy SendText uses for bodies longer than MaxTextBodyLength.
+func WithLongTextStrategy(strategy LongTextStrategy) ClientOption {
+	return func(wa *Client) {
+		wa.LongTextStrategy = strategy
+	}
+}
+
+// TruncateText shortens text to at most limit characters, as counted by
+// TextLength, replacing the tail with an ellipsis. Emoji and other characters
+// made of several code points are never cut apart. Text that already fits is
+// returned unchanged.
+func TruncateText(text string, limit int) string {
+	if TextLength(text) <= limit {
+		return text
+	}
+	if limit <= 0 {
+		return ""
+	}
+	return strings.TrimRight(text[:textPrefix(text, limit-1)], " \t\n") + "…"
+}
+
+// protectedSpanRegexps match the parts of a message that must never be split:
+// URLs and WhatsApp formatting spans (*bold*, _italic_, ~strikethrough~, ```monospace```).
+var protectedSpanRegexps = []*regexp.Regexp{
+	regexp.MustCompile("(?s)```.*?```"),
+	regexp.MustCompile(`\*[^\s*](?:[^*\n]*[^\s*])?\*`),
+	regexp.MustCompile(`_[^\s_](?:[^_\n]*[^\s_])?_`),
+	regexp.MustCompile(`~[^\s~](?:[^~\n]*[^\s~])?~`),
+	regexp.MustCompile(`(?i)(?:https?://|www\.)\S+`),
+}
+
+// SplitText splits text into ordered chunks of at most limit characters each.
+// Chunks are broken at sentence or word boundaries and never inside a URL or
+// a formatting span such as *bold* or _italic_, unless a single span is longer
+// than the limit. A limit of zero or less means MaxTextBodyLength.
+//
+// Example usage:
+//
+//	for _, chunk := range SplitText(longText, MaxTextBodyLength) {
+//	    _, err := client.SendText(ctx, "1234567890", &SendTextParams{Body: chunk})
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+func SplitText(text string, limit int) []string {
+	if limit <= 0 {
+		limit = MaxTextBodyLength
+	}
+
+	var chunks []string
+	for TextLength(text) > limit {
+		cut := splitPoint(text, limit)
+		if chunk := strings.TrimRight(text[:cut], " \t\n"); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		text = strings.TrimLeft(text[cut:], " \t\n")
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// splitPoint returns the byte offset at which text, which is longer than limit
+// characters, should be cut so that the first part fits into the limit.
+func splitPoint(text string, limit int) int {
+	maxCut := textPrefix(text, limit)
+
+	var spans [][]int
+	for _, re := range protectedSpanRegexps {
+		spans = append(spans, re.FindAllStringIndex(text, -1)...)
+	}
+	insideSpan := func(i int) bool {
+		for _, span := range spans {
+			if i > span[0] && i < span[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	sentence, word := -1, -1
+	for i := maxCut; i > 0 && (sentence < 0 || word < 0); i-- {
+		prev := text[i-1]
+		if prev != ' ' && prev != '\t' && prev != '\n' {
+			continue
+		}
+		if insideSpan(i) {
+			continue
+		}
+		if word < 0 {
+			word = i
+		}
+		if sentence < 0 && (prev == '\n' || (i >= 2 && strings.IndexByte(".!?", text[i-2]) >= 0)) {
+			sentence = i
+		}
+	}
+
+	switch {
+	case sentence > maxCut/2:
+		return sentence
+	case word > 0:
+		return word
+	case sentence > 0:
+		return sentence
+	default:
+		return maxCut
+	}
+}
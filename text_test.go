@@ -0,0 +1,26 @@
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSendTextAutoSplitBlankBody(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("SendText sent a blank text")
+		messagesHandler(w, r)
+	}, WithStrictValidation())
+
+	ctx := context.Background()
+	_, want := client.SendText(ctx, "15551234567", &SendTextParams{})
+	if want == nil {
+		t.Fatal("SendText() with an empty body succeeded, want an error")
+	}
+	blank := strings.Repeat(" ", MaxTextBodyLength+1)
+	response, err := client.SendText(ctx, "15551234567", &SendTextParams{Body: blank, AutoSplit: true})
+	if err == nil || err.Error() != want.Error() {
+		t.Errorf("SendText() with a blank split body = %v, %v, want error %v", response, err, want)
+	}
+}
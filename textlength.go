@@ -0,0 +1,80 @@
+package whatsapp
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// TextLength returns the number of characters of text as users perceive them,
+// which is how the length limits of the API are applied. Unlike len, it counts
+// characters instead of bytes, and unlike utf8.RuneCountInString, it counts an
+// emoji built from several code points, such as 👍🏽, 👨‍👩‍👧 or 🇩🇪, or a letter
+// with combining accents, as a single character.
+//
+// Example usage:
+//
+//	if TextLength(caption) > MaxCaptionLength {
+//	    caption = TruncateText(caption, MaxCaptionLength)
+//	}
+func TextLength(text string) int {
+	n := 0
+	for text != "" {
+		text = text[nextCharacter(text):]
+		n++
+	}
+	return n
+}
+
+// textPrefix returns the byte offset after the first n characters of text, as counted by TextLength.
+func textPrefix(text string, n int) int {
+	offset := 0
+	for ; n > 0 && offset < len(text); n-- {
+		offset += nextCharacter(text[offset:])
+	}
+	return offset
+}
+
+// nextCharacter returns the byte length of the first character of text. It
+// approximates the extended grapheme clusters of Unicode Standard Annex #29
+// for the sequences common in messages: combining marks, variation selectors,
+// emoji modifiers, zero width joiner sequences, emoji tag sequences and flags.
+func nextCharacter(text string) int {
+	r, size := utf8.DecodeRuneInString(text)
+	if r == '\r' && len(text) > 1 && text[1] == '\n' {
+		return 2
+	}
+	if isRegionalIndicator(r) {
+		if next, nextSize := utf8.DecodeRuneInString(text[size:]); isRegionalIndicator(next) {
+			size += nextSize
+		}
+	}
+	for size < len(text) {
+		next, nextSize := utf8.DecodeRuneInString(text[size:])
+		switch {
+		case next == '\u200d':
+			// A zero width joiner joins the following character to the cluster
+			size += nextSize
+			if size < len(text) {
+				_, joinedSize := utf8.DecodeRuneInString(text[size:])
+				size += joinedSize
+			}
+		case isExtender(next):
+			size += nextSize
+		default:
+			return size
+		}
+	}
+	return size
+}
+
+// isExtender reports whether r extends the character before it.
+func isExtender(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) || // Emoji skin tone modifiers
+		(r >= 0xe0020 && r <= 0xe007f) // Emoji tag sequences, e.g. subdivision flags
+}
+
+// isRegionalIndicator reports whether r is one of the letters that form flag emoji in pairs.
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
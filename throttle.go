@@ -0,0 +1,210 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Error codes of messages sent faster than the phone number, or the pair of
+// phone numbers, allows.
+// https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+const (
+	codeThroughputExceeded = 130429
+	codeSpamRateLimit      = 131048
+	codePairRateLimit      = 131056
+)
+
+const (
+	// DefaultThrottleFactor is the factor an AdaptiveRateLimiter multiplies its
+	// rate with when throttled, if its Factor is not set.
+	DefaultThrottleFactor = 0.5
+	// DefaultThrottleRecoveryInterval is the period after which an
+	// AdaptiveRateLimiter raises its rate again, if its RecoveryInterval is not set.
+	DefaultThrottleRecoveryInterval = time.Minute
+	// DefaultThrottleRecoveryStep is the fraction of the nominal rate an
+	// AdaptiveRateLimiter restores every recovery interval, if its
+	// RecoveryStep is not set.
+	DefaultThrottleRecoveryStep = 0.1
+)
+
+// ErrorObserver is implemented by rate limiters that adapt to the outcome of
+// the messages they pace. The client passes the errors of sent messages to
+// the ObserveError method of its RateLimiter, if it has one.
+type ErrorObserver interface {
+	ObserveError(err error)
+}
+
+// AdaptiveRateLimiter is a RateLimiter that paces the messages of a phone
+// number at Rate per second, and slows down when Meta reports pacing
+// problems: throughput and pair rate limit errors (130429, 131048 and
+// 131056) of sent messages and of their failed statuses, and quality
+// downgrades reported by a QualityMonitor. Every problem multiplies the rate
+// by Factor, and the rate is restored by RecoveryStep every RecoveryInterval
+// without problems. It is an EventSink, and is safe for concurrent use.
+//
+// Example usage:
+//
+//	limiter := NewAdaptiveRateLimiter(50)
+//	limiter.PhoneNumberID = phoneNumberID
+//	limiter.OnRateChange = func(previous, current float64, reason string) {
+//	    log.Printf("send rate %.1f/s -> %.1f/s: %s", previous, current, reason)
+//	}
+//	client := NewClient(token, phoneNumberID, WithRateLimiter(limiter))
+//	webhook.EventSink = limiter
+//	monitor.OnDowngrade = limiter.OnQualityDowngrade
+type AdaptiveRateLimiter struct {
+	// Rate is the nominal number of messages per second.
+	Rate float64
+	// MinRate is the rate throttling doesn't go below, a tenth of Rate if zero.
+	MinRate float64
+	// Factor is the factor the rate is multiplied with on every problem,
+	// DefaultThrottleFactor if zero.
+	Factor float64
+	// RecoveryInterval is the period without problems after which the rate is
+	// raised, DefaultThrottleRecoveryInterval if zero.
+	RecoveryInterval time.Duration
+	// RecoveryStep is the fraction of Rate restored every RecoveryInterval,
+	// DefaultThrottleRecoveryStep if zero.
+	RecoveryStep float64
+	// PhoneNumberID, if set, restricts the status notifications the limiter
+	// reacts to to the ones of the phone number.
+	PhoneNumberID string
+	// OnRateChange, if set, is called whenever the rate changes, with the
+	// reason of the change. It is called with the limiter locked, so it must
+	// not call the limiter's methods.
+	OnRateChange func(previous, current float64, reason string)
+
+	mu        sync.Mutex
+	current   float64   // current is the current rate, Rate if zero.
+	changedAt time.Time // changedAt is the time of the last problem or recovery step.
+	next      time.Time // next is the earliest time the next message may be sent.
+}
+
+// NewAdaptiveRateLimiter creates an AdaptiveRateLimiter with the nominal rate
+// of messages per second.
+func NewAdaptiveRateLimiter(rate float64) *AdaptiveRateLimiter {
+	return &AdaptiveRateLimiter{Rate: rate}
+}
+
+// Wait implements RateLimiter by blocking until the next message may be sent
+// at the current rate.
+func (l *AdaptiveRateLimiter) Wait(ctx context.Context) error {
+	if l.Rate <= 0 {
+		return fmt.Errorf("invalid rate %v", l.Rate)
+	}
+	now := time.Now()
+	l.mu.Lock()
+	rate := l.rate(now)
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(time.Duration(float64(time.Second) / rate))
+	l.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CurrentRate returns the current number of messages per second.
+func (l *AdaptiveRateLimiter) CurrentRate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate(time.Now())
+}
+
+// Throttle multiplies the rate by Factor, down to MinRate, for reason.
+func (l *AdaptiveRateLimiter) Throttle(reason string) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	previous := l.rate(now)
+	minRate := orDefault(l.MinRate, l.Rate/10)
+	l.setRate(previous, max(previous*orDefault(l.Factor, DefaultThrottleFactor), minRate), reason)
+	l.changedAt = now
+}
+
+// ObserveError implements ErrorObserver by throttling on pacing errors.
+func (l *AdaptiveRateLimiter) ObserveError(err error) {
+	var graphErr *GraphError
+	if errors.As(err, &graphErr) && isPacingError(graphErr.Code) {
+		l.Throttle(fmt.Sprintf("error %d: %s", graphErr.Code, graphErr.Message))
+	}
+}
+
+// WriteEvent implements EventSink by throttling on the pacing errors of
+// failed statuses.
+func (l *AdaptiveRateLimiter) WriteEvent(ctx context.Context, request *WebhookRequest) error {
+	for _, status := range request.Statuses() {
+		if l.PhoneNumberID != "" && status.Metadata.PhoneNumberID != l.PhoneNumberID {
+			continue
+		}
+		for _, err := range status.Errors {
+			if isPacingError(err.Code) {
+				l.Throttle(fmt.Sprintf("message %s failed with error %d", status.ID, err.Code))
+			}
+		}
+	}
+	return nil
+}
+
+// OnQualityDowngrade throttles on a quality downgrade. It is meant to be the
+// OnDowngrade callback of a QualityMonitor.
+func (l *AdaptiveRateLimiter) OnQualityDowngrade(ctx context.Context, change QualityChange) {
+	reason := "quality downgrade"
+	if change.Event != "" {
+		reason += " " + string(change.Event)
+	}
+	l.Throttle(fmt.Sprintf("%s: %s %s -> %s %s", reason,
+		change.Previous.QualityRating, change.Previous.MessagingLimit,
+		change.Current.QualityRating, change.Current.MessagingLimit))
+}
+
+// rate returns the current rate at now, after restoring the steps due since
+// the last change. It must be called with l.mu held.
+func (l *AdaptiveRateLimiter) rate(now time.Time) float64 {
+	if l.current == 0 || l.current >= l.Rate {
+		l.current = l.Rate
+		return l.current
+	}
+	interval := orDefault(l.RecoveryInterval, DefaultThrottleRecoveryInterval)
+	steps := now.Sub(l.changedAt) / interval
+	if steps <= 0 {
+		return l.current
+	}
+	step := orDefault(l.RecoveryStep, DefaultThrottleRecoveryStep)
+	l.setRate(l.current, min(l.current+float64(steps)*step*l.Rate, l.Rate), "recovery")
+	l.changedAt = l.changedAt.Add(steps * interval)
+	return l.current
+}
+
+// setRate changes the current rate and reports the change. It must be called
+// with l.mu held.
+func (l *AdaptiveRateLimiter) setRate(previous, current float64, reason string) {
+	l.current = current
+	if current != previous && l.OnRateChange != nil {
+		l.OnRateChange(previous, current, reason)
+	}
+}
+
+// isPacingError reports whether code is the error code of messages sent too fast.
+func isPacingError(code int) bool {
+	switch code {
+	case codeThroughputExceeded, codeSpamRateLimit, codePairRateLimit:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"mime"
+	"strings"
+)
+
+const (
+	// DefaultThumbnailSize is the longer side of thumbnails, in pixels, if the
+	// Size of a Thumbnailer is not set.
+	DefaultThumbnailSize = 320
+	// DefaultThumbnailQuality is the JPEG quality of thumbnails, if the Quality
+	// of a Thumbnailer is not set.
+	DefaultThumbnailQuality = 75
+)
+
+// ThumbnailDecoder decodes the first frame of media into an image.
+type ThumbnailDecoder func(ctx context.Context, r io.Reader) (image.Image, error)
+
+// Thumbnailer produces small JPEG thumbnails of received media, e.g. for the
+// previews of a dashboard. Images are decoded with image.Decode, which
+// supports JPEG and PNG, and WebP, such as stickers, once the application
+// imports golang.org/x/image/webp. Other formats, e.g. videos, need a decoder
+// in Decoders.
+//
+// Example usage, with the first frame of videos extracted by ffmpeg:
+//
+//	thumbnailer := &Thumbnailer{Decoders: map[string]ThumbnailDecoder{
+//	    "video/mp4": func(ctx context.Context, r io.Reader) (image.Image, error) {
+//	        var frame bytes.Buffer
+//	        cmd := exec.CommandContext(ctx, "ffmpeg", "-i", "pipe:0", "-frames:v", "1", "-f", "image2", "-c:v", "png", "pipe:1")
+//	        cmd.Stdin, cmd.Stdout = r, &frame
+//	        if err := cmd.Run(); err != nil {
+//	            return nil, err
+//	        }
+//	        return png.Decode(&frame)
+//	    },
+//	}}
+//	info, content, err := client.GetAndDownloadMediaBytes(ctx, message.Video.ID)
+//	thumbnail, err := thumbnailer.Thumbnail(ctx, info.MimeType, bytes.NewReader(content))
+type Thumbnailer struct {
+	// Size is the longer side of thumbnails in pixels, DefaultThumbnailSize if
+	// zero. Smaller media isn't scaled up.
+	Size int
+	// Quality is the JPEG quality of thumbnails, DefaultThumbnailQuality if zero.
+	Quality int
+	// Decoders are the decoders by MIME type, such as "video/mp4". They
+	// override the default decoder of images.
+	Decoders map[string]ThumbnailDecoder
+	// MaxPixels is the number of pixels above which media is rejected with
+	// ErrImageTooLarge, DefaultMaxImagePixels if zero. Images are checked
+	// before they are decoded, the frames of Decoders once decoded.
+	MaxPixels int
+}
+
+// Thumbnail returns a JPEG thumbnail of the media with mimeType read from r.
+func (t *Thumbnailer) Thumbnail(ctx context.Context, mimeType string, r io.Reader) ([]byte, error) {
+	decode, err := t.decoder(mimeType)
+	if err != nil {
+		return nil, err
+	}
+	img, err := decode(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", mimeType, err)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("decoding %s: empty image", mimeType)
+	}
+	if err := checkImagePixels(width, height, t.MaxPixels); err != nil {
+		return nil, err
+	}
+	if size := orDefault(t.Size, DefaultThumbnailSize); max(width, height) > size {
+		width, height = scaleToFit(width, height, size)
+	}
+	var thumbnail bytes.Buffer
+	if err := jpeg.Encode(&thumbnail, resizeImage(img, width, height), &jpeg.Options{Quality: orDefault(t.Quality, DefaultThumbnailQuality)}); err != nil {
+		return nil, fmt.Errorf("encoding thumbnail: %w", err)
+	}
+	return thumbnail.Bytes(), nil
+}
+
+// decodeImage is the ThumbnailDecoder of images, decoding the formats
+// registered with the image package. Received images come from anyone, so
+// their size is checked before they are decoded.
+func (t *Thumbnailer) decodeImage(ctx context.Context, r io.Reader) (image.Image, error) {
+	return decodeLimitedImage(r, t.MaxPixels)
+}
+
+// decoder returns the decoder of mimeType, ignoring its parameters.
+func (t *Thumbnailer) decoder(mimeType string) (ThumbnailDecoder, error) {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MIME type %q: %w", mimeType, err)
+	}
+	if decode, ok := t.Decoders[mediaType]; ok {
+		return decode, nil
+	}
+	if strings.HasPrefix(mediaType, "image/") {
+		return t.decodeImage, nil
+	}
+	return nil, fmt.Errorf("no thumbnail decoder for %s", mediaType)
+}
@@ -0,0 +1,76 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+)
+
+// declaredSizePNG returns a PNG of a single pixel whose header declares the
+// image to be width x height pixels.
+func declaredSizePNG(t testing.TB, width, height uint32) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	// The IHDR chunk follows the 8 bytes of the signature: length, type, width, height, ..., CRC
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestThumbnailRejectsHugeImages(t *testing.T) {
+	thumbnailer := &Thumbnailer{}
+	_, err := thumbnailer.Thumbnail(context.Background(), "image/png", bytes.NewReader(declaredSizePNG(t, 50000, 50000)))
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("Thumbnail of a 50000x50000 PNG = %v, want %v", err, ErrImageTooLarge)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, 400, 100))); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name        string
+		thumbnailer *Thumbnailer
+		wantBounds  image.Rectangle
+		wantErr     error
+	}{
+		{"scaled down", &Thumbnailer{}, image.Rect(0, 0, DefaultThumbnailSize, 80), nil},
+		{"small size", &Thumbnailer{Size: 40}, image.Rect(0, 0, 40, 10), nil},
+		{"too many pixels", &Thumbnailer{MaxPixels: 400*100 - 1}, image.Rectangle{}, ErrImageTooLarge},
+		{"custom decoder", &Thumbnailer{MaxPixels: 100, Decoders: map[string]ThumbnailDecoder{
+			"image/png": func(ctx context.Context, r io.Reader) (image.Image, error) {
+				return png.Decode(r)
+			},
+		}}, image.Rectangle{}, ErrImageTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			thumbnail, err := tc.thumbnailer.Thumbnail(context.Background(), "image/png", bytes.NewReader(b.Bytes()))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Thumbnail() = %v, want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			config, format, err := image.DecodeConfig(bytes.NewReader(thumbnail))
+			if err != nil || format != "jpeg" {
+				t.Fatalf("thumbnail is %s: %v, want a JPEG", format, err)
+			}
+			if bounds := image.Rect(0, 0, config.Width, config.Height); bounds != tc.wantBounds {
+				t.Errorf("thumbnail is %v, want %v", bounds, tc.wantBounds)
+			}
+		})
+	}
+}
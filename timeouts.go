@@ -0,0 +1,75 @@
+package whatsapp
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+const (
+	// DefaultSendTimeout is the timeout of Graph API requests set by WithDefaultTimeouts.
+	DefaultSendTimeout = 30 * time.Second
+	// DefaultUploadTimeout is the timeout of media uploads set by WithDefaultTimeouts.
+	DefaultUploadTimeout = 2 * time.Minute
+	// DefaultDownloadTimeout is the timeout of media downloads set by WithDefaultTimeouts.
+	DefaultDownloadTimeout = 5 * time.Minute
+)
+
+// WithSendTimeout limits Graph API requests, such as sending messages, to
+// timeout if the caller's context has no deadline.
+func WithSendTimeout(timeout time.Duration) ClientOption {
+	return func(wa *Client) {
+		wa.SendTimeout = timeout
+	}
+}
+
+// WithUploadTimeout limits media uploads to timeout if the caller's context has no deadline.
+func WithUploadTimeout(timeout time.Duration) ClientOption {
+	return func(wa *Client) {
+		wa.UploadTimeout = timeout
+	}
+}
+
+// WithDownloadTimeout limits media downloads, including reading the content,
+// to timeout if the caller's context has no deadline.
+func WithDownloadTimeout(timeout time.Duration) ClientOption {
+	return func(wa *Client) {
+		wa.DownloadTimeout = timeout
+	}
+}
+
+// WithDefaultTimeouts sets DefaultSendTimeout, DefaultUploadTimeout and
+// DefaultDownloadTimeout. Unlike the Timeout of an http.Client, which applies
+// to every request alike, they don't cut off large media downloads early nor
+// let a stuck message send hang for as long as a download may take.
+//
+// Example usage:
+//
+//	client := NewClient(token, phoneNumberID, WithTunedTransport(), WithDefaultTimeouts())
+func WithDefaultTimeouts() ClientOption {
+	return func(wa *Client) {
+		wa.SendTimeout = DefaultSendTimeout
+		wa.UploadTimeout = DefaultUploadTimeout
+		wa.DownloadTimeout = DefaultDownloadTimeout
+	}
+}
+
+// withDefaultTimeout returns ctx with timeout applied, unless timeout isn't
+// positive or ctx already has a deadline.
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// cancelOnClose cancels the context of a streamed response body when it is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
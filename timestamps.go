@@ -0,0 +1,52 @@
+package whatsapp
+
+import (
+	"strconv"
+	"time"
+)
+
+// parseUnixTime parses a webhook timestamp, a string of Unix seconds. It
+// returns false if the timestamp is missing or invalid.
+func parseUnixTime(timestamp string) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// unixTime returns the time of a webhook timestamp, or the zero time if it's missing or invalid.
+func unixTime(timestamp string) time.Time {
+	t, _ := parseUnixTime(timestamp)
+	return t
+}
+
+// Time returns the time the message was sent, or the zero time if the
+// timestamp is missing or invalid.
+//
+// Example usage:
+//
+//	if age := time.Since(message.Time()); age > 24*time.Hour {
+//	    log.Printf("Message %s arrived %s late", message.ID, age)
+//	}
+func (m *WebhookMessage) Time() time.Time {
+	return unixTime(m.Timestamp)
+}
+
+// Time returns the time of the status change, or the zero time if the
+// timestamp is missing or invalid.
+func (s *WebhookStatus) Time() time.Time {
+	return unixTime(s.Timestamp)
+}
+
+// ExpirationTime returns the time the conversation expires, or the zero time
+// if the notification didn't include it.
+func (c *WebhookStatusConversation) ExpirationTime() time.Time {
+	return unixTime(c.ExpirationTimestamp)
+}
+
+// Time returns the time of the call event, or the zero time if the timestamp
+// is missing or invalid.
+func (c *WebhookCall) Time() time.Time {
+	return unixTime(c.Timestamp)
+}
@@ -0,0 +1,111 @@
+package whatsapp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+)
+
+// Transcript is the text of an audio message.
+type Transcript struct {
+	// Text is the transcribed speech.
+	Text string
+	// Language is the detected language, e.g. "pt-BR", if the transcriber reports it.
+	Language string
+}
+
+// AudioTranscriber converts speech to text, e.g. with a speech-to-text API.
+type AudioTranscriber interface {
+	Transcribe(ctx context.Context, audio io.Reader, mimeType string) (*Transcript, error)
+}
+
+// AudioTranscriberFunc is a function type that implements the AudioTranscriber interface.
+type AudioTranscriberFunc func(ctx context.Context, audio io.Reader, mimeType string) (*Transcript, error)
+
+// Transcribe calls the function with the given parameters.
+func (f AudioTranscriberFunc) Transcribe(ctx context.Context, audio io.Reader, mimeType string) (*Transcript, error) {
+	return f(ctx, audio, mimeType)
+}
+
+// transcriptKey is the context key of the Transcript of a message.
+type transcriptKey struct{}
+
+// TranscriptFrom returns the transcript Transcription added to ctx, if any.
+func TranscriptFrom(ctx context.Context) (*Transcript, bool) {
+	transcript, ok := ctx.Value(transcriptKey{}).(*Transcript)
+	return transcript, ok
+}
+
+// Transcription transcribes incoming audio messages, so handlers can treat
+// voice notes like text, which is how many users talk to businesses. It
+// downloads the audio, passes it to the Transcriber, and adds the transcript
+// to the context of the next handler, see TranscriptFrom. Messages whose
+// transcription fails are passed on without a transcript, and the error is
+// passed to OnError. Its Middleware attaches it to a Router.
+//
+// Example usage:
+//
+//	transcription := NewTranscription(client, AudioTranscriberFunc(speechToText))
+//	router.Use(transcription.Middleware)
+//	router.HandleFunc(OnType(MessageTypeAudio), func(ctx context.Context, message *IncomingMessage) error {
+//	    if transcript, ok := TranscriptFrom(ctx); ok {
+//	        return answer(ctx, message, transcript.Text)
+//	    }
+//	    _, err := message.ReplyText(ctx, client, "Sorry, I couldn't understand your voice note.")
+//	    return err
+//	})
+type Transcription struct {
+	// Client downloads the audio.
+	Client *Client
+	// Transcriber transcribes the audio.
+	Transcriber AudioTranscriber
+	// VoiceOnly restricts transcription to voice notes, skipping audio files.
+	VoiceOnly bool
+	// MaxBytes, if positive, skips audio larger than it, to bound the cost of
+	// transcription.
+	MaxBytes int64
+	// OnError, if set, is called with the errors of failed transcriptions.
+	OnError func(err error)
+}
+
+// NewTranscription creates a Transcription of the audio messages downloaded with client.
+func NewTranscription(client *Client, transcriber AudioTranscriber) *Transcription {
+	return &Transcription{Client: client, Transcriber: transcriber}
+}
+
+// Middleware returns next wrapped with the transcription. It is a MessageMiddleware.
+func (t *Transcription) Middleware(next MessageHandler) MessageHandler {
+	return MessageHandlerFunc(func(ctx context.Context, message *IncomingMessage) error {
+		audio := message.Unwrap().Audio
+		if message.Unwrap().Type != MessageTypeAudio || audio == nil || (t.VoiceOnly && !audio.Voice) {
+			return next.HandleMessage(ctx, message)
+		}
+		transcript, err := t.transcribe(ctx, audio)
+		if err != nil {
+			if t.OnError != nil {
+				t.OnError(fmt.Errorf("transcribing message %s from %s: %w", message.ID, message.From, err))
+			}
+		} else if transcript != nil {
+			ctx = context.WithValue(ctx, transcriptKey{}, transcript)
+		}
+		return next.HandleMessage(ctx, message)
+	})
+}
+
+// transcribe downloads and transcribes audio. It returns nil for audio larger than MaxBytes.
+func (t *Transcription) transcribe(ctx context.Context, audio *WebhookMessageMedia) (*Transcript, error) {
+	info, err := t.Client.GetMedia(ctx, audio.ID)
+	if err != nil {
+		return nil, err
+	}
+	if t.MaxBytes > 0 && info.FileSize > t.MaxBytes {
+		return nil, nil
+	}
+	reader, err := t.Client.DownloadMediaVerified(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return t.Transcriber.Transcribe(ctx, reader, cmp.Or(info.MimeType, audio.MimeType))
+}
@@ -0,0 +1,205 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const (
+	// MaxCaptionLength is the maximum length of a media caption in characters.
+	MaxCaptionLength = 1024
+	// MaxInteractiveBodyLength is the maximum length of an interactive message body in characters.
+	MaxInteractiveBodyLength = 1024
+	// MaxInteractiveHeaderTextLength is the maximum length of an interactive message text header in characters.
+	MaxInteractiveHeaderTextLength = 60
+	// MaxInteractiveFooterLength is the maximum length of an interactive message footer in characters.
+	MaxInteractiveFooterLength = 60
+	// MaxListRows is the maximum number of rows across all sections of a list message.
+	MaxListRows = 10
+	// MaxListSections is the maximum number of sections of a list message.
+	MaxListSections = 10
+)
+
+// recipientRegexp matches phone numbers in international format, with an optional leading +.
+var recipientRegexp = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+
+// WithStrictValidation makes the client check every message with ValidateRequest
+// before sending it, so invalid messages fail without a network call.
+func WithStrictValidation() ClientOption {
+	return func(wa *Client) {
+		wa.StrictValidation = true
+	}
+}
+
+// ValidateRequest checks a message request against the documented limits of the
+// Cloud API: the recipient format, that the payload matches the message type,
+// text and caption lengths, and the constraints of interactive messages.
+// All problems found are returned together, joined with errors.Join.
+//
+// Example usage:
+//
+//	if err := ValidateRequest(request); err != nil {
+//	    log.Printf("Invalid message: %v", err)
+//	}
+func ValidateRequest(request *Request) error {
+	if request == nil {
+		return fmt.Errorf("request cannot be nil")
+	}
+
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if request.RecipientType == RecipientTypeGroup {
+		if request.To == "" {
+			check(fmt.Errorf("group ID is required"))
+		}
+	} else if !recipientRegexp.MatchString(request.To) {
+		check(fmt.Errorf("recipient %q is not a phone number in international format", request.To))
+	}
+	if request.Context != nil && request.Context.MessageID == "" {
+		check(fmt.Errorf("context message ID cannot be empty"))
+	}
+
+	var missing bool
+	switch request.Type {
+	case MessageTypeText:
+		if missing = request.Text == nil; !missing {
+			if request.Text.Body == "" {
+				check(fmt.Errorf("text body is required"))
+			}
+			check(maxLength("text body", request.Text.Body, MaxTextBodyLength))
+		}
+	case MessageTypeImage:
+		if missing = request.Image == nil; !missing {
+			check(request.Image.Validate())
+			check(maxLength("image caption", request.Image.Caption, MaxCaptionLength))
+		}
+	case MessageTypeDocument:
+		if missing = request.Document == nil; !missing {
+			check(request.Document.Validate())
+			check(maxLength("document caption", request.Document.Caption, MaxCaptionLength))
+		}
+	case MessageTypeAudio:
+		if missing = request.Audio == nil; !missing {
+			check(request.Audio.Validate())
+		}
+	case MessageTypeSticker:
+		if missing = request.Sticker == nil; !missing {
+			check(request.Sticker.Validate())
+		}
+	case MessageTypeReaction:
+		if missing = request.Reaction == nil; !missing && request.Reaction.MessageID == "" {
+			check(fmt.Errorf("reaction message ID is required"))
+		}
+	case MessageTypeTemplate:
+		if missing = request.Template == nil; !missing {
+			check(request.Template.Validate())
+		}
+	case MessageTypeInteractive:
+		if missing = request.Interactive == nil; !missing {
+			errs = append(errs, validateInteractive(request.Interactive)...)
+		}
+	default:
+		check(fmt.Errorf("unsupported message type %q", request.Type))
+	}
+	if missing {
+		check(fmt.Errorf("%s message has no %s payload", request.Type, request.Type))
+	}
+	return errors.Join(errs...)
+}
+
+// validateInteractive checks the constraints of an interactive message.
+func validateInteractive(interactive *Interactive) []error {
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if interactive.Body == nil || interactive.Body.Text == "" {
+		check(fmt.Errorf("interactive body is required"))
+	} else {
+		check(maxLength("interactive body", interactive.Body.Text, MaxInteractiveBodyLength))
+	}
+	if interactive.Header != nil && interactive.Header.Type == HeaderTypeText {
+		check(maxLength("interactive header", interactive.Header.Text, MaxInteractiveHeaderTextLength))
+	}
+	if interactive.Footer != nil {
+		check(maxLength("interactive footer", interactive.Footer.Text, MaxInteractiveFooterLength))
+	}
+	if interactive.Action == nil {
+		return append(errs, fmt.Errorf("interactive action is required"))
+	}
+
+	action := interactive.Action
+	switch interactive.Type {
+	case InteractiveTypeButton:
+		if n := len(action.Buttons); n == 0 || n > MaxReplyButtons {
+			check(fmt.Errorf("reply buttons message needs 1 to %d buttons, got %d", MaxReplyButtons, n))
+		}
+		ids := make(map[string]bool, len(action.Buttons))
+		for i, button := range action.Buttons {
+			if button.Reply == nil || button.Reply.ID == "" || button.Reply.Title == "" {
+				check(fmt.Errorf("reply button %d: ID and title are required", i))
+				continue
+			}
+			if ids[button.Reply.ID] {
+				check(fmt.Errorf("reply button %d: duplicate ID %q", i, button.Reply.ID))
+			}
+			ids[button.Reply.ID] = true
+			check(maxLength(fmt.Sprintf("reply button %d title", i), button.Reply.Title, MaxReplyButtonTitleLength))
+		}
+	case InteractiveTypeList:
+		if action.Button == "" {
+			check(fmt.Errorf("list button text is required"))
+		}
+		check(maxLength("list button text", action.Button, MaxListButtonTextLength))
+		if n := len(action.Sections); n == 0 || n > MaxListSections {
+			check(fmt.Errorf("list message needs 1 to %d sections, got %d", MaxListSections, n))
+		}
+		var rows int
+		ids := make(map[string]bool)
+		for i, section := range action.Sections {
+			if len(action.Sections) > 1 && section.Title == "" {
+				check(fmt.Errorf("list section %d: title is required when there are multiple sections", i))
+			}
+			check(maxLength(fmt.Sprintf("list section %d title", i), section.Title, MaxListSectionTitleLength))
+			for j, row := range section.Rows {
+				rows++
+				if ids[row.ID] {
+					check(fmt.Errorf("list section %d row %d: duplicate ID %q", i, j, row.ID))
+				}
+				ids[row.ID] = true
+				check(maxLength(fmt.Sprintf("list section %d row %d ID", i, j), row.ID, 200))
+				check(maxLength(fmt.Sprintf("list section %d row %d title", i, j), row.Title, MaxListRowTitleLength))
+				check(maxLength(fmt.Sprintf("list section %d row %d description", i, j), row.Description, MaxListRowDescriptionLength))
+			}
+		}
+		if rows == 0 || rows > MaxListRows {
+			check(fmt.Errorf("list message needs 1 to %d rows, got %d", MaxListRows, rows))
+		}
+	case InteractiveTypeFlow, InteractiveTypeCTAURL:
+		if action.Parameters == nil {
+			check(fmt.Errorf("%s action parameters are required", interactive.Type))
+		} else {
+			check(ValidateAction(action))
+		}
+	case InteractiveTypeCallPermissionRequest:
+	default:
+		check(fmt.Errorf("unsupported interactive type %q", interactive.Type))
+	}
+	return errs
+}
+
+// maxLength checks that the text of the named field is at most limit characters long, see TextLength.
+func maxLength(field, text string, limit int) error {
+	if n := TextLength(text); n > limit {
+		return fmt.Errorf("%s has %d characters, maximum is %d", field, n, limit)
+	}
+	return nil
+}
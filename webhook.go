@@ -1,20 +1,28 @@
 package whatsapp
 
 import (
+	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"hash"
 	"io"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"strings"
+	"time"
 )
 
+// DefaultWebhookMaxBodyBytes is the default limit of webhook request bodies.
+// Webhook notifications are batched by Meta, but stay well below this size.
+const DefaultWebhookMaxBodyBytes = 4 << 20
+
 // WebhookHandler is an interface that defines the methods that must be implemented
 // by a webhook handler. It is used to handle incoming webhook requests from the WhatsApp Business API.
 type WebhookHandler interface {
@@ -51,21 +59,134 @@ type Webhook struct {
 	AppSecret     string
 	Handler       WebhookHandler
 	ErrHandler    WebhookErrHandler
+
+	// PreviousAppSecrets are additional app secrets accepted when verifying signatures.
+	// Keep the old secret here while rotating AppSecret to avoid rejecting deliveries
+	// signed with it.
+	PreviousAppSecrets []string
+	// RequireSHA256 rejects requests signed only with the legacy SHA-1 X-Hub-Signature header.
+	RequireSHA256 bool
+	// AllowUnsigned accepts requests without any signature header.
+	// It is meant for local development only and must never be enabled in production.
+	AllowUnsigned bool
+
+	// AllowedNetworks, if not empty, rejects requests from addresses outside of
+	// these networks, see ParseNetworks. It is a defense in depth next to the
+	// signature verification.
+	AllowedNetworks []netip.Prefix
+	// TrustedProxies is the number of reverse proxies in front of the webhook.
+	// The client address is then taken from the X-Forwarded-For entries they
+	// added, and requests that didn't pass through all of them are rejected by
+	// AllowedNetworks. Entries before those are set by the client and ignored.
+	TrustedProxies int
+
+	// MaxBodyBytes limits the size of webhook (POST) request bodies. Zero means
+	// DefaultWebhookMaxBodyBytes, a negative value disables the limit.
+	MaxBodyBytes int64
+
+	// VerifyQuery, if set, performs additional checks on the query of a verification
+	// (GET) request after the verify token matched. Returning an error rejects the request.
+	// This is useful behind proxies that add or rewrite query parameters.
+	VerifyQuery func(url.Values) error
+	// OnVerify, if set, is called after every verification (GET) request with
+	// the reason of the rejection, or nil if the challenge was answered.
+	OnVerify func(*http.Request, error)
+
+	// MessageStore, if set, is updated with the delivery statuses of every
+	// notification before it is passed to Handler.
+	MessageStore MessageStore
+
+	// ReplayWindow, if positive, treats messages and statuses with timestamps
+	// older than the window as replays.
+	ReplayWindow time.Duration
+	// SeenIDs, if set, treats messages and statuses that were already processed
+	// as replays. IDs are forgotten again when the notification is answered
+	// with an error status, e.g. by a failing Moderator, EventSink, MessageStore
+	// or Handler, or the handler panics, so Meta's redelivery is handled.
+	SeenIDs SeenCache
+	// AllowReplays keeps replays in the request instead of removing them, so they
+	// are only reported to OnReplay. Requests consisting of replays only are
+	// acknowledged without calling Handler unless AllowReplays is set.
+	AllowReplays bool
+	// OnReplay, if set, is called for every replayed message or status with its
+	// message ID and ErrReplayExpired or ErrReplaySeen.
+	OnReplay func(id string, reason error)
+
+	// EventSink, if set, receives every notification before it is handled.
+	EventSink EventSink
+
+	// ChangeHandler, if set, is called for every change of a notification
+	// instead of calling Handler with the whole notification. Changes are
+	// handled in isolation: an error or panic while handling one of them is
+	// reported to ErrHandler as a *WebhookChangeError, and the remaining
+	// changes are still handled. Writes of ErrHandler to the response are
+	// ignored for these errors, the notification is always acknowledged.
+	ChangeHandler WebhookChangeHandler
+
+	// HandlerTimeout, if positive, limits how long the webhook waits for the
+	// handler before acknowledging the notification with 200, so slow handlers
+	// don't make Meta redeliver it. The handler keeps running in the background
+	// with a context that is not canceled with the request, and its response is
+	// discarded. Responses of handlers that finish in time are passed on.
+	HandlerTimeout time.Duration
+	// OnHandlerDone, if set, is called when the handler finished, with the time
+	// it took and whether it exceeded HandlerTimeout. It is only used with HandlerTimeout.
+	OnHandlerDone func(ctx context.Context, request *WebhookRequest, elapsed time.Duration, timedOut bool)
+
+	// JSONCodec, if set, replaces encoding/json for decoding notifications.
+	// Notifications are then read as a whole before they are decoded.
+	JSONCodec JSONCodec
+
+	// PoolStatusRequests decodes notifications into pooled requests and reuses
+	// those that contain status updates only, which outnumber other notifications
+	// by far, to reduce garbage collection on busy receivers. Handlers, error
+	// handlers and the EventSink must not retain the *WebhookRequest or the
+	// statuses it contains after they return. It has no effect with HandlerTimeout.
+	PoolStatusRequests bool
+
+	// ReadyCheck, if set, reports whether the webhook is ready to handle
+	// notifications, e.g. whether its queue accepts work, see Mux.
+	ReadyCheck func(context.Context) error
+
+	// KeepRawBody keeps the request body in memory, so handlers can get it
+	// from WebhookContext.RawBody.
+	KeepRawBody bool
+
+	// OnUnknownEnum, if set, is called for every value of an enum-like field of
+	// a notification that this version of the package doesn't know, e.g. a new
+	// message type, before the notification is handled. See IsKnown.
+	OnUnknownEnum UnknownEnumHandler
+
+	// OnSchemaAnomaly, if set, validates every notification with
+	// ValidateWebhookPayload and is called for its anomalies, e.g. unknown
+	// or missing fields, before the notification is handled. It keeps the
+	// request body in memory like KeepRawBody.
+	OnSchemaAnomaly SchemaAnomalyHandler
+
+	// Moderator, if set, moderates the incoming messages before the
+	// notification is handled, see Moderator.
+	Moderator *Moderator
+
+	inflight inflight // inflight counts the notifications being handled, see Shutdown.
 }
 
 // NewWebhook creates a new WhatsApp webhook with the given parameters.
 func NewWebhook(webhookSecret, appSecret string, handler WebhookHandler) *Webhook {
-	wh := &Webhook{
+	return &Webhook{
 		WebhookSecret: webhookSecret,
 		AppSecret:     appSecret,
 		Handler:       handler,
 	}
-	wh.ErrHandler = wh
-	return wh
 }
 
 // ServeHTTP handles incoming HTTP requests for the WhatsApp webhook.
 func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := wh.checkSourceIP(r); err != nil {
+		if !wh.HandleWebhookErr(r.Context(), w, nil, err) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		}
+		return
+	}
 	switch r.Method {
 	case http.MethodGet:
 		wh.verifyChallenge(w, r)
@@ -85,70 +206,296 @@ func (wh *Webhook) HandleWebhookErr(ctx context.Context, w http.ResponseWriter,
 }
 
 func (wh *Webhook) verifyChallenge(w http.ResponseWriter, r *http.Request) {
-	mode := r.URL.Query().Get("hub.mode")
-	challenge := r.URL.Query().Get("hub.challenge")
-	verifyToken := r.URL.Query().Get("hub.verify_token")
+	query := r.URL.Query()
+	err := wh.checkChallenge(query)
+	if wh.OnVerify != nil {
+		wh.OnVerify(r, err)
+	}
 
-	if mode == "subscribe" && verifyToken == wh.WebhookSecret {
+	if err == nil {
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(challenge))
+		w.Write([]byte(query.Get("hub.challenge")))
 	} else {
 		w.WriteHeader(http.StatusForbidden)
 	}
 }
 
-// verifySignature verifies the X-Hub-Signature or X-Hub-Signature-256 header
-// against the request body using the webhook secret.
-func (wh *Webhook) verifySignature(r *http.Request, body []byte) bool {
+func (wh *Webhook) checkChallenge(query url.Values) error {
+	if mode := query.Get("hub.mode"); mode != "subscribe" {
+		return fmt.Errorf("unexpected hub.mode %q", mode)
+	}
+	verifyToken := query.Get("hub.verify_token")
+	if subtle.ConstantTimeCompare([]byte(verifyToken), []byte(wh.WebhookSecret)) != 1 {
+		return errors.New("invalid verify token")
+	}
+	if wh.VerifyQuery != nil {
+		if err := wh.VerifyQuery(query); err != nil {
+			return fmt.Errorf("verifying query: %w", err)
+		}
+	}
+	return nil
+}
+
+// signatureCheck computes the HMAC of the request body for every app secret
+// and compares them with the signature sent by Meta.
+type signatureCheck struct {
+	algorithm   string
+	expectedSig string
+	macs        []hash.Hash
+	unsigned    bool
+}
+
+// newSignatureCheck picks the signature from the X-Hub-Signature-256 or X-Hub-Signature
+// header according to the webhook signature policy. It returns nil if the request
+// carries no acceptable signature.
+func (wh *Webhook) newSignatureCheck(r *http.Request) *signatureCheck {
 	if signature := r.Header.Get("X-Hub-Signature-256"); signature != "" {
-		return wh.verifySignatureImpl(signature, "sha256=", body, sha256.New)
+		return wh.newSignatureCheckImpl(signature, "sha256", sha256.New)
 	}
 	if signature := r.Header.Get("X-Hub-Signature"); signature != "" {
-		return wh.verifySignatureImpl(signature, "sha1=", body, sha1.New)
+		if wh.RequireSHA256 {
+			return nil
+		}
+		return wh.newSignatureCheckImpl(signature, "sha1", sha1.New)
 	}
-	return false
+	if wh.AllowUnsigned {
+		return &signatureCheck{unsigned: true}
+	}
+	return nil
 }
 
-func (wh *Webhook) verifySignatureImpl(signature, prefix string, body []byte, hashFunc func() hash.Hash) bool {
-	expectedSig, foundPrefix := strings.CutPrefix(signature, prefix)
+func (wh *Webhook) newSignatureCheckImpl(signature, algorithm string, hashFunc func() hash.Hash) *signatureCheck {
+	expectedSig, foundPrefix := strings.CutPrefix(signature, algorithm+"=")
 	if !foundPrefix {
-		return false
+		return nil
+	}
+
+	sc := &signatureCheck{algorithm: algorithm, expectedSig: expectedSig}
+	for _, secret := range append([]string{wh.AppSecret}, wh.PreviousAppSecrets...) {
+		sc.macs = append(sc.macs, hmac.New(hashFunc, []byte(secret)))
+	}
+	return sc
+}
+
+// Write feeds the body into the HMAC of every app secret.
+func (sc *signatureCheck) Write(p []byte) (int, error) {
+	for _, mac := range sc.macs {
+		mac.Write(p)
+	}
+	return len(p), nil
+}
+
+// Verify reports whether the signature matches the body for any of the app secrets.
+func (sc *signatureCheck) Verify() bool {
+	if sc.unsigned {
+		return true
+	}
+	for _, mac := range sc.macs {
+		actualSig := hex.EncodeToString(mac.Sum(nil))
+		if hmac.Equal([]byte(sc.expectedSig), []byte(actualSig)) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxBodyBytes returns the effective request body limit, or zero for no limit.
+func (wh *Webhook) maxBodyBytes() int64 {
+	switch {
+	case wh.MaxBodyBytes < 0:
+		return 0
+	case wh.MaxBodyBytes == 0:
+		return DefaultWebhookMaxBodyBytes
+	default:
+		return wh.MaxBodyBytes
 	}
+}
 
-	mac := hmac.New(hashFunc, []byte(wh.AppSecret))
-	mac.Write(body)
-	actualSig := hex.EncodeToString(mac.Sum(nil))
+// errRecorder remembers the first read error other than io.EOF,
+// so it can be told apart from JSON decoding errors.
+type errRecorder struct {
+	r   io.Reader
+	err error
+}
 
-	return hmac.Equal([]byte(expectedSig), []byte(actualSig))
+func (er *errRecorder) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && err != io.EOF && er.err == nil {
+		er.err = err
+	}
+	return n, err
 }
 
 func (wh *Webhook) handleWebhookPOST(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
+	if !wh.inflight.acquire() {
+		// Meta redelivers the notification later, e.g. to another instance
+		if !wh.HandleWebhookErr(r.Context(), w, nil, ErrShuttingDown) {
+			http.Error(w, "Shutting down", http.StatusServiceUnavailable)
+		}
+		return
+	}
+	defer wh.inflight.release()
+
+	webhookContext := wh.newWebhookContext(r)
+	ctx := context.WithValue(r.Context(), webhookContextKey{}, webhookContext)
+
+	signature := wh.newSignatureCheck(r)
+	if signature == nil {
+		if !wh.HandleWebhookErr(ctx, w, nil, errors.New("invalid signature")) {
+			http.Error(w, "Invalid signature", http.StatusForbidden)
+		}
+		return
+	}
+
+	body := io.Reader(r.Body)
+	if limit := wh.maxBodyBytes(); limit > 0 {
+		body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	// The body is hashed while it is being decoded, so it is never buffered as a whole
+	// unless KeepRawBody is set.
+	// Whatever the decoder leaves unread is hashed as well, since the signature covers
+	// the whole body.
+	var rawBody bytes.Buffer
+	sink := io.Writer(signature)
+	if wh.KeepRawBody || wh.OnSchemaAnomaly != nil {
+		sink = io.MultiWriter(signature, &rawBody)
+	}
+	reader := &errRecorder{r: io.TeeReader(body, sink)}
+	request := wh.newWebhookRequest()
+	defer wh.releaseWebhookRequest(request)
+	decodeErr := decodeJSON(wh.JSONCodec, reader, request)
+	io.Copy(io.Discard, reader)
+
+	if err := reader.err; err != nil {
+		status, text := http.StatusBadRequest, "Failed to read request body"
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status, text = http.StatusRequestEntityTooLarge, "Request body too large"
+		}
 		err = fmt.Errorf("reading body: %w", err)
-		if !wh.HandleWebhookErr(r.Context(), w, nil, err) {
-			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		if !wh.HandleWebhookErr(ctx, w, nil, err) {
+			http.Error(w, text, status)
 		}
 		return
 	}
 
-	if !wh.verifySignature(r, body) {
-		if !wh.HandleWebhookErr(r.Context(), w, nil, errors.New("invalid signature")) {
+	if !signature.Verify() {
+		if !wh.HandleWebhookErr(ctx, w, nil, errors.New("invalid signature")) {
 			http.Error(w, "Invalid signature", http.StatusForbidden)
 		}
 		return
 	}
 
-	var request WebhookRequest
-	if err := json.Unmarshal(body, &request); err != nil {
-		err = fmt.Errorf("unmarshalling request body: %w", err)
-		if !wh.HandleWebhookErr(r.Context(), w, &request, err) {
+	webhookContext.SignatureAlgorithm = signature.algorithm
+	if wh.KeepRawBody {
+		webhookContext.rawBody = rawBody.Bytes()
+	}
+
+	if decodeErr != nil {
+		err := fmt.Errorf("unmarshalling request body: %w", decodeErr)
+		if !wh.HandleWebhookErr(ctx, w, request, err) {
 			http.Error(w, "Failed to parse request body", http.StatusBadRequest)
 		}
 		return
 	}
 
-	wh.Handler.HandleWebhook(r.Context(), w, &request)
+	if wh.OnSchemaAnomaly != nil {
+		wh.reportSchemaAnomalies(ctx, rawBody.Bytes())
+	}
+
+	if wh.replayCheckEnabled() {
+		onlyReplays, marked, err := wh.filterReplays(ctx, request)
+		if err != nil {
+			err = fmt.Errorf("checking replays: %w", err)
+			if !wh.HandleWebhookErr(ctx, w, request, err) {
+				http.Error(w, "Failed to check replays", http.StatusInternalServerError)
+			}
+			return
+		}
+		if onlyReplays && !wh.AllowReplays {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if len(marked) > 0 {
+			// The IDs are forgotten if handling fails, so the redelivery is handled
+			sw := &seenResponse{ResponseWriter: w}
+			w = sw
+			defer func() {
+				if v := recover(); v != nil {
+					wh.forgetSeen(ctx, marked)
+					panic(v)
+				}
+				if sw.failed() {
+					wh.forgetSeen(ctx, marked)
+				}
+			}()
+		}
+	}
+
+	if wh.OnUnknownEnum != nil {
+		request.reportUnknownEnums(ctx, wh.OnUnknownEnum)
+	}
+
+	if wh.Moderator != nil {
+		if err := wh.Moderator.moderate(ctx, request, webhookContext); err != nil {
+			if !wh.HandleWebhookErr(ctx, w, request, err) {
+				http.Error(w, "Failed to moderate messages", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	if wh.EventSink != nil {
+		if err := wh.EventSink.WriteEvent(ctx, request); err != nil {
+			err = fmt.Errorf("writing event: %w", err)
+			if !wh.HandleWebhookErr(ctx, w, request, err) {
+				http.Error(w, "Failed to write event", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	if wh.MessageStore != nil {
+		if err := wh.updateMessageStore(ctx, request); err != nil {
+			err = fmt.Errorf("updating message store: %w", err)
+			if !wh.HandleWebhookErr(ctx, w, request, err) {
+				http.Error(w, "Failed to update message store", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	if wh.HandlerTimeout > 0 {
+		wh.handleWithTimeout(ctx, w, request)
+		return
+	}
+	wh.dispatch(ctx, w, request)
+}
+
+// dispatch passes the request to ChangeHandler, if set, or Handler.
+func (wh *Webhook) dispatch(ctx context.Context, w http.ResponseWriter, request *WebhookRequest) {
+	if wh.ChangeHandler != nil {
+		wh.handleChanges(ctx, w, request)
+		return
+	}
+	wh.Handler.HandleWebhook(ctx, w, request)
+}
+
+// Start implements Lifecycle. The webhook handles notifications as soon as it
+// is registered with a server, so Start does nothing.
+func (wh *Webhook) Start(ctx context.Context) error {
+	return nil
+}
+
+// Shutdown implements Lifecycle. It answers further notifications with 503,
+// so Meta delivers them again later, reports not ready on the readiness endpoint
+// of Mux, and waits for the notifications being handled, including handlers
+// running past HandlerTimeout. Call it after shutting the HTTP server down, or
+// before if the server should drain while the load balancer picks up the
+// readiness change.
+func (wh *Webhook) Shutdown(ctx context.Context) error {
+	return wh.inflight.close(ctx)
 }
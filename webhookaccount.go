@@ -0,0 +1,182 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Webhook fields are the kinds of changes a notification reports, see WebhookChange.Field.
+// https://developers.facebook.com/docs/whatsapp/business-management-api/guides/set-up-webhooks
+const (
+	// WebhookFieldMessages reports messages, statuses and calls.
+	WebhookFieldMessages = "messages"
+	// WebhookFieldAccountReviewUpdate reports the decision of the review of a WhatsApp Business Account.
+	WebhookFieldAccountReviewUpdate = "account_review_update"
+	// WebhookFieldAccountUpdate reports changes of a WhatsApp Business Account,
+	// such as policy violations, restrictions and bans.
+	WebhookFieldAccountUpdate = "account_update"
+	// WebhookFieldAccountAlerts reports alerts about a WhatsApp Business Account or its phone numbers.
+	WebhookFieldAccountAlerts = "account_alerts"
+	// WebhookFieldPhoneNumberQualityUpdate reports changes of the quality and
+	// messaging limit of a phone number.
+	WebhookFieldPhoneNumberQualityUpdate = "phone_number_quality_update"
+)
+
+// AccountReviewDecision represents the decision of an account review.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#account_review_update
+type AccountReviewDecision string
+
+const (
+	// AccountReviewDecisionApproved represents an approved account.
+	AccountReviewDecisionApproved AccountReviewDecision = "APPROVED"
+	// AccountReviewDecisionRejected represents a rejected account.
+	AccountReviewDecisionRejected AccountReviewDecision = "REJECTED"
+	// AccountReviewDecisionPending represents a review in progress.
+	AccountReviewDecisionPending AccountReviewDecision = "PENDING"
+	// AccountReviewDecisionDeferred represents a review postponed by Meta.
+	AccountReviewDecisionDeferred AccountReviewDecision = "DEFERRED"
+)
+
+// AccountUpdateEvent represents the event of an account update.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#account_update
+type AccountUpdateEvent string
+
+const (
+	// AccountUpdateEventVerifiedAccount represents a business that passed verification.
+	AccountUpdateEventVerifiedAccount AccountUpdateEvent = "VERIFIED_ACCOUNT"
+	// AccountUpdateEventDisabledUpdate represents a change of the ban state, see WebhookValue.BanInfo.
+	AccountUpdateEventDisabledUpdate AccountUpdateEvent = "DISABLED_UPDATE"
+	// AccountUpdateEventAccountViolation represents a policy violation, see WebhookValue.ViolationInfo.
+	AccountUpdateEventAccountViolation AccountUpdateEvent = "ACCOUNT_VIOLATION"
+	// AccountUpdateEventAccountRestriction represents a restriction, see WebhookValue.RestrictionInfo.
+	AccountUpdateEventAccountRestriction AccountUpdateEvent = "ACCOUNT_RESTRICTION"
+	// AccountUpdateEventAccountDeleted represents a deleted account.
+	AccountUpdateEventAccountDeleted AccountUpdateEvent = "ACCOUNT_DELETED"
+	// AccountUpdateEventPartnerAdded represents a partner granted access to the account.
+	AccountUpdateEventPartnerAdded AccountUpdateEvent = "PARTNER_ADDED"
+	// AccountUpdateEventPartnerRemoved represents a partner removed from the account.
+	AccountUpdateEventPartnerRemoved AccountUpdateEvent = "PARTNER_REMOVED"
+)
+
+// BanState represents the ban state of a WhatsApp Business Account.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#account_update
+type BanState string
+
+const (
+	// BanStateScheduleForDisable represents an account that will be disabled at the ban date.
+	BanStateScheduleForDisable BanState = "SCHEDULE_FOR_DISABLE"
+	// BanStateDisable represents a disabled account.
+	BanStateDisable BanState = "DISABLE"
+	// BanStateReinstate represents an account enabled again.
+	BanStateReinstate BanState = "REINSTATE"
+)
+
+// BanStates are the ban states of an account. The field is documented as a
+// list, a single state is accepted as well.
+type BanStates []BanState
+
+// UnmarshalJSON decodes a list of ban states or a single one.
+func (s *BanStates) UnmarshalJSON(data []byte) error {
+	var state BanState
+	if err := json.Unmarshal(data, &state); err == nil {
+		*s = BanStates{state}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]BanState)(s))
+}
+
+// Contains reports whether state is one of the states.
+func (s BanStates) Contains(state BanState) bool {
+	return slices.Contains(s, state)
+}
+
+// RestrictionType represents an action a restricted account can't take.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#account_update
+type RestrictionType string
+
+const (
+	// RestrictionTypeAddPhoneNumber prevents adding phone numbers.
+	RestrictionTypeAddPhoneNumber RestrictionType = "RESTRICTED_ADD_PHONE_NUMBER_ACTION"
+	// RestrictionTypeBusinessInitiatedMessaging prevents business-initiated conversations.
+	RestrictionTypeBusinessInitiatedMessaging RestrictionType = "RESTRICTED_BIZ_INITIATED_MESSAGING"
+	// RestrictionTypeCustomerInitiatedMessaging prevents replying to customers.
+	RestrictionTypeCustomerInitiatedMessaging RestrictionType = "RESTRICTED_CUSTOMER_INITIATED_MESSAGING"
+)
+
+// AlertSeverity represents the severity of an account alert.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#account_alerts
+type AlertSeverity string
+
+const (
+	// AlertSeverityCritical represents an alert that requires action.
+	AlertSeverityCritical AlertSeverity = "CRITICAL"
+	// AlertSeverityWarning represents an alert that may require action.
+	AlertSeverityWarning AlertSeverity = "WARNING"
+	// AlertSeverityInformational represents an informational alert.
+	AlertSeverityInformational AlertSeverity = "INFORMATIONAL"
+)
+
+// WebhookBanInfo describes the ban state of an account in account_update notifications.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#account_update
+type WebhookBanInfo struct {
+	WABABanState BanStates `json:"waba_ban_state"`
+	WABABanDate  string    `json:"waba_ban_date,omitempty"`
+}
+
+// WebhookViolationInfo describes a policy violation in account_update notifications.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#account_update
+type WebhookViolationInfo struct {
+	ViolationType string `json:"violation_type"`
+}
+
+// WebhookRestriction describes a restriction in account_update notifications.
+// https://developers.facebook.com/docs/graph-api/webhooks/reference/whatsapp-business-account/#account_update
+type WebhookRestriction struct {
+	RestrictionType RestrictionType `json:"restriction_type"`
+	// Expiration is the time the restriction ends, as sent by Meta: Unix
+	// seconds or a date. See ExpirationTime.
+	Expiration string `json:"expiration,omitempty"`
+}
+
+// UnmarshalJSON decodes the restriction, accepting numbers and strings as expiration.
+func (r *WebhookRestriction) UnmarshalJSON(data []byte) error {
+	var restriction struct {
+		RestrictionType RestrictionType `json:"restriction_type"`
+		Expiration      json.RawMessage `json:"expiration"`
+	}
+	if err := json.Unmarshal(data, &restriction); err != nil {
+		return err
+	}
+	*r = WebhookRestriction{RestrictionType: restriction.RestrictionType}
+	if len(restriction.Expiration) > 0 && string(restriction.Expiration) != "null" {
+		if err := json.Unmarshal(restriction.Expiration, &r.Expiration); err != nil {
+			r.Expiration = string(restriction.Expiration)
+		}
+	}
+	return nil
+}
+
+// ExpirationTime returns the time the restriction ends, or the zero time if
+// the expiration is missing or can't be parsed.
+func (r *WebhookRestriction) ExpirationTime() time.Time {
+	if t, ok := parseUnixTime(r.Expiration); ok {
+		return t
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, r.Expiration); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Restricted reports whether the notification restricts the action.
+func (v *WebhookValue) Restricted(restriction RestrictionType) bool {
+	for _, r := range v.RestrictionInfo {
+		if r.RestrictionType == restriction {
+			return true
+		}
+	}
+	return false
+}
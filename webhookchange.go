@@ -0,0 +1,108 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// WebhookChangeHandler handles a single change of a webhook notification.
+// See Webhook.ChangeHandler.
+//
+// Example usage:
+//
+//	webhook := NewWebhook(verifyToken, appSecret, nil)
+//	webhook.ChangeHandler = WebhookChangeHandlerFunc(func(ctx context.Context, entry *WebhookEntry, change *WebhookChange) error {
+//	    return store.SaveMessages(ctx, change.Value.Messages)
+//	})
+//	webhook.ErrHandler = WebhookErrHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *WebhookRequest, err error) bool {
+//	    log.Printf("webhook: %v", err)
+//	    return false
+//	})
+type WebhookChangeHandler interface {
+	HandleWebhookChange(ctx context.Context, entry *WebhookEntry, change *WebhookChange) error
+}
+
+// WebhookChangeHandlerFunc is a function type that implements the WebhookChangeHandler interface.
+type WebhookChangeHandlerFunc func(ctx context.Context, entry *WebhookEntry, change *WebhookChange) error
+
+// HandleWebhookChange calls the function with the given parameters.
+func (f WebhookChangeHandlerFunc) HandleWebhookChange(ctx context.Context, entry *WebhookEntry, change *WebhookChange) error {
+	return f(ctx, entry, change)
+}
+
+// WebhookChangeError is reported to the ErrHandler of a Webhook for every
+// change its ChangeHandler failed to handle, returned an error for or panicked on.
+type WebhookChangeError struct {
+	// EntryID is the ID of the entry, i.e. the WhatsApp Business Account ID.
+	EntryID string
+	// Field is the field of the change, e.g. "messages".
+	Field string
+	// Entry and Change are the positions of the entry in the request and of the change in the entry.
+	Entry, Change int
+	// Err is the error returned by the handler, or describes the panic.
+	Err error
+	// Stack is the stack trace of the panic, or nil if the handler returned an error.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *WebhookChangeError) Error() string {
+	return fmt.Sprintf("entry %s (%d), change %d (%s): %v", e.EntryID, e.Entry, e.Change, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *WebhookChangeError) Unwrap() error {
+	return e.Err
+}
+
+// handleChanges passes every change of request to ChangeHandler in isolation.
+// Failures are reported to ErrHandler with a request containing only the
+// failed change, and don't stop the remaining changes. The request is always
+// acknowledged, so Meta doesn't redeliver the changes that succeeded.
+func (wh *Webhook) handleChanges(ctx context.Context, w http.ResponseWriter, request *WebhookRequest) {
+	for i := range request.Entry {
+		entry := &request.Entry[i]
+		for j := range entry.Changes {
+			change := &entry.Changes[j]
+			err := wh.handleChange(ctx, entry, change)
+			if err == nil {
+				continue
+			}
+			err.Entry, err.Change = i, j
+			failed := &WebhookRequest{
+				Object: request.Object,
+				Entry:  []WebhookEntry{{ID: entry.ID, Changes: []WebhookChange{*change}}},
+			}
+			wh.HandleWebhookErr(ctx, discardResponseWriter{}, failed, err)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleChange calls ChangeHandler, recovering from panics.
+func (wh *Webhook) handleChange(ctx context.Context, entry *WebhookEntry, change *WebhookChange) (changeErr *WebhookChangeError) {
+	defer func() {
+		if p := recover(); p != nil {
+			changeErr = &WebhookChangeError{
+				EntryID: entry.ID,
+				Field:   change.Field,
+				Err:     fmt.Errorf("panic: %v", p),
+				Stack:   debug.Stack(),
+			}
+		}
+	}()
+	if err := wh.ChangeHandler.HandleWebhookChange(ctx, entry, change); err != nil {
+		return &WebhookChangeError{EntryID: entry.ID, Field: change.Field, Err: err}
+	}
+	return nil
+}
+
+// discardResponseWriter is passed to ErrHandler for change errors, since the
+// response to the request is written after all changes were handled.
+type discardResponseWriter struct{}
+
+func (discardResponseWriter) Header() http.Header         { return http.Header{} }
+func (discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (discardResponseWriter) WriteHeader(int)             {}
@@ -0,0 +1,55 @@
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WebhookContext describes the HTTP request a webhook notification was received
+// with. Webhook adds it to the context passed to handlers, so logging and audit
+// code can use it without the original request.
+//
+// Example usage:
+//
+//	handler := WebhookHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *WebhookRequest) {
+//	    if wc, ok := WebhookContextFrom(ctx); ok {
+//	        log.Printf("notification from %s received at %s, signed with %s",
+//	            wc.RemoteIP, wc.ReceivedAt, wc.SignatureAlgorithm)
+//	    }
+//	})
+type WebhookContext struct {
+	// RemoteIP is the IP address of the client, taken from the connection, or
+	// from X-Forwarded-For if the Webhook has TrustedProxies.
+	RemoteIP string
+	// ReceivedAt is the time the request was received.
+	ReceivedAt time.Time
+	// SignatureAlgorithm is "sha256" or "sha1" depending on the verified
+	// signature header, or empty for unsigned requests, see AllowUnsigned.
+	SignatureAlgorithm string
+
+	rawBody []byte
+	flagged map[string]ModerationVerdict // flagged are the verdicts of flagged messages by ID.
+}
+
+// RawBody returns the request body as received. It is only kept if the
+// Webhook has KeepRawBody set, and nil otherwise. The returned slice must not be modified.
+func (wc *WebhookContext) RawBody() []byte {
+	return wc.rawBody
+}
+
+// webhookContextKey is the context key of the WebhookContext.
+type webhookContextKey struct{}
+
+// WebhookContextFrom returns the WebhookContext of ctx, if any.
+func WebhookContextFrom(ctx context.Context) (*WebhookContext, bool) {
+	wc, ok := ctx.Value(webhookContextKey{}).(*WebhookContext)
+	return wc, ok
+}
+
+// newWebhookContext returns the WebhookContext of r.
+func (wh *Webhook) newWebhookContext(r *http.Request) *WebhookContext {
+	// RemoteIP stays empty for requests that passed fewer proxies than trusted
+	remoteIP, _ := wh.clientIP(r)
+	return &WebhookContext{RemoteIP: remoteIP, ReceivedAt: time.Now()}
+}
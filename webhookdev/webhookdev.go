@@ -0,0 +1,201 @@
+// Package webhookdev records webhook notifications and replays them against a
+// handler, for offline development and regression tests with production-shaped
+// traffic.
+//
+// A Recorder sits in front of the webhook, e.g. behind a tunnel to a development
+// machine, and stores every notification body in a directory. A Replayer sends
+// the stored notifications again, signed with the app secret of the handler
+// under test, so its signature verification stays enabled.
+//
+// Example usage:
+//
+//	// Record while developing against live traffic
+//	recorder := webhookdev.NewRecorder("testdata/webhooks", webhook)
+//	log.Fatal(http.ListenAndServe(":8080", recorder))
+//
+//	// Replay later against a local handler
+//	replayer := &webhookdev.Replayer{AppSecret: "test-secret", Handler: webhook}
+//	results, err := replayer.Replay(ctx, "testdata/webhooks")
+//
+// Notifications are replayed with their original timestamps, so disable the
+// ReplayWindow of the webhook, or set AllowReplays, when replaying old recordings.
+package webhookdev
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBodyBytes limits the size of recorded notifications.
+const DefaultMaxBodyBytes = 4 << 20
+
+// Recorder is an http.Handler that stores the body of every POST request in a
+// directory before passing the request on to the next handler. Files are named
+// after the time they were received, so they sort in arrival order.
+// Notifications contain phone numbers and message texts, so the files and the
+// directory, if created, are only accessible by the owner.
+type Recorder struct {
+	// Dir is the directory the notifications are stored in.
+	Dir string
+	// Next handles the requests, typically a *whatsapp.Webhook. If nil, requests are answered with 200.
+	Next http.Handler
+	// OnError, if set, is called if a notification can't be stored. The request is still passed on.
+	OnError func(error)
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder creates a recorder storing notifications in dir, which is created if needed.
+func NewRecorder(dir string, next http.Handler) *Recorder {
+	return &Recorder{Dir: dir, Next: next}
+}
+
+// ServeHTTP records POST requests and passes all requests to Next.
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(io.LimitReader(r.Body, DefaultMaxBodyBytes+1))
+		r.Body.Close()
+		if err == nil && len(body) > DefaultMaxBodyBytes {
+			err = fmt.Errorf("notification exceeds %d bytes", DefaultMaxBodyBytes)
+		}
+		if err == nil {
+			err = rec.store(body)
+		}
+		if err != nil && rec.OnError != nil {
+			rec.OnError(fmt.Errorf("recording notification: %w", err))
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if rec.Next == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	rec.Next.ServeHTTP(w, r)
+}
+
+// store writes body to a new file in Dir.
+func (rec *Recorder) store(body []byte) error {
+	rec.mu.Lock()
+	rec.seq++
+	name := fmt.Sprintf("%s-%06d.json", time.Now().UTC().Format("20060102T150405.000000000"), rec.seq)
+	rec.mu.Unlock()
+
+	if err := os.MkdirAll(rec.Dir, 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rec.Dir, name), body, 0o600)
+}
+
+// Result is the outcome of replaying a recorded notification.
+type Result struct {
+	// File is the path of the recorded notification.
+	File string
+	// StatusCode is the status the handler answered with.
+	StatusCode int
+	// Err is the error sending the notification, if any.
+	Err error
+}
+
+// Replayer sends recorded notifications to a handler, signed with AppSecret.
+type Replayer struct {
+	// AppSecret signs the notifications with the X-Hub-Signature-256 header.
+	AppSecret string
+	// Handler, if set, receives the notifications in-process.
+	Handler http.Handler
+	// URL, if Handler is nil, is the webhook URL the notifications are posted to.
+	URL string
+	// Client sends the notifications to URL. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Replay sends the notifications stored in dir, in order of their file names.
+// It stops at the first notification that can't be sent. Notifications the
+// handler rejects are reported with their status code only.
+func (rp *Replayer) Replay(ctx context.Context, dir string) ([]Result, error) {
+	if rp.Handler == nil && rp.URL == "" {
+		return nil, fmt.Errorf("either Handler or URL is required")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	slices.Sort(files)
+
+	results := make([]Result, 0, len(files))
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return results, err
+		}
+		status, err := rp.send(ctx, body)
+		results = append(results, Result{File: file, StatusCode: status, Err: err})
+		if err != nil {
+			return results, fmt.Errorf("replaying %s: %w", file, err)
+		}
+	}
+	return results, nil
+}
+
+// Sign returns the X-Hub-Signature-256 header value of body for appSecret.
+func Sign(body []byte, appSecret string) string {
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send posts body to the handler and returns the response status.
+func (rp *Replayer) send(ctx context.Context, body []byte) (int, error) {
+	target := rp.URL
+	if rp.Handler != nil {
+		target = "http://webhookdev.local/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature-256", Sign(body, rp.AppSecret))
+
+	if rp.Handler != nil {
+		req.RemoteAddr = "127.0.0.1:0"
+		recorder := httptest.NewRecorder()
+		rp.Handler.ServeHTTP(recorder, req)
+		return recorder.Code, nil
+	}
+
+	client := rp.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,85 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ErrSourceIPNotAllowed is reported to the ErrHandler of a Webhook for
+// requests from addresses outside of AllowedNetworks.
+var ErrSourceIPNotAllowed = errors.New("source IP not allowed")
+
+// ParseNetworks parses CIDR prefixes like "157.240.0.0/16" for the
+// AllowedNetworks of a Webhook. Single addresses are accepted as well.
+//
+// Meta publishes no fixed list of webhook addresses. The prefixes announced by
+// its autonomous system can be listed with:
+//
+//	whois -h whois.radb.net -- '-i origin AS32934' | grep ^route
+func ParseNetworks(cidrs ...string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", cidr, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// clientIP returns the address of the client of r. With TrustedProxies, it is
+// taken from the X-Forwarded-For entries added by the trusted proxies, counted
+// from the connection backwards.
+func (wh *Webhook) clientIP(r *http.Request) (string, error) {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+	if wh.TrustedProxies <= 0 {
+		return remoteIP, nil
+	}
+
+	var chain []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, ip := range strings.Split(header, ",") {
+			chain = append(chain, strings.TrimSpace(ip))
+		}
+	}
+	chain = append(chain, remoteIP)
+	if len(chain) <= wh.TrustedProxies {
+		return "", fmt.Errorf("request passed %d proxies, expected %d trusted proxies", len(chain)-1, wh.TrustedProxies)
+	}
+	return chain[len(chain)-1-wh.TrustedProxies], nil
+}
+
+// checkSourceIP checks the client address of r against AllowedNetworks.
+func (wh *Webhook) checkSourceIP(r *http.Request) error {
+	if len(wh.AllowedNetworks) == 0 {
+		return nil
+	}
+	ip, err := wh.clientIP(r)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSourceIPNotAllowed, err)
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address %q", ErrSourceIPNotAllowed, ip)
+	}
+	addr = addr.Unmap()
+	for _, prefix := range wh.AllowedNetworks {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSourceIPNotAllowed, addr)
+}
@@ -0,0 +1,66 @@
+package whatsapp
+
+import (
+	"sync"
+)
+
+// statusRequestPool holds decoded requests that contained statuses only, for reuse by PoolStatusRequests.
+var statusRequestPool = sync.Pool{
+	New: func() any { return new(WebhookRequest) },
+}
+
+// newWebhookRequest returns the request to decode a notification into.
+func (wh *Webhook) newWebhookRequest() *WebhookRequest {
+	if !wh.poolStatusRequests() {
+		return new(WebhookRequest)
+	}
+	return statusRequestPool.Get().(*WebhookRequest)
+}
+
+// releaseWebhookRequest returns request to the pool if it contains statuses
+// only. Requests with messages or other notifications are more likely to be
+// retained by handlers and are left to the garbage collector.
+func (wh *Webhook) releaseWebhookRequest(request *WebhookRequest) {
+	if !wh.poolStatusRequests() || !request.statusesOnly() {
+		return
+	}
+	request.reset()
+	statusRequestPool.Put(request)
+}
+
+// poolStatusRequests reports whether decoded requests are pooled. Handlers
+// running past HandlerTimeout may still use the request after it was answered.
+func (wh *Webhook) poolStatusRequests() bool {
+	return wh.PoolStatusRequests && wh.HandlerTimeout <= 0
+}
+
+// statusesOnly reports whether the request contains status updates and nothing else.
+func (r *WebhookRequest) statusesOnly() bool {
+	statuses := false
+	for _, entry := range r.Entry {
+		for _, change := range entry.Changes {
+			value := &change.Value
+			if len(value.Messages) > 0 || len(value.Calls) > 0 || len(value.Contacts) > 0 || len(value.Errors) > 0 {
+				return false
+			}
+			statuses = statuses || len(value.Statuses) > 0
+		}
+	}
+	return statuses
+}
+
+// reset zeroes the request, keeping the arrays of its entries, changes and
+// statuses, which the JSON decoder reuses. Everything they referenced is released.
+func (r *WebhookRequest) reset() {
+	entries := r.Entry[:cap(r.Entry)]
+	for i := range entries {
+		changes := entries[i].Changes[:cap(entries[i].Changes)]
+		for j := range changes {
+			statuses := changes[j].Value.Statuses[:cap(changes[j].Value.Statuses)]
+			clear(statuses)
+			changes[j] = WebhookChange{Value: WebhookValue{Statuses: statuses[:0]}}
+		}
+		entries[i] = WebhookEntry{Changes: changes[:0]}
+	}
+	*r = WebhookRequest{Entry: entries[:0]}
+}
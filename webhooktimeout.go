@@ -0,0 +1,122 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// handleWithTimeout runs the handler of request in the background and waits
+// for it up to HandlerTimeout. Responses of handlers that finish in time are
+// passed on, otherwise the request is acknowledged with 200 and the handler's
+// response is discarded. OnHandlerDone is called when the handler finishes.
+func (wh *Webhook) handleWithTimeout(ctx context.Context, w http.ResponseWriter, request *WebhookRequest) {
+	// The handler may outlive the request, whose context is canceled once it is answered.
+	ctx = context.WithoutCancel(ctx)
+	tw := &timeoutWriter{header: make(http.Header)}
+	done := make(chan any, 1)
+	start := time.Now()
+
+	wh.inflight.add()
+	go func() {
+		defer wh.inflight.release()
+		defer func() {
+			panicValue := recover()
+			timedOut := tw.finish()
+			if timedOut && panicValue != nil {
+				err := fmt.Errorf("handler panicked after the request timed out: %v", panicValue)
+				wh.HandleWebhookErr(ctx, discardResponseWriter{}, request, err)
+			}
+			if wh.OnHandlerDone != nil {
+				wh.OnHandlerDone(ctx, request, time.Since(start), timedOut)
+			}
+			done <- panicValue
+		}()
+		wh.dispatch(ctx, tw, request)
+	}()
+
+	timer := time.NewTimer(wh.HandlerTimeout)
+	defer timer.Stop()
+	select {
+	case panicValue := <-done:
+		if panicValue != nil {
+			panic(panicValue)
+		}
+		tw.writeTo(w)
+	case <-timer.C:
+		if tw.timeout() {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		// The handler finished just in time
+		if panicValue := <-done; panicValue != nil {
+			panic(panicValue)
+		}
+		tw.writeTo(w)
+	}
+}
+
+// timeoutWriter buffers the response of a handler until it finishes or times out.
+type timeoutWriter struct {
+	header http.Header
+
+	mu       sync.Mutex
+	status   int
+	body     bytes.Buffer
+	finished bool
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return len(p), nil
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.status == 0 {
+		tw.status = status
+	}
+}
+
+// finish marks the handler as finished and reports whether it timed out before.
+func (tw *timeoutWriter) finish() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.finished = true
+	return tw.timedOut
+}
+
+// timeout marks the handler as timed out, unless it finished before. It reports whether it did.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut = !tw.finished
+	return tw.timedOut
+}
+
+// writeTo writes the buffered response to w.
+func (tw *timeoutWriter) writeTo(w http.ResponseWriter) {
+	for key, values := range tw.header {
+		w.Header()[key] = values
+	}
+	if tw.status != 0 {
+		w.WriteHeader(tw.status)
+	}
+	w.Write(tw.body.Bytes())
+}
@@ -0,0 +1,134 @@
+// Package whatsapptest provides helpers for testing code built on the
+// whatsapp package, such as golden files of the payloads sent to the Cloud
+// API, to catch accidental changes of the messages across library upgrades.
+//
+// Example usage:
+//
+//	func TestOrderConfirmation(t *testing.T) {
+//	    var payloads [][]byte
+//	    client := whatsapp.NewClient("token", "123", whatsapp.WithDryRun(func(request *whatsapp.Request, payload []byte) {
+//	        payloads = append(payloads, payload)
+//	    }))
+//	    if err := sendOrderConfirmation(ctx, client, order); err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    whatsapptest.AssertGolden(t, "testdata/order_confirmation.golden.json", payloads)
+//	}
+//
+// Run the tests with UPDATE_GOLDEN=1 to write the golden files, and review
+// the changes like code.
+package whatsapptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Update makes AssertGolden write the golden files instead of comparing with
+// them. It is set if the UPDATE_GOLDEN environment variable is not empty,
+// and tests may set it, e.g. from a flag of their own.
+var Update = os.Getenv("UPDATE_GOLDEN") != ""
+
+// MarshalGolden returns the JSON encoding of v in a deterministic format: object
+// keys sorted, two-space indentation, numbers as encoded, no HTML escaping,
+// and a final newline. Byte slices and json.RawMessage values, such as the
+// payloads passed to a whatsapp.DryRunFunc, are taken as JSON and
+// reformatted, and so are the elements of slices of them.
+func MarshalGolden(v any) ([]byte, error) {
+	var data []byte
+	switch v := v.(type) {
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	case [][]byte:
+		payloads := make([]json.RawMessage, len(v))
+		for i, payload := range v {
+			payloads[i] = payload
+		}
+		return MarshalGolden(payloads)
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	// Decoding into maps sorts the keys when encoding again
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("decoding JSON: %w", err)
+	}
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// AssertGolden reports a test error if the MarshalGolden encoding of v differs
+// from the content of the golden file at path, showing the first line that
+// differs. With Update, it writes the file instead, creating its directory.
+func AssertGolden(t testing.TB, path string, v any) {
+	t.Helper()
+	got, err := MarshalGolden(v)
+	if err != nil {
+		t.Fatalf("encoding golden %s: %v", path, err)
+	}
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("updating golden %s: %v", path, err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("updating golden %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("golden %s does not exist; run the test with UPDATE_GOLDEN=1 to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("reading golden %s: %v", path, err)
+	}
+	// Golden files checked out on Windows may have CRLF line endings
+	want = bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n"))
+	if diff := firstDifference(string(got), string(want)); diff != "" {
+		t.Errorf("payload differs from golden %s:\n%s\nrun the test with UPDATE_GOLDEN=1 to accept the change", path, diff)
+	}
+}
+
+// firstDifference describes the first line that differs between got and
+// want, or returns "" if they are equal.
+func firstDifference(got, want string) string {
+	if got == want {
+		return ""
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := range max(len(gotLines), len(wantLines)) {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w || i >= len(gotLines) || i >= len(wantLines) {
+			return fmt.Sprintf("line %d:\n  got:  %s\n  want: %s", i+1, g, w)
+		}
+	}
+	return ""
+}